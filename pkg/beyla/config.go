@@ -1,6 +1,8 @@
 package beyla
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"io"
 	"os"
@@ -17,6 +19,7 @@ import (
 	"github.com/grafana/beyla/pkg/internal/export/prom"
 	"github.com/grafana/beyla/pkg/internal/filter"
 	"github.com/grafana/beyla/pkg/internal/imetrics"
+	"github.com/grafana/beyla/pkg/internal/request"
 	"github.com/grafana/beyla/pkg/internal/traces"
 	"github.com/grafana/beyla/pkg/services"
 	"github.com/grafana/beyla/pkg/transform"
@@ -71,6 +74,9 @@ var DefaultConfig = Config{
 		MaxQueueSize:       4096,
 		MaxExportBatchSize: 4096,
 		ReportersCacheLen:  ReporterLRUSize,
+		// EmitDistroAttributes is enabled by default
+		EmitDistroAttributes: true,
+		AlwaysSampleErrors:   true,
 	},
 	Prometheus: prom.PrometheusConfig{
 		Path:                        "/metrics",
@@ -158,6 +164,9 @@ type Consumer interface {
 
 type TracesReceiverConfig struct {
 	Traces []Consumer
+	// KnownHTTPMethods is explicitly set up from the otel_traces_export configuration before building
+	// the graph, so the forwarded traces report the same HTTP methods as the exported traces
+	KnownHTTPMethods request.HTTPMethods
 }
 
 func (t TracesReceiverConfig) Enabled() bool {
@@ -192,6 +201,15 @@ func (c *Config) Validate() error {
 	if c.EBPF.BatchLength == 0 {
 		return ConfigError("BEYLA_BPF_BATCH_LENGTH must be at least 1")
 	}
+	if err := c.Grafana.OTLP.Validate(); err != nil {
+		return ConfigError(err.Error())
+	}
+	if err := c.Traces.Validate(); err != nil {
+		return ConfigError(err.Error())
+	}
+	if err := c.Metrics.Validate(); err != nil {
+		return ConfigError(err.Error())
+	}
 
 	if c.Enabled(FeatureNetO11y) && !c.Grafana.OTLP.MetricsEnabled() && !c.Metrics.Enabled() &&
 		!c.Prometheus.Enabled() && !c.NetworkFlows.Print {
@@ -249,3 +267,15 @@ func LoadConfig(file io.Reader) (*Config, error) {
 
 	return &cfg, nil
 }
+
+// Fingerprint returns a hash of the effective configuration, so two Beyla instances
+// with the same configuration return the same fingerprint.
+func (c *Config) Fingerprint() (string, error) {
+	// the YAML encoder sorts the map keys, so equal configurations are serialized equally
+	cfgBuf, err := yaml.Marshal(c)
+	if err != nil {
+		return "", fmt.Errorf("serializing configuration: %w", err)
+	}
+	sum := sha256.Sum256(cfgBuf)
+	return hex.EncodeToString(sum[:]), nil
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"regexp"
+	"strings"
 	"testing"
 	"time"
 
@@ -127,6 +128,9 @@ network:
 			MaxQueueSize:       4096,
 			MaxExportBatchSize: 4096,
 			ReportersCacheLen:  ReporterLRUSize,
+			// EmitDistroAttributes is enabled by default
+			EmitDistroAttributes: true,
+			AlwaysSampleErrors:   true,
 		},
 		Prometheus: prom.PrometheusConfig{
 			Path:                        "/metrics",
@@ -196,6 +200,8 @@ func TestConfigValidate_error(t *testing.T) {
 	testCases := []map[string]string{
 		{"OTEL_EXPORTER_OTLP_ENDPOINT": "localhost:1234", "INSTRUMENT_FUNC_NAME": "bar"},
 		{"BEYLA_EXECUTABLE_NAME": "foo", "INSTRUMENT_FUNC_NAME": "bar", "BEYLA_PRINT_TRACES": "false"},
+		{"BEYLA_EXECUTABLE_NAME": "foo", "OTEL_EXPORTER_OTLP_ENDPOINT": "localhost:1234", "BEYLA_OTLP_TRACES_IP_FAMILY": "ipv5"},
+		{"BEYLA_EXECUTABLE_NAME": "foo", "OTEL_EXPORTER_OTLP_METRICS_ENDPOINT": "localhost:1234", "OTEL_EXPORTER_OTLP_METRICS_PROTOCOL": "file"},
 	}
 	for n, tc := range testCases {
 		t.Run(fmt.Sprint("case", n), func(t *testing.T) {
@@ -268,6 +274,37 @@ func TestConfig_OtelGoAutoEnv(t *testing.T) {
 	assert.True(t, cfg.Exec.IsSet()) // Exec maps to BEYLA_EXECUTABLE_NAME
 }
 
+func TestConfig_Fingerprint(t *testing.T) {
+	fingerprint := func(yamlCfg string) string {
+		cfg, err := LoadConfig(strings.NewReader(yamlCfg))
+		require.NoError(t, err)
+		fp, err := cfg.Fingerprint()
+		require.NoError(t, err)
+		return fp
+	}
+	base := fingerprint(`
+otel_traces_export:
+  endpoint: http://otelcol:4318
+  sampler:
+    name: traceidratio
+    arg: "0.1"
+`)
+	assert.NotEmpty(t, base)
+	// same configuration, differently formatted
+	assert.Equal(t, base, fingerprint(`
+otel_traces_export:
+  sampler: { arg: "0.1", name: traceidratio }
+  endpoint: http://otelcol:4318
+`))
+	assert.NotEqual(t, base, fingerprint(`
+otel_traces_export:
+  endpoint: http://otelcol:4318
+  sampler:
+    name: traceidratio
+    arg: "0.5"
+`))
+}
+
 func loadConfig(t *testing.T, env map[string]string) *Config {
 	for k, v := range env {
 		require.NoError(t, os.Setenv(k, v))
@@ -0,0 +1,14 @@
+package beyla
+
+import (
+	"go.opentelemetry.io/otel/sdk/trace"
+
+	"github.com/grafana/beyla/pkg/internal/export/otel"
+)
+
+// RegisterSampler makes a custom traces sampler available by the provided name, so it can be
+// selected from the traces sampler configuration. The factory receives the sampler arguments.
+// It must be invoked before Beyla starts, and panics if the name is already in use.
+func RegisterSampler(name string, factory func(args map[string]any) trace.Sampler) {
+	otel.RegisterSampler(name, factory)
+}
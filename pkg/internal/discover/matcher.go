@@ -229,10 +229,13 @@ var processInfo = func(pp processAttrs) (*services.ProcessInfo, error) {
 			return nil, fmt.Errorf("can't read /proc/<pid>/fd information: %w", err)
 		}
 	}
+	// the command line is only informative, so errors are ignored
+	cmdLine, _ := proc.Cmdline()
 	return &services.ProcessInfo{
 		Pid:       proc.Pid,
 		PPid:      ppid,
 		ExePath:   exePath,
 		OpenPorts: pp.openPorts,
+		CmdLine:   cmdLine,
 	}, nil
 }
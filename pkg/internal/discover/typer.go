@@ -69,7 +69,12 @@ func (t *typer) FilterClassify(evs []Event[ProcessMatch]) []Event[Instrumentable
 		ev := &evs[i]
 		switch evs[i].Type {
 		case EventCreated:
-			svcID := svc.ID{Name: ev.Obj.Criteria.Name, Namespace: ev.Obj.Criteria.Namespace}
+			svcID := svc.ID{
+				Name:      ev.Obj.Criteria.Name,
+				Namespace: ev.Obj.Criteria.Namespace,
+				ExePath:   ev.Obj.Process.ExePath,
+				CmdLine:   ev.Obj.Process.CmdLine,
+			}
 			if elfFile, err := exec.FindExecELF(ev.Obj.Process, svcID); err != nil {
 				t.log.Warn("error finding process ELF. Ignoring", "error", err)
 			} else {
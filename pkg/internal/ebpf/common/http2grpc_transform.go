@@ -55,10 +55,13 @@ func protocolIsGRPC(conn *BPFConnInfo) {
 	activeGRPCConnections.Add(*conn, GRPC)
 }
 
-func readMetaFrame(conn *BPFConnInfo, fr *http2.Framer, hf *http2.HeadersFrame) (string, string, Protocol) {
+// readMetaFrame returns the method, path and protocol of the request, as well as the rest of
+// non-pseudo headers (e.g. the gRPC metadata), with lowercase keys.
+func readMetaFrame(conn *BPFConnInfo, fr *http2.Framer, hf *http2.HeadersFrame) (string, string, Protocol, map[string][]string) {
 	method := ""
 	path := ""
 	proto := defaultProtocol(conn)
+	var headers map[string][]string
 
 	hdec.SetEmitFunc(func(hf hpack.HeaderField) {
 		hfKey := strings.ToLower(hf.Name)
@@ -73,6 +76,12 @@ func readMetaFrame(conn *BPFConnInfo, fr *http2.Framer, hf *http2.HeadersFrame)
 				proto = GRPC
 			}
 		}
+		if !strings.HasPrefix(hfKey, ":") {
+			if headers == nil {
+				headers = map[string][]string{}
+			}
+			headers[hfKey] = append(headers[hfKey], hf.Value)
+		}
 	})
 	// Lose reference to MetaHeadersFrame:
 	defer hdec.SetEmitFunc(func(_ hpack.HeaderField) {})
@@ -80,18 +89,18 @@ func readMetaFrame(conn *BPFConnInfo, fr *http2.Framer, hf *http2.HeadersFrame)
 	for {
 		frag := hf.HeaderBlockFragment()
 		if _, err := hdec.Write(frag); err != nil {
-			return method, path, proto
+			return method, path, proto, headers
 		}
 
 		if hf.HeadersEnded() {
 			break
 		}
 		if _, err := fr.ReadFrame(); err != nil {
-			return method, path, proto
+			return method, path, proto, headers
 		}
 	}
 
-	return method, path, proto
+	return method, path, proto, headers
 }
 
 func http2grpcStatus(status int) int {
@@ -105,8 +114,10 @@ func http2grpcStatus(status int) int {
 	return 2 // Unknown
 }
 
-func readRetMetaFrame(conn *BPFConnInfo, fr *http2.Framer, hf *http2.HeadersFrame) (int, Protocol) {
+// readRetMetaFrame returns the status, the content type and the protocol of the response
+func readRetMetaFrame(conn *BPFConnInfo, fr *http2.Framer, hf *http2.HeadersFrame) (int, string, Protocol) {
 	status := 0
+	contentType := ""
 	proto := defaultProtocol(conn)
 
 	hdec.SetEmitFunc(func(hf hpack.HeaderField) {
@@ -122,6 +133,8 @@ func readRetMetaFrame(conn *BPFConnInfo, fr *http2.Framer, hf *http2.HeadersFram
 			status, _ = strconv.Atoi(hf.Value)
 			protocolIsGRPC(conn)
 			proto = GRPC
+		case "content-type":
+			contentType = hf.Value
 		}
 	})
 	// Lose reference to MetaHeadersFrame:
@@ -130,23 +143,27 @@ func readRetMetaFrame(conn *BPFConnInfo, fr *http2.Framer, hf *http2.HeadersFram
 	for {
 		frag := hf.HeaderBlockFragment()
 		if _, err := hdec.Write(frag); err != nil {
-			return status, proto
+			return status, contentType, proto
 		}
 
 		if hf.HeadersEnded() {
 			break
 		}
 		if _, err := fr.ReadFrame(); err != nil {
-			return status, proto
+			return status, contentType, proto
 		}
 	}
 
-	return status, proto
+	return status, contentType, proto
 }
 
 var genericServiceID = svc.ID{SDKLanguage: svc.InstrumentableGeneric}
 
 func http2InfoToSpan(info *BPFHTTP2Info, method, path, peer, host string, status int, protocol Protocol) request.Span {
+	detector := request.DetectorHTTP2Parser
+	if protocol == GRPC {
+		detector = request.DetectorGRPCParser
+	}
 	return request.Span{
 		Type:          info.eventType(protocol),
 		ID:            0,
@@ -155,6 +172,7 @@ func http2InfoToSpan(info *BPFHTTP2Info, method, path, peer, host string, status
 		Peer:          peer,
 		Host:          host,
 		HostPort:      int(info.ConnInfo.D_port),
+		PeerPort:      int(info.ConnInfo.S_port),
 		ContentLength: int64(info.Len),
 		RequestStart:  int64(info.StartMonotimeNs),
 		Start:         int64(info.StartMonotimeNs),
@@ -170,6 +188,9 @@ func http2InfoToSpan(info *BPFHTTP2Info, method, path, peer, host string, status
 			UserPID:   info.Pid.UserPid,
 			Namespace: info.Pid.Ns,
 		},
+		// the HTTP/2 framing has been detected, either negotiated via ALPN (h2) or in cleartext (h2c)
+		ProtocolVersion: "2",
+		Detector:        detector,
 	}
 }
 
@@ -223,16 +244,17 @@ func ReadHTTP2InfoIntoSpan(record *ringbuf.Record) (request.Span, bool, error) {
 	retF, _ := retFramer.ReadFrame()
 
 	status := 0
+	responseContentType := ""
 	eventType := HTTP2
 
 	if ff, ok := retF.(*http2.HeadersFrame); ok {
-		status, eventType = readRetMetaFrame((*BPFConnInfo)(&event.ConnInfo), retFramer, ff)
+		status, responseContentType, eventType = readRetMetaFrame((*BPFConnInfo)(&event.ConnInfo), retFramer, ff)
 	}
 
 	f, _ := framer.ReadFrame()
 
 	if ff, ok := f.(*http2.HeadersFrame); ok {
-		method, path, proto := readMetaFrame((*BPFConnInfo)(&event.ConnInfo), framer, ff)
+		method, path, proto, headers := readMetaFrame((*BPFConnInfo)(&event.ConnInfo), framer, ff)
 
 		if eventType != GRPC && proto == GRPC {
 			eventType = proto
@@ -247,7 +269,13 @@ func ReadHTTP2InfoIntoSpan(record *ringbuf.Record) (request.Span, bool, error) {
 			peer = source
 		}
 
-		return http2InfoToSpan(&event, method, path, peer, host, status, eventType), false, nil
+		span := http2InfoToSpan(&event, method, path, peer, host, status, eventType)
+		span.RequestHeaders = headers
+		span.SetContextFromHeaders()
+		span.SetEndUserFromHeaders()
+		span.RequestContentType = headerValue(headers, "content-type")
+		span.ResponseContentType = responseContentType
+		return span, false, nil
 	}
 
 	return request.Span{}, true, nil // ignore if we couldn't parse it
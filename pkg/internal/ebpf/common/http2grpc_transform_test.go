@@ -0,0 +1,102 @@
+package ebpfcommon
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+
+	"github.com/cilium/ebpf/ringbuf"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/hpack"
+
+	"github.com/grafana/beyla/pkg/internal/request"
+)
+
+func makeHeadersFrame(t *testing.T, headers ...hpack.HeaderField) []byte {
+	block := &bytes.Buffer{}
+	enc := hpack.NewEncoder(block)
+	// the decoder does not keep a dynamic table, as the eBPF buffers might be partial
+	enc.SetMaxDynamicTableSizeLimit(0)
+	for _, h := range headers {
+		require.NoError(t, enc.WriteField(h))
+	}
+	frame := &bytes.Buffer{}
+	require.NoError(t, http2.NewFramer(frame, nil).WriteHeaders(http2.HeadersFrameParam{
+		StreamID:      1,
+		BlockFragment: block.Bytes(),
+		EndHeaders:    true,
+	}))
+	return frame.Bytes()
+}
+
+func TestReadMetaFrame_Metadata(t *testing.T) {
+	framer := byteFramer(makeHeadersFrame(t,
+		hpack.HeaderField{Name: ":method", Value: "POST"},
+		hpack.HeaderField{Name: ":path", Value: "/routeguide.RouteGuide/GetFeature"},
+		hpack.HeaderField{Name: "content-type", Value: "application/grpc"},
+		hpack.HeaderField{Name: "X-Tenant-ID", Value: "tenant-a"},
+		hpack.HeaderField{Name: "x-tags", Value: "blue"},
+		hpack.HeaderField{Name: "x-tags", Value: "green"},
+	))
+	f, err := framer.ReadFrame()
+	require.NoError(t, err)
+	hf, ok := f.(*http2.HeadersFrame)
+	require.True(t, ok)
+
+	method, path, proto, headers := readMetaFrame(&BPFConnInfo{S_port: 1234, D_port: 50051}, framer, hf)
+	assert.Equal(t, "POST", method)
+	assert.Equal(t, "/routeguide.RouteGuide/GetFeature", path)
+	assert.Equal(t, GRPC, proto)
+	assert.Equal(t, map[string][]string{
+		"content-type": {"application/grpc"},
+		"x-tenant-id":  {"tenant-a"},
+		"x-tags":       {"blue", "green"},
+	}, headers)
+}
+
+func TestReadHTTP2InfoIntoSpan_ContentTypes(t *testing.T) {
+	var event BPFHTTP2Info
+	event.Type = 1
+	event.ConnInfo.S_port, event.ConnInfo.D_port = 1234, 8080
+	copy(event.Data[:], makeHeadersFrame(t,
+		hpack.HeaderField{Name: ":method", Value: "POST"},
+		hpack.HeaderField{Name: ":path", Value: "/users"},
+		hpack.HeaderField{Name: "content-type", Value: "application/json"},
+	))
+	copy(event.RetData[:], makeHeadersFrame(t,
+		hpack.HeaderField{Name: ":status", Value: "201"},
+		hpack.HeaderField{Name: "content-type", Value: "application/json"},
+	))
+	raw := new(bytes.Buffer)
+	require.NoError(t, binary.Write(raw, binary.LittleEndian, &event))
+
+	span, ignore, err := ReadHTTP2InfoIntoSpan(&ringbuf.Record{RawSample: raw.Bytes()})
+	require.NoError(t, err)
+	require.False(t, ignore)
+	assert.Equal(t, 201, span.Status)
+	assert.Equal(t, "application/json", span.RequestContentType)
+	assert.Equal(t, "application/json", span.ResponseContentType)
+}
+
+func TestReadHTTP2InfoIntoSpan_GRPCWeb(t *testing.T) {
+	var event BPFHTTP2Info
+	event.Type = 1
+	event.ConnInfo.S_port, event.ConnInfo.D_port = 1234, 8443
+	copy(event.Data[:], makeHeadersFrame(t,
+		hpack.HeaderField{Name: ":method", Value: "POST"},
+		hpack.HeaderField{Name: ":path", Value: "/shop.Cart/AddItem"},
+		hpack.HeaderField{Name: "content-type", Value: "application/grpc-web-text"},
+	))
+	raw := new(bytes.Buffer)
+	require.NoError(t, binary.Write(raw, binary.LittleEndian, &event))
+
+	span, ignore, err := ReadHTTP2InfoIntoSpan(&ringbuf.Record{RawSample: raw.Bytes()})
+	require.NoError(t, err)
+	require.False(t, ignore)
+	// gRPC-Web is not native gRPC, so it is detected by the traces exporter from the content type
+	assert.Equal(t, request.EventTypeHTTP, span.Type)
+	assert.Equal(t, "application/grpc-web-text", span.RequestContentType)
+}
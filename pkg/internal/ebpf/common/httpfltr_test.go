@@ -7,6 +7,7 @@ import (
 
 	"github.com/cilium/ebpf/ringbuf"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/grafana/beyla/pkg/internal/request"
 	"github.com/grafana/beyla/pkg/internal/svc"
@@ -104,17 +105,22 @@ func TestToRequestTrace(t *testing.T) {
 	assert.NoError(t, err)
 
 	expected := request.Span{
-		Host:         "8.8.8.8",
-		Peer:         "192.168.0.1",
-		Path:         "/hello",
-		Method:       "GET",
-		Status:       200,
-		Type:         request.EventTypeHTTP,
-		RequestStart: 123456,
-		Start:        123456,
-		End:          789012,
-		HostPort:     1,
-		ServiceID:    svc.ID{SDKLanguage: svc.InstrumentableGeneric},
+		Host:               "8.8.8.8",
+		Peer:               "192.168.0.1",
+		Path:               "/hello",
+		Method:             "GET",
+		Status:             200,
+		Type:               request.EventTypeHTTP,
+		RequestStart:       123456,
+		Start:              123456,
+		End:                789012,
+		HostPort:           1,
+		ServiceID:          svc.ID{SDKLanguage: svc.InstrumentableGeneric},
+		Detector:           request.DetectorHTTPParser,
+		ConnectionSecurity: request.ConnectionPlaintext,
+		ProtocolVersion:    "1.1",
+		RequestLine:        "GET /hello HTTP/1.1",
+		RequestHeaders:     map[string][]string{"host": {"example.com"}},
 	}
 	assert.Equal(t, expected, result)
 }
@@ -138,17 +144,22 @@ func TestToRequestTraceNoConnection(t *testing.T) {
 
 	// change the expected port just before testing
 	expected := request.Span{
-		Host:         "localhost",
-		Peer:         "",
-		Path:         "/hello",
-		Method:       "GET",
-		Type:         request.EventTypeHTTP,
-		Start:        123456,
-		RequestStart: 123456,
-		End:          789012,
-		Status:       200,
-		HostPort:     7033,
-		ServiceID:    svc.ID{SDKLanguage: svc.InstrumentableGeneric},
+		Host:               "localhost",
+		Peer:               "",
+		Path:               "/hello",
+		Method:             "GET",
+		Type:               request.EventTypeHTTP,
+		Start:              123456,
+		RequestStart:       123456,
+		End:                789012,
+		Status:             200,
+		HostPort:           7033,
+		ServiceID:          svc.ID{SDKLanguage: svc.InstrumentableGeneric},
+		Detector:           request.DetectorHTTPParser,
+		ConnectionSecurity: request.ConnectionPlaintext,
+		ProtocolVersion:    "1.1",
+		RequestLine:        "GET /hello HTTP/1.1",
+		RequestHeaders:     map[string][]string{"host": {"localhost:7033"}},
 	}
 	assert.Equal(t, expected, result)
 }
@@ -173,17 +184,21 @@ func TestToRequestTrace_BadHost(t *testing.T) {
 	assert.NoError(t, err)
 
 	expected := request.Span{
-		Host:         "",
-		Peer:         "",
-		Path:         "/hello",
-		Method:       "GET",
-		Status:       200,
-		Type:         request.EventTypeHTTP,
-		RequestStart: 123456,
-		Start:        123456,
-		End:          789012,
-		HostPort:     0,
-		ServiceID:    svc.ID{SDKLanguage: svc.InstrumentableGeneric},
+		Host:               "",
+		Peer:               "",
+		Path:               "/hello",
+		Method:             "GET",
+		Status:             200,
+		Type:               request.EventTypeHTTP,
+		RequestStart:       123456,
+		Start:              123456,
+		End:                789012,
+		HostPort:           0,
+		ServiceID:          svc.ID{SDKLanguage: svc.InstrumentableGeneric},
+		Detector:           request.DetectorHTTPParser,
+		ConnectionSecurity: request.ConnectionPlaintext,
+		ProtocolVersion:    "1.1",
+		RequestLine:        "GET /hello HTTP/1.1",
 	}
 	assert.Equal(t, expected, result)
 
@@ -212,3 +227,155 @@ func TestToRequestTrace_BadHost(t *testing.T) {
 	assert.Equal(t, s, "")
 	assert.Equal(t, p, -1)
 }
+
+func TestHeadersFromBuf(t *testing.T) {
+	var record BPFHTTPInfo
+	copy(record.Buf[:], "GET /hello HTTP/1.1\r\nHost: example.com\r\nX-Debug: true\r\nAccept: text/html\r\nAccept: application/json\r\nUser-Agent: cur")
+	assert.Equal(t, map[string][]string{
+		"host":    {"example.com"},
+		"x-debug": {"true"},
+		"accept":  {"text/html", "application/json"},
+	}, record.headers())
+
+	record = BPFHTTPInfo{}
+	copy(record.Buf[:], "GET /hello HTTP/1.1")
+	assert.Nil(t, record.headers())
+}
+
+func TestToRequestTrace_Traceparent(t *testing.T) {
+	var record BPFHTTPInfo
+	record.Type = 1
+	copy(record.Buf[:], "GET /hello HTTP/1.1\r\nTraceparent: 00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01\r\n\r\n")
+
+	buf := new(bytes.Buffer)
+	require.NoError(t, binary.Write(buf, binary.LittleEndian, &record))
+
+	result, _, err := ReadHTTPInfoIntoSpan(&ringbuf.Record{RawSample: buf.Bytes()})
+	require.NoError(t, err)
+	assert.Equal(t, "0af7651916cd43dd8448eb211c80319c", result.TraceID.String())
+	assert.Equal(t, "b7ad6b7169203331", result.ParentSpanID.String())
+	assert.Equal(t, uint8(1), result.Flags)
+}
+
+func TestToRequestTrace_ClientTraceparent(t *testing.T) {
+	var record BPFHTTPInfo
+	record.Type = uint8(request.EventTypeHTTPClient)
+	copy(record.Buf[:], "GET /hello HTTP/1.1\r\nTraceparent: 00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01\r\n\r\n")
+
+	buf := new(bytes.Buffer)
+	require.NoError(t, binary.Write(buf, binary.LittleEndian, &record))
+
+	result, _, err := ReadHTTPInfoIntoSpan(&ringbuf.Record{RawSample: buf.Bytes()})
+	require.NoError(t, err)
+	assert.Equal(t, request.EventTypeHTTPClient, result.Type)
+	// the client span propagated this traceparent, so it does not contain its parent
+	assert.False(t, result.TraceID.IsValid())
+	assert.False(t, result.ParentSpanID.IsValid())
+}
+
+func TestToRequestTrace_ContentType(t *testing.T) {
+	for buf, contentType := range map[string]string{
+		"POST /users HTTP/1.1\r\nContent-Type: application/json\r\n\r\n":                  "application/json",
+		"POST /login HTTP/1.1\r\ncontent-type: application/x-www-form-urlencoded\r\n\r\n": "application/x-www-form-urlencoded",
+		"GET /users HTTP/1.1\r\nHost: example.com\r\n\r\n":                                "",
+		"POST /users HTTP/1.1\r\nHost: example.com\r\nContent-Type: application/js":       "",
+	} {
+		var record BPFHTTPInfo
+		record.Type = 1
+		copy(record.Buf[:], buf)
+
+		raw := new(bytes.Buffer)
+		require.NoError(t, binary.Write(raw, binary.LittleEndian, &record))
+
+		result, _, err := ReadHTTPInfoIntoSpan(&ringbuf.Record{RawSample: raw.Bytes()})
+		require.NoError(t, err)
+		assert.Equal(t, contentType, result.RequestContentType, buf)
+		// the response headers are not captured
+		assert.Empty(t, result.ResponseContentType)
+	}
+}
+
+func TestRequestLineFromBuf(t *testing.T) {
+	for buf, line := range map[string]string{
+		"GET /foo?x=1 HTTP/1.1\r\nHost: example.com\r\n": "GET /foo?x=1 HTTP/1.1",
+		"POST /users HTTP/1.0\r\n":                       "POST /users HTTP/1.0",
+		// truncated request line
+		"GET /a-very-long-path-that-does-not-fit": "",
+	} {
+		var record BPFHTTPInfo
+		copy(record.Buf[:], buf)
+		assert.Equal(t, line, record.requestLine(), buf)
+	}
+}
+
+func TestToRequestTrace_RequestLine(t *testing.T) {
+	var record BPFHTTPInfo
+	record.Type = 1
+	copy(record.Buf[:], "GET /foo?x=1 HTTP/1.1\r\nHost: example.com\r\n\r\n")
+
+	buf := new(bytes.Buffer)
+	require.NoError(t, binary.Write(buf, binary.LittleEndian, &record))
+
+	result, _, err := ReadHTTPInfoIntoSpan(&ringbuf.Record{RawSample: buf.Bytes()})
+	require.NoError(t, err)
+	assert.Equal(t, "GET /foo?x=1 HTTP/1.1", result.RequestLine)
+	assert.Equal(t, "1.1", result.ProtocolVersion)
+	assert.Equal(t, "/foo", result.Path)
+}
+
+func TestToRequestTrace_EndUser(t *testing.T) {
+	var record BPFHTTPInfo
+	record.Type = 1
+	copy(record.Buf[:], "GET /foo HTTP/1.1\r\nAuthorization: Basic YWxpY2U6c2VjcmV0\r\n\r\n")
+
+	buf := new(bytes.Buffer)
+	require.NoError(t, binary.Write(buf, binary.LittleEndian, &record))
+
+	result, _, err := ReadHTTPInfoIntoSpan(&ringbuf.Record{RawSample: buf.Bytes()})
+	require.NoError(t, err)
+	assert.Equal(t, "alice", result.EndUserID)
+}
+
+func TestToRequestTrace_ConnectionSecurity(t *testing.T) {
+	var record BPFHTTPInfo
+	record.Type = 1
+	record.Ssl = 1
+	copy(record.Buf[:], "GET /foo HTTP/1.1\r\nHost: example.com\r\n\r\n")
+
+	buf := new(bytes.Buffer)
+	require.NoError(t, binary.Write(buf, binary.LittleEndian, &record))
+
+	result, _, err := ReadHTTPInfoIntoSpan(&ringbuf.Record{RawSample: buf.Bytes()})
+	require.NoError(t, err)
+	assert.Equal(t, request.ConnectionTLS, result.ConnectionSecurity)
+}
+
+func TestProtocolVersionFromBuf(t *testing.T) {
+	for buf, version := range map[string]string{
+		"GET /hello HTTP/1.1\r\nHost: example.com\r\n": "1.1",
+		"GET /hello HTTP/1.0\r\n":                      "1.0",
+		// truncated request line
+		"GET /hello HTTP/1.":                          "",
+		"GET /a-very-long-path-that-does-not-fit\r\n": "",
+	} {
+		var record BPFHTTPInfo
+		copy(record.Buf[:], buf)
+		assert.Equal(t, version, record.protocolVersion(), buf)
+	}
+}
+
+func TestToRequestTrace_GRPCWeb(t *testing.T) {
+	var record BPFHTTPInfo
+	record.Type = 1
+	copy(record.Buf[:], "POST /shop.Cart/AddItem HTTP/1.1\r\nContent-Type: application/grpc-web+proto\r\n\r\n")
+
+	buf := new(bytes.Buffer)
+	require.NoError(t, binary.Write(buf, binary.LittleEndian, &record))
+
+	result, _, err := ReadHTTPInfoIntoSpan(&ringbuf.Record{RawSample: buf.Bytes()})
+	require.NoError(t, err)
+	// the span is classified as HTTP, and the traces exporter detects gRPC-Web from its content type
+	assert.Equal(t, request.EventTypeHTTP, result.Type)
+	assert.Equal(t, "/shop.Cart/AddItem", result.Path)
+	assert.Equal(t, "application/grpc-web+proto", result.RequestContentType)
+}
@@ -25,6 +25,7 @@ func httpInfoToSpan(info *HTTPInfo) request.Span {
 		Peer:          info.Peer,
 		Host:          info.Host,
 		HostPort:      int(info.ConnInfo.D_port),
+		PeerPort:      int(info.ConnInfo.S_port),
 		ContentLength: int64(info.Len),
 		RequestStart:  int64(info.StartMonotimeNs),
 		Start:         int64(info.StartMonotimeNs),
@@ -40,9 +41,22 @@ func httpInfoToSpan(info *HTTPInfo) request.Span {
 			UserPID:   info.Pid.UserPid,
 			Namespace: info.Pid.Ns,
 		},
+		// the kernel-side parser accounts the bytes sent for the whole response
+		ResponseLength:     int64(info.RespLen),
+		Detector:           request.DetectorHTTPParser,
+		ConnectionSecurity: connectionSecurity(info.Ssl),
 	}
 }
 
+// connectionSecurity returns whether the request was captured by the probes of the TLS libraries
+// or by the probes of the plaintext sockets
+func connectionSecurity(ssl uint8) request.ConnectionSecurity {
+	if ssl != 0 {
+		return request.ConnectionTLS
+	}
+	return request.ConnectionPlaintext
+}
+
 func removeQuery(url string) string {
 	idx := strings.IndexByte(url, '?')
 	if idx > 0 {
@@ -90,7 +104,23 @@ func ReadHTTPInfoIntoSpan(record *ringbuf.Record) (request.Span, bool, error) {
 	// set generic service to be overwritten later by the PID filters
 	result.Service = svc.ID{SDKLanguage: svc.InstrumentableGeneric}
 
-	return httpInfoToSpan(&result), false, nil
+	span := httpInfoToSpan(&result)
+	span.RequestHeaders = event.headers()
+	span.SetContextFromHeaders()
+	span.SetEndUserFromHeaders()
+	span.ProtocolVersion = event.protocolVersion()
+	span.RequestLine = event.requestLine()
+	span.RequestContentType = headerValue(span.RequestHeaders, "content-type")
+	return span, false, nil
+}
+
+// headerValue returns the first value of the header with the provided lowercase name,
+// or an empty string if it was not captured
+func headerValue(headers map[string][]string, name string) string {
+	if values := headers[name]; len(values) > 0 {
+		return values[0]
+	}
+	return ""
 }
 
 func (event *BPFHTTPInfo) url() string {
@@ -117,6 +147,63 @@ func (event *BPFHTTPInfo) method() string {
 	return buf[:space]
 }
 
+// requestLine returns the first line of the request (e.g. GET /foo?x=1 HTTP/1.1), or an
+// empty string if it has not been fully captured
+func (event *BPFHTTPInfo) requestLine() string {
+	buf := cstr(event.Buf[:])
+	eol := strings.Index(buf, "\r\n")
+	if eol < 0 {
+		return ""
+	}
+	return buf[:eol]
+}
+
+// protocolVersion returns the HTTP version from the request line (e.g. 1.1 for HTTP/1.1),
+// or an empty string if the request line has not been fully captured
+func (event *BPFHTTPInfo) protocolVersion() string {
+	line := event.requestLine()
+	space := strings.LastIndexByte(line, ' ')
+	if space < 0 {
+		return ""
+	}
+	version, ok := strings.CutPrefix(line[space+1:], "HTTP/")
+	if !ok {
+		return ""
+	}
+	return version
+}
+
+// headers returns the request headers that have been fully captured in the buffer, with lowercase keys
+func (event *BPFHTTPInfo) headers() map[string][]string {
+	buf := cstr(event.Buf[:])
+	// skip the request line
+	eol := strings.Index(buf, "\r\n")
+	if eol < 0 {
+		return nil
+	}
+	buf = buf[eol+2:]
+
+	var headers map[string][]string
+	for {
+		eol = strings.Index(buf, "\r\n")
+		// end of the headers, or truncated header line
+		if eol <= 0 {
+			return headers
+		}
+		line := buf[:eol]
+		buf = buf[eol+2:]
+		colon := strings.IndexByte(line, ':')
+		if colon <= 0 {
+			continue
+		}
+		if headers == nil {
+			headers = map[string][]string{}
+		}
+		key := strings.ToLower(strings.TrimSpace(line[:colon]))
+		headers[key] = append(headers[key], strings.TrimSpace(line[colon+1:]))
+	}
+}
+
 func (event *BPFHTTPInfo) hostFromBuf() (string, int) {
 	buf := cstr(event.Buf[:])
 
@@ -29,10 +29,12 @@ func HTTPRequestTraceToSpan(trace *HTTPRequestTrace) request.Span {
 	peer := ""
 	hostname := ""
 	hostPort := 0
+	peerPort := 0
 
 	if trace.Conn.S_port != 0 || trace.Conn.D_port != 0 {
 		peer, hostname = trace.hostInfo()
 		hostPort = int(trace.Conn.D_port)
+		peerPort = int(trace.Conn.S_port)
 	}
 
 	return request.Span{
@@ -42,6 +44,7 @@ func HTTPRequestTraceToSpan(trace *HTTPRequestTrace) request.Span {
 		Peer:          peer,
 		Host:          hostname,
 		HostPort:      hostPort,
+		PeerPort:      peerPort,
 		ContentLength: trace.ContentLength,
 		RequestStart:  int64(trace.GoStartMonotimeNs),
 		Start:         int64(trace.StartMonotimeNs),
@@ -56,6 +59,7 @@ func HTTPRequestTraceToSpan(trace *HTTPRequestTrace) request.Span {
 			UserPID:   trace.Pid.UserPid,
 			Namespace: trace.Pid.Ns,
 		},
+		Detector: request.DetectorGoUprobes,
 	}
 }
 
@@ -105,5 +109,6 @@ func SQLRequestTraceToSpan(trace *SQLRequestTrace) request.Span {
 			Namespace: trace.Pid.Ns,
 		},
 		Statement: sql,
+		Detector:  request.DetectorSQLParser,
 	}
 }
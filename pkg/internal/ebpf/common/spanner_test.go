@@ -84,6 +84,31 @@ func TestRequestTraceParsing(t *testing.T) {
 	})
 }
 
+func TestSpanDetector(t *testing.T) {
+	httpTrace := makeHTTPRequestTrace("GET", "/users", 200, 5)
+	httpSpan := HTTPRequestTraceToSpan(&httpTrace)
+	assert.Equal(t, request.DetectorGoUprobes, httpSpan.Detector)
+
+	grpcTrace := makeGRPCRequestTrace("/posts/1/1", 2, 1)
+	grpcSpan := HTTPRequestTraceToSpan(&grpcTrace)
+	assert.Equal(t, request.DetectorGoUprobes, grpcSpan.Detector)
+
+	sqlTrace := SQLRequestTrace{Type: uint8(request.EventTypeSQLClient)}
+	copy(sqlTrace.Sql[:], tocstr("SELECT * FROM users"))
+	sqlSpan := SQLRequestTraceToSpan(&sqlTrace)
+	assert.Equal(t, request.DetectorSQLParser, sqlSpan.Detector)
+
+	kprobesSpan := httpInfoToSpan(&HTTPInfo{BPFHTTPInfo: BPFHTTPInfo{Type: 1}})
+	assert.Equal(t, request.DetectorHTTPParser, kprobesSpan.Detector)
+
+	http2Span := http2InfoToSpan(&BPFHTTP2Info{Type: 1}, "GET", "/", "", "", 200, HTTP2)
+	assert.Equal(t, request.DetectorHTTP2Parser, http2Span.Detector)
+	assert.Equal(t, "2", http2Span.ProtocolVersion)
+
+	kprobesGRPCSpan := http2InfoToSpan(&BPFHTTP2Info{Type: 1}, "POST", "/svc/Method", "", "", 0, GRPC)
+	assert.Equal(t, request.DetectorGRPCParser, kprobesGRPCSpan.Detector)
+}
+
 func makeSpanWithTimings(goStart, start, end uint64) request.Span {
 	tr := HTTPRequestTrace{
 		Type:              1,
@@ -9,20 +9,35 @@ import (
 	"github.com/grafana/beyla/pkg/beyla"
 	"github.com/grafana/beyla/pkg/internal/export/attributes"
 	"github.com/grafana/beyla/pkg/internal/export/otel"
+	"github.com/grafana/beyla/pkg/internal/imetrics"
+	"github.com/grafana/beyla/pkg/internal/pipe/global"
 	"github.com/grafana/beyla/pkg/internal/request"
 )
 
 // TracesReceiver creates a terminal node that consumes request.Spans and sends OpenTelemetry traces to the configured consumers.
-func TracesReceiver(ctx context.Context, cfg *beyla.TracesReceiverConfig, userAttribSelection attributes.Selection) pipe.FinalProvider[[]request.Span] {
-	return (&tracesReceiver{ctx: ctx, cfg: cfg, attributes: userAttribSelection}).provideLoop
+func TracesReceiver(
+	ctx context.Context,
+	cfg *beyla.TracesReceiverConfig,
+	ctxInfo *global.ContextInfo,
+	userAttribSelection attributes.Selection,
+) pipe.FinalProvider[[]request.Span] {
+	return (&tracesReceiver{ctx: ctx, cfg: cfg, ctxInfo: ctxInfo, attributes: userAttribSelection}).provideLoop
 }
 
 type tracesReceiver struct {
 	ctx        context.Context
 	cfg        *beyla.TracesReceiverConfig
+	ctxInfo    *global.ContextInfo
 	attributes attributes.Selection
 }
 
+func (tr *tracesReceiver) internalMetrics() imetrics.Reporter {
+	if tr.ctxInfo == nil || tr.ctxInfo.Metrics == nil {
+		return imetrics.NoopReporter{}
+	}
+	return tr.ctxInfo.Metrics
+}
+
 func (tr *tracesReceiver) provideLoop() (pipe.FinalFunc[[]request.Span], error) {
 	if !tr.cfg.Enabled() {
 		return pipe.IgnoreFinal[[]request.Span](), nil
@@ -33,6 +48,10 @@ func (tr *tracesReceiver) provideLoop() (pipe.FinalFunc[[]request.Span], error)
 		if err != nil {
 			slog.Error("error fetching user defined attributes", "error", err)
 		}
+		// traces forwarded to Alloy are generated with the default options, but they
+		// report the same HTTP methods as the rest of exporters
+		tracesCfg := beyla.DefaultConfig.Traces
+		tracesCfg.KnownHTTPMethods = tr.cfg.KnownHTTPMethods
 
 		for spans := range in {
 			for i := range spans {
@@ -41,8 +60,13 @@ func (tr *tracesReceiver) provideLoop() (pipe.FinalFunc[[]request.Span], error)
 					continue
 				}
 
+				traces := otel.GenerateTraces(&tracesCfg, span, traceAttrs)
+				if traces.SpanCount() == 0 {
+					// the span was rejected because of its attributes overflow
+					tr.internalMetrics().OTELTraceRejectedSpan()
+					continue
+				}
 				for _, tc := range tr.cfg.Traces {
-					traces := otel.GenerateTraces(span, traceAttrs)
 					err := tc.ConsumeTraces(tr.ctx, traces)
 					if err != nil {
 						slog.Error("error sending trace to consumer", "error", err)
@@ -197,7 +197,20 @@ func getDefinitions(groups AttrGroups) map[Section]AttrReportGroup {
 		},
 		Traces.Section: {
 			Attributes: map[attr.Name]Default{
-				attr.IncludeDBStatement: false,
+				attr.IncludeDBStatement:       false,
+				attr.DBOperationBatchSize:     false,
+				attr.HTTPRequestContentType:   false,
+				attr.HTTPResponseContentType:  false,
+				attr.HTTPResponseBodySize:     false,
+				attr.HTTPRequestResendCount:   false,
+				attr.NetworkProtocolVersion:   false,
+				attr.NetworkTransport:         false,
+				attr.EnduserID:                false,
+				attr.BeylaDetector:            false,
+				attr.BeylaSamplingProbability: false,
+				attr.BeylaAdjustedCount:       false,
+				attr.BeylaConnectionReused:    false,
+				attr.BeylaHTTPRequestLine:     false,
 			},
 		},
 	}
@@ -110,5 +110,44 @@ var (
 // traces related attributes
 var (
 	// SQL
-	IncludeDBStatement = Name("db.statement")
+	IncludeDBStatement   = Name("db.statement")
+	DBOperationBatchSize = Name("db.operation.batch.size")
+
+	// Original HTTP method, when it is unknown and reported as _OTHER
+	HTTPRequestMethodOriginal = Name("http.request.method_original")
+
+	// HTTP content types, following the semantic conventions for the HTTP headers
+	HTTPRequestContentType  = Name("http.request.header.content-type")
+	HTTPResponseContentType = Name("http.response.header.content-type")
+
+	// HTTP protocol version and the transport used by it
+	NetworkProtocolVersion = Name("network.protocol.version")
+	NetworkTransport       = Name("network.transport")
+
+	// HTTP response size
+	HTTPResponseBodySize = Name("http.response.body.size")
+
+	// Resends of HTTP client requests
+	HTTPRequestResendCount = Name("http.request.resend_count")
+
+	// Authenticated user
+	EnduserID = Name(semconv.EnduserIDKey)
+
+	// Beyla internals
+	BeylaDetector            = Name("beyla.detector")
+	BeylaSamplingProbability = Name("beyla.sampling.probability")
+	BeylaAdjustedCount       = Name("beyla.adjusted_count")
+	BeylaSynthetic           = Name("beyla.synthetic")
+	BeylaSpansDropped        = Name("beyla.spans_dropped")
+	BeylaConnectionReused    = Name("beyla.connection.reused")
+	BeylaHTTPRequestLine     = Name("beyla.http.request_line")
+	BeylaLatencyBucket       = Name("beyla.latency_bucket")
+	BeylaSpanTrimmed         = Name("beyla.span_trimmed")
+	BeylaRequestSizeBucket   = Name("beyla.request_size_bucket")
+	BeylaResponseSizeBucket  = Name("beyla.response_size_bucket")
+	BeylaTraceContinued      = Name("beyla.trace.continued")
+
+	// Observed timestamps of the requests, to debug clock skews
+	BeylaObservedRequestStart = Name("beyla.observed.request_start")
+	BeylaObservedEnd          = Name("beyla.observed.end")
 )
@@ -0,0 +1,47 @@
+package otel
+
+import (
+	"fmt"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/trace"
+	trace2 "go.opentelemetry.io/otel/trace"
+)
+
+// alwaysKeepSampler keeps the spans with any of the configured attribute values (e.g. the spans
+// of a canary workload), and delegates the decision for the rest of spans to the wrapped sampler.
+type alwaysKeepSampler struct {
+	rules map[string]string
+	next  trace.Sampler
+}
+
+func newAlwaysKeepSampler(rules map[string]string, next trace.Sampler) *alwaysKeepSampler {
+	return &alwaysKeepSampler{rules: rules, next: next}
+}
+
+func (s *alwaysKeepSampler) ShouldSample(p trace.SamplingParameters) trace.SamplingResult {
+	if matchesAlwaysKeep(s.rules, p.Attributes) {
+		return withProbability(trace.SamplingResult{
+			Decision:   trace.RecordAndSample,
+			Tracestate: trace2.SpanContextFromContext(p.ParentContext).TraceState(),
+		}, 1)
+	}
+	return s.next.ShouldSample(p)
+}
+
+func (s *alwaysKeepSampler) Description() string {
+	return fmt.Sprintf("AlwaysKeep{%v}+%s", s.rules, s.next.Description())
+}
+
+// matchesAlwaysKeep returns whether any of the attributes has the value of an AlwaysKeep rule
+func matchesAlwaysKeep(rules map[string]string, attrs []attribute.KeyValue) bool {
+	if len(rules) == 0 {
+		return false
+	}
+	for _, kv := range attrs {
+		if value, ok := rules[string(kv.Key)]; ok && kv.Value.Emit() == value {
+			return true
+		}
+	}
+	return false
+}
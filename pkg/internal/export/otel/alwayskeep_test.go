@@ -0,0 +1,81 @@
+package otel
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/exporter"
+	trace2 "go.opentelemetry.io/otel/trace"
+
+	attr "github.com/grafana/beyla/pkg/internal/export/attributes/names"
+	"github.com/grafana/beyla/pkg/internal/pipe/global"
+	"github.com/grafana/beyla/pkg/internal/request"
+	"github.com/grafana/beyla/pkg/internal/svc"
+)
+
+func deploymentSpan(deployment string) *request.Span {
+	return &request.Span{Type: request.EventTypeHTTP, Route: "/checkout", ServiceID: svc.ID{
+		Name:     "checkout",
+		Metadata: map[attr.Name]string{attr.K8sDeploymentName: deployment},
+	}}
+}
+
+func TestAlwaysKeepSampler(t *testing.T) {
+	cfg := Sampler{
+		Name:       "traceidratio",
+		Arg:        "0.2",
+		AlwaysKeep: map[string]string{"k8s.deployment.name": "checkout-canary"},
+	}
+	sampler := cfg.Implementation()
+
+	const spans = 5000
+	canary, stable := 0, 0
+	for i := 0; i < spans; i++ {
+		if shouldSample(sampler, deploymentSpan("checkout-canary"), nil) {
+			canary++
+		}
+		if shouldSample(sampler, deploymentSpan("checkout"), nil) {
+			stable++
+		}
+	}
+	assert.Equal(t, spans, canary)
+	assert.InDelta(t, 0.2*spans, stable, 0.05*spans)
+
+	assert.Equal(t, 1.0, sampleSpan(sampler, deploymentSpan("checkout-canary"), nil).probability)
+	stableSpan := deploymentSpan("checkout")
+	// the trace ID is below the ratio bound, so the span is kept by the wrapped sampler
+	stableSpan.TraceID = trace2.TraceID{1}
+	assert.Equal(t, samplingDecision{keep: true, probability: 0.2}, sampleSpan(sampler, stableSpan, nil))
+}
+
+func TestAlwaysKeepSampler_SpanAttributes(t *testing.T) {
+	cfg := Sampler{Name: "always_off", AlwaysKeep: map[string]string{"http.route": "/checkout"}}
+	sampler := cfg.Implementation()
+	assert.True(t, shouldSample(sampler, deploymentSpan("checkout"), nil))
+	assert.False(t, shouldSample(sampler, &request.Span{Type: request.EventTypeHTTP, Route: "/cart"}, nil))
+}
+
+func TestTracesReceiver_AlwaysKeep(t *testing.T) {
+	t.Setenv(envTracesProtocol, "")
+	cfg := TracesConfig{
+		TracesEndpoint: "http://collector:4318",
+		Sampler: Sampler{
+			Name:       "always_off",
+			AlwaysKeep: map[string]string{"k8s.deployment.name": "checkout-canary"},
+		},
+	}
+	exp := &countingExporter{}
+	tr := newTracesOTELReceiver(context.Background(), cfg, &global.ContextInfo{}, nil)
+	tr.newExporter = func(_ context.Context, _ TracesConfig, _ *global.ContextInfo) (exporter.Traces, error) {
+		return exp, nil
+	}
+	loop, err := tr.provideLoop()
+	require.NoError(t, err)
+	in := make(chan []request.Span, 1)
+	in <- []request.Span{*deploymentSpan("checkout"), *deploymentSpan("checkout-canary"), *deploymentSpan("checkout")}
+	close(in)
+	loop(in)
+	assert.Equal(t, 1, exp.consumed)
+}
@@ -0,0 +1,66 @@
+package otel
+
+import (
+	"fmt"
+	"strings"
+
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+)
+
+// Accepted values for the TracesConfig.AttributeOverflowPolicy option
+const (
+	AttributeOverflowDropExtra       = "drop_extra"
+	AttributeOverflowRejectSpan      = "reject_span"
+	AttributeOverflowTruncateToLimit = "truncate_to_limit"
+)
+
+// extraAttributesPrefix identifies the Beyla-specific attributes, which are dropped before
+// the attributes defined by the semantic conventions with the drop_extra policy
+const extraAttributesPrefix = "beyla."
+
+func validateAttributeOverflowPolicy(policy string) error {
+	switch policy {
+	case "", AttributeOverflowDropExtra, AttributeOverflowRejectSpan, AttributeOverflowTruncateToLimit:
+		return nil
+	}
+	return fmt.Errorf("invalid attribute_overflow_policy %q. Accepted values: %s, %s, %s", policy,
+		AttributeOverflowDropExtra, AttributeOverflowRejectSpan, AttributeOverflowTruncateToLimit)
+}
+
+// limitAttributes applies the AttributeOverflowPolicy to the span whose attributes exceed the
+// MaxSpanAttributes. It returns false if the span must be rejected. Otherwise, the exceeding
+// attributes are removed and their count is recorded as dropped attributes of the span.
+func limitAttributes(cfg *TracesConfig, s ptrace.Span) bool {
+	attrs := s.Attributes()
+	excess := attrs.Len() - cfg.MaxSpanAttributes
+	if cfg.MaxSpanAttributes <= 0 || excess <= 0 {
+		return true
+	}
+	if cfg.AttributeOverflowPolicy == AttributeOverflowRejectSpan {
+		return false
+	}
+	keys := make([]string, 0, attrs.Len())
+	attrs.Range(func(k string, _ pcommon.Value) bool {
+		keys = append(keys, k)
+		return true
+	})
+	drop := make(map[string]struct{}, excess)
+	if cfg.AttributeOverflowPolicy != AttributeOverflowTruncateToLimit {
+		// the last added Beyla-specific attributes are dropped first
+		for i := len(keys) - 1; i >= 0 && len(drop) < excess; i-- {
+			if strings.HasPrefix(keys[i], extraAttributesPrefix) {
+				drop[keys[i]] = struct{}{}
+			}
+		}
+	}
+	for i := len(keys) - 1; i >= 0 && len(drop) < excess; i-- {
+		drop[keys[i]] = struct{}{}
+	}
+	attrs.RemoveIf(func(k string, _ pcommon.Value) bool {
+		_, ok := drop[k]
+		return ok
+	})
+	s.SetDroppedAttributesCount(s.DroppedAttributesCount() + uint32(excess))
+	return true
+}
@@ -0,0 +1,115 @@
+package otel
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/exporter"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+
+	attr "github.com/grafana/beyla/pkg/internal/export/attributes/names"
+	"github.com/grafana/beyla/pkg/internal/imetrics"
+	"github.com/grafana/beyla/pkg/internal/pipe/global"
+	"github.com/grafana/beyla/pkg/internal/request"
+)
+
+type fakeRejectedSpans struct {
+	imetrics.NoopReporter
+	rejected int
+}
+
+func (f *fakeRejectedSpans) OTELTraceRejectedSpan() {
+	f.rejected++
+}
+
+func TestLimitAttributes(t *testing.T) {
+	overLimitSpan := func() ptrace.Span {
+		s := ptrace.NewSpan()
+		s.Attributes().PutStr("http.request.method", "GET")
+		s.Attributes().PutStr("beyla.http.request_line", "GET /foo HTTP/1.1")
+		s.Attributes().PutStr("url.path", "/foo")
+		s.Attributes().PutInt("http.response.status_code", 200)
+		s.Attributes().PutStr("beyla.latency_bucket", "fast")
+		s.Attributes().PutStr("http.route", "/foo")
+		return s
+	}
+	keys := func(s ptrace.Span) []string {
+		var keys []string
+		for k := range s.Attributes().AsRaw() {
+			keys = append(keys, k)
+		}
+		return keys
+	}
+
+	t.Run("drop_extra", func(t *testing.T) {
+		for _, policy := range []string{"", AttributeOverflowDropExtra} {
+			s := overLimitSpan()
+			require.True(t, limitAttributes(&TracesConfig{MaxSpanAttributes: 3, AttributeOverflowPolicy: policy}, s))
+			assert.ElementsMatch(t, []string{"http.request.method", "url.path", "http.response.status_code"}, keys(s))
+			assert.EqualValues(t, 3, s.DroppedAttributesCount())
+		}
+		// if dropping the Beyla attributes is not enough, the last attributes are dropped
+		s := overLimitSpan()
+		require.True(t, limitAttributes(&TracesConfig{MaxSpanAttributes: 5, AttributeOverflowPolicy: AttributeOverflowDropExtra}, s))
+		assert.ElementsMatch(t, []string{"http.request.method", "beyla.http.request_line", "url.path",
+			"http.response.status_code", "http.route"}, keys(s))
+		assert.EqualValues(t, 1, s.DroppedAttributesCount())
+	})
+	t.Run("truncate_to_limit", func(t *testing.T) {
+		s := overLimitSpan()
+		require.True(t, limitAttributes(&TracesConfig{MaxSpanAttributes: 3, AttributeOverflowPolicy: AttributeOverflowTruncateToLimit}, s))
+		assert.ElementsMatch(t, []string{"http.request.method", "beyla.http.request_line", "url.path"}, keys(s))
+		assert.EqualValues(t, 3, s.DroppedAttributesCount())
+	})
+	t.Run("reject_span", func(t *testing.T) {
+		s := overLimitSpan()
+		assert.False(t, limitAttributes(&TracesConfig{MaxSpanAttributes: 3, AttributeOverflowPolicy: AttributeOverflowRejectSpan}, s))
+	})
+	t.Run("within limits", func(t *testing.T) {
+		for _, cfg := range []TracesConfig{
+			{MaxSpanAttributes: 6, AttributeOverflowPolicy: AttributeOverflowRejectSpan},
+			{AttributeOverflowPolicy: AttributeOverflowRejectSpan},
+		} {
+			s := overLimitSpan()
+			require.True(t, limitAttributes(&cfg, s))
+			assert.Equal(t, 6, s.Attributes().Len())
+			assert.Zero(t, s.DroppedAttributesCount())
+		}
+	})
+}
+
+func TestTracesReceiver_RejectSpan(t *testing.T) {
+	t.Setenv(envTracesProtocol, "")
+	cfg := TracesConfig{
+		TracesEndpoint:          "http://collector:4318",
+		MaxSpanAttributes:       7,
+		AttributeOverflowPolicy: AttributeOverflowRejectSpan,
+	}
+	require.NoError(t, cfg.Validate())
+	span := &request.Span{Type: request.EventTypeHTTP, Method: "GET", Path: "/foo", Route: "/foo", Status: 200}
+	assert.Zero(t, GenerateTraces(&cfg, span, map[attr.Name]struct{}{}).SpanCount())
+
+	metrics := &fakeRejectedSpans{}
+	exp := &countingExporter{}
+	tr := newTracesOTELReceiver(context.Background(), cfg, &global.ContextInfo{Metrics: metrics}, nil)
+	tr.newExporter = func(_ context.Context, _ TracesConfig, _ *global.ContextInfo) (exporter.Traces, error) {
+		return exp, nil
+	}
+	loop, err := tr.provideLoop()
+	require.NoError(t, err)
+	in := make(chan []request.Span, 1)
+	// the span without route is within the limit
+	in <- []request.Span{*span, {Type: request.EventTypeHTTP, Method: "GET", Path: "/foo", Status: 200}}
+	close(in)
+	loop(in)
+
+	assert.Equal(t, 1, metrics.rejected)
+	assert.Equal(t, 1, exp.consumed)
+}
+
+func TestAttributeOverflowPolicy_Invalid(t *testing.T) {
+	cfg := TracesConfig{AttributeOverflowPolicy: "drop_all"}
+	assert.Error(t, cfg.Validate())
+}
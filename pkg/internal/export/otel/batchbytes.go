@@ -0,0 +1,139 @@
+package otel
+
+import (
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/collector/pdata/ptrace"
+)
+
+// same default as the batch span processor of the OTEL SDK
+const defaultBatchTimeout = 5 * time.Second
+
+// tracesBatcher queues the generated traces and submits them in batches whose serialized size
+// does not exceed the configured amount of bytes, independently of the amount of spans in the batch.
+// The queued traces are also submitted after the batch timeout. If a minimum export interval is
+// configured, the batches are not submitted more often than it.
+type tracesBatcher struct {
+	maxBytes    int
+	timeout     time.Duration
+	minInterval time.Duration
+	submit      func(ptrace.Traces)
+
+	mt         sync.Mutex
+	pending    []ptrace.Traces
+	sizes      []int
+	size       int
+	lastSubmit time.Time
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+func newTracesBatcher(cfg *TracesConfig, submit func(ptrace.Traces)) *tracesBatcher {
+	timeout := cfg.BatchTimeout
+	if timeout <= 0 {
+		timeout = defaultBatchTimeout
+	}
+	tb := &tracesBatcher{
+		maxBytes:    cfg.MaxExportBatchBytes,
+		timeout:     timeout,
+		minInterval: cfg.MinExportInterval,
+		submit:      submit,
+		stop:        make(chan struct{}),
+		done:        make(chan struct{}),
+	}
+	go tb.flushLoop()
+	return tb
+}
+
+// add queues the traces. If the new traces would make the queued ones exceed the maximum
+// size, the queued traces are submitted first, unless the minimum export interval has not elapsed
+func (tb *tracesBatcher) add(traces ptrace.Traces) {
+	size := (&ptrace.ProtoMarshaler{}).TracesSize(traces)
+	tb.mt.Lock()
+	var batches []ptrace.Traces
+	if tb.maxBytes > 0 && len(tb.pending) > 0 && tb.size+size > tb.maxBytes && !tb.throttled() {
+		batches = tb.takePending()
+	}
+	tb.pending = append(tb.pending, traces)
+	tb.sizes = append(tb.sizes, size)
+	tb.size += size
+	tb.mt.Unlock()
+	// the traces are submitted without holding the lock, so a slow exporter does not block the queue
+	for _, b := range batches {
+		tb.submit(b)
+	}
+}
+
+// flush submits all the queued traces, unless the minimum export interval has not elapsed
+// and force is false
+func (tb *tracesBatcher) flush(force bool) {
+	tb.mt.Lock()
+	var batches []ptrace.Traces
+	if force || !tb.throttled() {
+		batches = tb.takePending()
+	}
+	tb.mt.Unlock()
+	for _, b := range batches {
+		tb.submit(b)
+	}
+}
+
+// throttled must be invoked with the mutex locked
+func (tb *tracesBatcher) throttled() bool {
+	return tb.minInterval > 0 && time.Since(tb.lastSubmit) < tb.minInterval
+}
+
+// takePending must be invoked with the mutex locked. It empties the queue and returns its
+// traces, grouped in batches that do not exceed the maximum size. The queue could exceed it if the
+// submission was throttled.
+func (tb *tracesBatcher) takePending() []ptrace.Traces {
+	if len(tb.pending) == 0 {
+		return nil
+	}
+	var batches []ptrace.Traces
+	first, size := 0, 0
+	for i := range tb.pending {
+		// a single trace bigger than the limit is still sent in its own batch
+		if tb.maxBytes > 0 && i > first && size+tb.sizes[i] > tb.maxBytes {
+			batches = append(batches, mergeTraces(tb.pending[first:i]))
+			first, size = i, 0
+		}
+		size += tb.sizes[i]
+	}
+	batches = append(batches, mergeTraces(tb.pending[first:]))
+	tb.pending, tb.sizes, tb.size = nil, nil, 0
+	tb.lastSubmit = time.Now()
+	return batches
+}
+
+func (tb *tracesBatcher) flushLoop() {
+	defer close(tb.done)
+	ticker := time.NewTicker(tb.timeout)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-tb.stop:
+			return
+		case <-ticker.C:
+			tb.flush(false)
+		}
+	}
+}
+
+// close stops the periodic submission and submits the traces that are still queued
+func (tb *tracesBatcher) close() {
+	close(tb.stop)
+	<-tb.done
+	tb.flush(true)
+}
+
+// mergeTraces moves the resource spans of all the traces into a single ptrace.Traces
+func mergeTraces(batch []ptrace.Traces) ptrace.Traces {
+	merged := batch[0]
+	for _, t := range batch[1:] {
+		t.ResourceSpans().MoveAndAppendTo(merged.ResourceSpans())
+	}
+	return merged
+}
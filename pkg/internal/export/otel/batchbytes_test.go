@@ -0,0 +1,199 @@
+package otel
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mariomac/guara/pkg/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/consumer"
+	"go.opentelemetry.io/collector/exporter"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+
+	"github.com/grafana/beyla/pkg/internal/pipe/global"
+	"github.com/grafana/beyla/pkg/internal/request"
+)
+
+// batchesExporter records the traces of each ConsumeTraces invocation
+type batchesExporter struct {
+	mt      sync.Mutex
+	batches []ptrace.Traces
+	times   []time.Time
+}
+
+func (b *batchesExporter) Start(_ context.Context, _ component.Host) error { return nil }
+
+func (b *batchesExporter) Shutdown(_ context.Context) error { return nil }
+
+func (b *batchesExporter) Capabilities() consumer.Capabilities { return consumer.Capabilities{} }
+
+func (b *batchesExporter) ConsumeTraces(_ context.Context, traces ptrace.Traces) error {
+	b.mt.Lock()
+	defer b.mt.Unlock()
+	b.batches = append(b.batches, traces)
+	b.times = append(b.times, time.Now())
+	return nil
+}
+
+// SpanCounts returns the number of spans of each consumed batch
+func (b *batchesExporter) SpanCounts() []int {
+	b.mt.Lock()
+	defer b.mt.Unlock()
+	counts := make([]int, 0, len(b.batches))
+	for _, t := range b.batches {
+		counts = append(counts, t.SpanCount())
+	}
+	return counts
+}
+
+// Times returns the time of each ConsumeTraces invocation
+func (b *batchesExporter) Times() []time.Time {
+	b.mt.Lock()
+	defer b.mt.Unlock()
+	return append([]time.Time{}, b.times...)
+}
+
+func batchingReceiver(t *testing.T, cfg TracesConfig) (*tracesOTELReceiver, *batchesExporter) {
+	t.Setenv(envTracesProtocol, "")
+	cfg.TracesEndpoint = "http://collector:4318"
+	exp := &batchesExporter{}
+	tr := newTracesOTELReceiver(context.Background(), cfg, &global.ContextInfo{}, nil)
+	tr.newExporter = func(_ context.Context, _ TracesConfig, _ *global.ContextInfo) (exporter.Traces, error) {
+		return exp, nil
+	}
+	return tr, exp
+}
+
+func pathSpan(path string) request.Span {
+	return request.Span{Type: request.EventTypeHTTP, Method: "GET", Path: path, Status: 200}
+}
+
+func TestTracesReceiver_MaxExportBatchBytes(t *testing.T) {
+	small := pathSpan("/")
+	large := pathSpan("/" + strings.Repeat("x", 1000))
+	largeSize := (&ptrace.ProtoMarshaler{}).TracesSize(GenerateTraces(&TracesConfig{}, &large, nil))
+	limit := 2 * largeSize
+
+	tr, exp := batchingReceiver(t, TracesConfig{MaxExportBatchBytes: limit, BatchTimeout: time.Hour})
+	loop, err := tr.provideLoop()
+	require.NoError(t, err)
+	in := make(chan []request.Span, 1)
+	in <- []request.Span{small, large, large, small, large}
+	close(in)
+	loop(in)
+
+	// the second large span would exceed the limit, so it triggers a new batch.
+	// The last batch is submitted when the node is stopped
+	assert.Equal(t, []int{2, 2, 1}, exp.SpanCounts())
+	for _, b := range exp.batches {
+		assert.LessOrEqual(t, (&ptrace.ProtoMarshaler{}).TracesSize(b), limit)
+	}
+}
+
+func TestTracesReceiver_MaxExportBatchBytes_SpanBiggerThanLimit(t *testing.T) {
+	large := pathSpan("/" + strings.Repeat("x", 1000))
+
+	tr, exp := batchingReceiver(t, TracesConfig{MaxExportBatchBytes: 500, BatchTimeout: time.Hour})
+	loop, err := tr.provideLoop()
+	require.NoError(t, err)
+	in := make(chan []request.Span, 1)
+	in <- []request.Span{large, pathSpan("/"), large}
+	close(in)
+	loop(in)
+
+	// spans bigger than the limit are not discarded but sent in their own batch
+	assert.Equal(t, []int{1, 1, 1}, exp.SpanCounts())
+}
+
+func TestTracesReceiver_MaxExportBatchBytes_BatchTimeout(t *testing.T) {
+	tr, exp := batchingReceiver(t, TracesConfig{MaxExportBatchBytes: 1_000_000, BatchTimeout: 50 * time.Millisecond})
+	loop, err := tr.provideLoop()
+	require.NoError(t, err)
+	in := make(chan []request.Span, 1)
+	done := make(chan struct{})
+	go func() {
+		loop(in)
+		close(done)
+	}()
+	in <- []request.Span{pathSpan("/foo"), pathSpan("/bar")}
+
+	// the queued spans are submitted after the batch timeout, without waiting for more spans
+	test.Eventually(t, timeout, func(t require.TestingT) {
+		assert.Equal(t, []int{2}, exp.SpanCounts())
+	})
+	close(in)
+	test.Eventually(t, timeout, func(t require.TestingT) {
+		select {
+		case <-done:
+		default:
+			require.Fail(t, "traces node did not finish")
+		}
+	})
+	assert.Equal(t, []int{2}, exp.SpanCounts())
+}
+
+func TestTracesReceiver_MinExportInterval(t *testing.T) {
+	const interval = 200 * time.Millisecond
+	tr, exp := batchingReceiver(t, TracesConfig{MinExportInterval: interval, BatchTimeout: 10 * time.Millisecond})
+	loop, err := tr.provideLoop()
+	require.NoError(t, err)
+	in := make(chan []request.Span, 1)
+	done := make(chan struct{})
+	go func() {
+		loop(in)
+		close(done)
+	}()
+
+	// a burst of tiny batches, longer than the interval
+	for i := 0; i < 30; i++ {
+		in <- []request.Span{pathSpan("/foo")}
+		time.Sleep(15 * time.Millisecond)
+	}
+	test.Eventually(t, timeout, func(t require.TestingT) {
+		total := 0
+		for _, c := range exp.SpanCounts() {
+			total += c
+		}
+		assert.Equal(t, 30, total)
+	})
+	close(in)
+	<-done
+
+	// the spans are coalesced and submitted no more often than the interval
+	times := exp.Times()
+	require.GreaterOrEqual(t, len(times), 2)
+	assert.Less(t, len(times), 30)
+	for i := 1; i < len(times); i++ {
+		assert.GreaterOrEqual(t, times[i].Sub(times[i-1]), interval-10*time.Millisecond)
+	}
+}
+
+func TestTracesReceiver_MinExportInterval_MaxExportBatchBytes(t *testing.T) {
+	large := pathSpan("/" + strings.Repeat("x", 1000))
+	largeSize := (&ptrace.ProtoMarshaler{}).TracesSize(GenerateTraces(&TracesConfig{}, &large, nil))
+	limit := 2 * largeSize
+
+	tr, exp := batchingReceiver(t, TracesConfig{
+		MaxExportBatchBytes: limit,
+		MinExportInterval:   time.Hour,
+		BatchTimeout:        time.Hour,
+	})
+	loop, err := tr.provideLoop()
+	require.NoError(t, err)
+	in := make(chan []request.Span, 1)
+	in <- []request.Span{large, large, large, large, large, large}
+	close(in)
+	loop(in)
+
+	// the first batch exceeding the limit is submitted. Then the submission is throttled, so the
+	// rest of spans are queued and split in batches that do not exceed the limit when the node stops
+	assert.Equal(t, []int{2, 2, 2}, exp.SpanCounts())
+	for _, b := range exp.batches {
+		assert.LessOrEqual(t, (&ptrace.ProtoMarshaler{}).TracesSize(b), limit)
+	}
+}
@@ -0,0 +1,38 @@
+package otel
+
+import (
+	"context"
+	"fmt"
+
+	"go.opentelemetry.io/collector/consumer"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+)
+
+// consumeTraces submits the traces to the consumer. If the ExportCallTimeout is defined, the
+// submission is cancelled after it, and the function returns without waiting for
+// hung consumers that do not honor the context cancellation.
+func (tr *tracesOTELReceiver) consumeTraces(exp consumer.Traces, traces ptrace.Traces) error {
+	if tr.cfg.MeasurePayloadSize {
+		tr.measurePayloadSize(traces)
+	}
+	if tr.cfg.ExportCallTimeout <= 0 {
+		return exp.ConsumeTraces(tr.ctx, traces)
+	}
+	ctx, cancel := context.WithTimeout(tr.ctx, tr.cfg.ExportCallTimeout)
+	defer cancel()
+
+	// buffered, so the goroutine of a hung consumer does not block forever after returning
+	result := make(chan error, 1)
+	go func() {
+		result <- exp.ConsumeTraces(ctx, traces)
+	}()
+	select {
+	case err := <-result:
+		return err
+	case <-ctx.Done():
+		if tr.ctx.Err() == nil {
+			tr.internalMetrics().OTELTraceExportTimeout()
+		}
+		return fmt.Errorf("submitting traces after %s: %w", tr.cfg.ExportCallTimeout, ctx.Err())
+	}
+}
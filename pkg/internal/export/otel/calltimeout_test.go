@@ -0,0 +1,70 @@
+package otel
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/consumer"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+
+	"github.com/grafana/beyla/pkg/internal/imetrics"
+	"github.com/grafana/beyla/pkg/internal/pipe/global"
+)
+
+// blockingConsumer never returns from ConsumeTraces until the unblock channel is closed,
+// ignoring the context cancellation
+type blockingConsumer struct {
+	unblock chan struct{}
+	calls   atomic.Int32
+}
+
+func (b *blockingConsumer) Capabilities() consumer.Capabilities {
+	return consumer.Capabilities{}
+}
+
+func (b *blockingConsumer) ConsumeTraces(_ context.Context, _ ptrace.Traces) error {
+	b.calls.Add(1)
+	<-b.unblock
+	return nil
+}
+
+type fakeExportTimeouts struct {
+	imetrics.NoopReporter
+	timeouts atomic.Int32
+}
+
+func (f *fakeExportTimeouts) OTELTraceExportTimeout() {
+	f.timeouts.Add(1)
+}
+
+func TestConsumeTraces_Timeout(t *testing.T) {
+	metrics := &fakeExportTimeouts{}
+	tr := newTracesOTELReceiver(context.Background(), TracesConfig{ExportCallTimeout: 50 * time.Millisecond},
+		&global.ContextInfo{Metrics: metrics}, nil)
+	exp := &blockingConsumer{unblock: make(chan struct{})}
+	defer close(exp.unblock)
+
+	start := time.Now()
+	err := tr.consumeTraces(exp, ptrace.NewTraces())
+	require.Error(t, err)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), 5*time.Second)
+	assert.EqualValues(t, 1, exp.calls.Load())
+	assert.EqualValues(t, 1, metrics.timeouts.Load())
+}
+
+func TestConsumeTraces_NoTimeout(t *testing.T) {
+	metrics := &fakeExportTimeouts{}
+	tr := newTracesOTELReceiver(context.Background(), TracesConfig{ExportCallTimeout: time.Minute},
+		&global.ContextInfo{Metrics: metrics}, nil)
+	exp := &blockingConsumer{unblock: make(chan struct{})}
+	close(exp.unblock)
+
+	require.NoError(t, tr.consumeTraces(exp, ptrace.NewTraces()))
+	assert.EqualValues(t, 1, exp.calls.Load())
+	assert.Zero(t, metrics.timeouts.Load())
+}
@@ -0,0 +1,80 @@
+package otel
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"sync/atomic"
+	"syscall"
+	"time"
+)
+
+const defaultCaptureSessionDuration = 5 * time.Minute
+
+// captureSessionSignal starts a capture session when the Beyla process receives it
+const captureSessionSignal = syscall.SIGUSR1
+
+// captureSessions controls the time-boxed capture sessions of the traces exporters configured with
+// CaptureSessionsOnly. It is shared by all the exporters, so a session started by the
+// captureSessionSignal applies to all of them.
+var captureSessions = newCaptureSession(time.Now)
+
+// captureSession stores the end time of the currently active capture session, if any.
+// It can be safely accessed from multiple goroutines.
+type captureSession struct {
+	// until is the end of the session, as Unix nanoseconds. Zero if no session was started
+	until atomic.Int64
+	now   func() time.Time
+}
+
+func newCaptureSession(now func() time.Time) *captureSession {
+	return &captureSession{now: now}
+}
+
+// StartCaptureSession enables the export of the traces configured with CaptureSessionsOnly during the
+// given duration. Starting a session while another is active replaces its end time.
+func StartCaptureSession(duration time.Duration) {
+	captureSessions.start(duration)
+	tlog().Info("traces capture session started", "duration", duration)
+}
+
+// StopCaptureSession finishes the currently active capture session, if any.
+func StopCaptureSession() {
+	captureSessions.stop()
+	tlog().Info("traces capture session stopped")
+}
+
+func (cs *captureSession) start(duration time.Duration) {
+	cs.until.Store(cs.now().Add(duration).UnixNano())
+}
+
+func (cs *captureSession) stop() {
+	cs.until.Store(0)
+}
+
+// listenCaptureSignal starts a capture session of the given duration each time the Beyla process
+// receives the captureSessionSignal, until the context is cancelled. The signal is handled
+// as soon as this function returns.
+func listenCaptureSignal(ctx context.Context, duration time.Duration) {
+	if duration <= 0 {
+		duration = defaultCaptureSessionDuration
+	}
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, captureSessionSignal)
+	go func() {
+		defer signal.Stop(signals)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-signals:
+				StartCaptureSession(duration)
+			}
+		}
+	}()
+}
+
+// active returns whether the spans must be exported at this moment
+func (cs *captureSession) active() bool {
+	return cs.now().UnixNano() < cs.until.Load()
+}
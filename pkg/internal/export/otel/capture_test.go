@@ -0,0 +1,81 @@
+package otel
+
+import (
+	"context"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/mariomac/guara/pkg/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	attr "github.com/grafana/beyla/pkg/internal/export/attributes/names"
+	"github.com/grafana/beyla/pkg/internal/request"
+)
+
+func TestTracesReceiver_CaptureSessionsOnly(t *testing.T) {
+	now := time.Now()
+	oldSessions := captureSessions
+	captureSessions = newCaptureSession(func() time.Time { return now })
+	defer func() { captureSessions = oldSessions }()
+
+	var exported []string
+	consume := func(cfg TracesConfig, path string) {
+		tr := newTracesOTELReceiver(context.Background(), cfg, nil, nil)
+		in := make(chan []request.Span, 1)
+		in <- []request.Span{{Type: request.EventTypeHTTP, Path: path}}
+		close(in)
+		tr.consume(in, func(s *request.Span) { exported = append(exported, s.Path) })
+	}
+	capturing := TracesConfig{CaptureSessionsOnly: true}
+
+	consume(capturing, "/before-session")
+	// without CaptureSessionsOnly, the spans are always exported
+	consume(TracesConfig{}, "/no-capture-sessions")
+
+	StartCaptureSession(5 * time.Minute)
+	consume(capturing, "/session-start")
+	now = now.Add(4 * time.Minute)
+	consume(capturing, "/within-session")
+	now = now.Add(time.Minute)
+	consume(capturing, "/session-expired")
+
+	StartCaptureSession(time.Minute)
+	consume(capturing, "/new-session")
+	StopCaptureSession()
+	consume(capturing, "/session-stopped")
+
+	assert.Equal(t, []string{"/no-capture-sessions", "/session-start", "/within-session", "/new-session"}, exported)
+}
+
+func TestTracesReceiver_CaptureSessionSignal(t *testing.T) {
+	oldSessions := captureSessions
+	captureSessions = newCaptureSession(time.Now)
+	defer func() { captureSessions = oldSessions }()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	tr, exp := batchingReceiver(t, TracesConfig{CaptureSessionsOnly: true, CaptureSessionDuration: time.Hour})
+	tr.ctx = ctx
+	loop, err := tr.provideLoop()
+	require.NoError(t, err)
+	run := func(path string) {
+		in := make(chan []request.Span, 1)
+		in <- []request.Span{pathSpan(path)}
+		close(in)
+		loop(in)
+	}
+
+	run("/before-signal")
+	require.NoError(t, syscall.Kill(os.Getpid(), captureSessionSignal))
+	test.Eventually(t, timeout, func(t require.TestingT) {
+		assert.True(t, captureSessions.active())
+	})
+	run("/after-signal")
+
+	require.Equal(t, []int{1}, exp.SpanCounts())
+	ensureTraceStrAttr(t, exp.batches[0].ResourceSpans().At(0).ScopeSpans().At(0).Spans().At(0).Attributes(),
+		attr.HTTPUrlPath.OTEL(), "/after-signal")
+}
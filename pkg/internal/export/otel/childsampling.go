@@ -0,0 +1,53 @@
+package otel
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/rand"
+
+	"github.com/grafana/beyla/pkg/internal/request"
+)
+
+func validateChildSampleRatio(ratio float64, decisionsCacheLen int) error {
+	if ratio == 0 {
+		return nil
+	}
+	if ratio < 0 || ratio > 1 {
+		return fmt.Errorf("child_sample_ratio %v out of the [0, 1] range", ratio)
+	}
+	if decisionsCacheLen <= 0 {
+		return errors.New("child_sample_ratio requires sampling_decisions_cache_len to be set")
+	}
+	return nil
+}
+
+// sampleChild returns whether a span from a kept trace is exported, according to the ratio of
+// sampled children. Local root spans are always kept, as they are the entry point of the service
+// even if they have a remote parent. The decision is taken from the span ID,
+// in the same way the TraceIDRatioBased sampler does from the trace ID, so it is consistent
+// for a given span.
+func sampleChild(ratio float64, span *request.Span) bool {
+	if isLocalRoot(span) || ratio >= 1 {
+		return true
+	}
+	var x uint64
+	if span.SpanID.IsValid() {
+		x = binary.BigEndian.Uint64(span.SpanID[:]) >> 1
+	} else {
+		// the span ID will be randomly generated at export time
+		x = rand.Uint64() >> 1
+	}
+	return x < uint64(ratio*(1<<63))
+}
+
+// sampleChildDecision applies the ratio of sampled children to the decision taken for the trace
+// of the span. The probability of the children that are kept is scaled by the ratio.
+func sampleChildDecision(ratio float64, span *request.Span, decision samplingDecision) samplingDecision {
+	if !decision.keep || isLocalRoot(span) || ratio >= 1 {
+		return decision
+	}
+	decision.keep = sampleChild(ratio, span)
+	decision.probability *= ratio
+	return decision
+}
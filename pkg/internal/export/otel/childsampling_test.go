@@ -0,0 +1,94 @@
+package otel
+
+import (
+	"context"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/grafana/beyla/pkg/internal/request"
+)
+
+func TestTracesReceiver_ChildSampleRatio(t *testing.T) {
+	// the traceidratio sampler keeps the first trace and drops the second
+	keptTrace := trace.TraceID{1}
+	droppedTrace := trace.TraceID{8: 0xff}
+	spans := []request.Span{
+		// children are reported before their parent, but sampled after it
+		{Type: request.EventTypeHTTPClient, Path: "/kept-child", TraceID: keptTrace,
+			SpanID: trace.SpanID{0x10}, ParentSpanID: trace.SpanID{1}},
+		{Type: request.EventTypeHTTPClient, Path: "/ratio-dropped-child", TraceID: keptTrace,
+			SpanID: trace.SpanID{0xf0}, ParentSpanID: trace.SpanID{1}},
+		{Type: request.EventTypeHTTP, Path: "/kept-root", TraceID: keptTrace, SpanID: trace.SpanID{1}},
+		{Type: request.EventTypeHTTPClient, Path: "/dropped-root-child", TraceID: droppedTrace,
+			SpanID: trace.SpanID{0x10}, ParentSpanID: trace.SpanID{2}},
+		{Type: request.EventTypeHTTP, Path: "/dropped-root", TraceID: droppedTrace, SpanID: trace.SpanID{2}},
+	}
+	tr := newTracesOTELReceiver(context.Background(), TracesConfig{
+		Sampler:                   Sampler{Name: "traceidratio", Arg: "0.5"},
+		SamplingDecisionsCacheLen: 10,
+		ChildSampleRatio:          0.5,
+	}, nil, nil)
+	in := make(chan []request.Span, 1)
+	in <- spans
+	close(in)
+
+	var exported []string
+	tr.consume(in, func(s *request.Span) {
+		if tr.sample(s) {
+			exported = append(exported, s.Path)
+		}
+	})
+	assert.Equal(t, []string{"/kept-root", "/kept-child"}, exported)
+}
+
+func TestTracesReceiver_ChildSampleRatio_Probability(t *testing.T) {
+	tr := newTracesOTELReceiver(context.Background(), TracesConfig{
+		Sampler:                   Sampler{Name: "traceidratio", Arg: "0.5"},
+		SamplingDecisionsCacheLen: 10,
+		ChildSampleRatio:          0.25,
+	}, nil, nil)
+	root := request.Span{Type: request.EventTypeHTTP, TraceID: trace.TraceID{1}, SpanID: trace.SpanID{1}}
+	p, ok := sampledProbability(t, tr, &root)
+	require.True(t, ok)
+	assert.Equal(t, 0.5, p)
+
+	// the children inherit the probability of the trace, scaled by the ratio of sampled children
+	child := request.Span{Type: request.EventTypeHTTPClient, TraceID: root.TraceID,
+		SpanID: trace.SpanID{7: 1}, ParentSpanID: root.SpanID}
+	p, ok = sampledProbability(t, tr, &child)
+	require.True(t, ok)
+	assert.Equal(t, 0.125, p)
+}
+
+func TestSampleChild_Ratio(t *testing.T) {
+	root := request.Span{Type: request.EventTypeHTTP, TraceID: trace.TraceID{1}, SpanID: trace.SpanID{1}}
+	assert.True(t, sampleChild(0.01, &root))
+	// a server span continuing a remote trace is still the local root
+	remoteChild := request.Span{Type: request.EventTypeHTTP, TraceID: trace.TraceID{1}, SpanID: trace.SpanID{0xf0}, ParentSpanID: trace.SpanID{9}}
+	assert.True(t, sampleChild(0.01, &remoteChild))
+
+	const children = 10000
+	kept := 0
+	for i := 0; i < children; i++ {
+		child := request.Span{Type: request.EventTypeHTTPClient, TraceID: root.TraceID, ParentSpanID: root.SpanID}
+		_, _ = rand.Read(child.SpanID[:])
+		if sampleChild(0.25, &child) {
+			kept++
+		}
+		// the decision is consistent for the same span
+		assert.Equal(t, sampleChild(0.25, &child), sampleChild(0.25, &child))
+	}
+	assert.InDelta(t, 0.25*children, kept, 0.05*children)
+}
+
+func TestTracesConfig_ValidateChildSampleRatio(t *testing.T) {
+	assert.NoError(t, (&TracesConfig{}).Validate())
+	assert.NoError(t, (&TracesConfig{ChildSampleRatio: 0.3, SamplingDecisionsCacheLen: 100}).Validate())
+	assert.Error(t, (&TracesConfig{ChildSampleRatio: 0.3}).Validate())
+	assert.Error(t, (&TracesConfig{ChildSampleRatio: 1.5, SamplingDecisionsCacheLen: 100}).Validate())
+	assert.Error(t, (&TracesConfig{ChildSampleRatio: -1, SamplingDecisionsCacheLen: 100}).Validate())
+}
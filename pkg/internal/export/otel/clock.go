@@ -0,0 +1,74 @@
+package otel
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/grafana/beyla/pkg/internal/request"
+)
+
+// Accepted values for the TracesConfig.ClockSource option
+const (
+	ClockSourceRealtime = "realtime"
+	ClockSourceBootTime = "boottime"
+)
+
+// Accepted values for the TracesConfig.TimestampPrecision option
+const (
+	TimestampPrecisionNanos  = "ns"
+	TimestampPrecisionMicros = "us"
+	TimestampPrecisionMillis = "ms"
+)
+
+var timestampPrecisions = map[string]time.Duration{
+	"":                       time.Nanosecond,
+	TimestampPrecisionNanos:  time.Nanosecond,
+	TimestampPrecisionMicros: time.Microsecond,
+	TimestampPrecisionMillis: time.Millisecond,
+}
+
+func validateClockSource(source string) error {
+	switch source {
+	case "", ClockSourceRealtime, ClockSourceBootTime:
+		return nil
+	}
+	return fmt.Errorf("invalid clock_source %q. Accepted values: %s, %s", source,
+		ClockSourceRealtime, ClockSourceBootTime)
+}
+
+func validateTimestampPrecision(precision string) error {
+	if _, ok := timestampPrecisions[precision]; !ok {
+		return fmt.Errorf("invalid timestamp_precision %q. Accepted values: %s, %s, %s", precision,
+			TimestampPrecisionNanos, TimestampPrecisionMicros, TimestampPrecisionMillis)
+	}
+	return nil
+}
+
+// bootTime can be overridden from tests
+var bootTime = request.BootTime
+
+// spanTimings converts the monotonic timestamps of the span to wall-clock time, according to the
+// configured ClockSource, and rounds them down to the configured TimestampPrecision.
+func (m *TracesConfig) spanTimings(span *request.Span) request.Timings {
+	var t request.Timings
+	if m.ClockSource == ClockSourceBootTime {
+		t = span.TimingsFrom(bootTime())
+	} else {
+		t = span.Timings()
+	}
+	if precision, ok := timestampPrecisions[m.TimestampPrecision]; ok && precision > time.Nanosecond {
+		t = truncateTimings(t, precision)
+	}
+	return t
+}
+
+// truncateTimings rounds down all the timestamps to the given precision. As all of them are
+// rounded down, their order is kept (e.g. the end never precedes the start), although the
+// shortest durations might become zero.
+func truncateTimings(t request.Timings, precision time.Duration) request.Timings {
+	return request.Timings{
+		RequestStart: t.RequestStart.Truncate(precision),
+		Start:        t.Start.Truncate(precision),
+		End:          t.End.Truncate(precision),
+	}
+}
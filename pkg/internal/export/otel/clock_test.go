@@ -0,0 +1,99 @@
+package otel
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/beyla/pkg/internal/request"
+)
+
+func TestClockSource_BootTime(t *testing.T) {
+	defer func(old func() time.Time) { bootTime = old }(bootTime)
+	boot := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	bootTime = func() time.Time { return boot }
+
+	span := &request.Span{
+		Type:         request.EventTypeHTTP,
+		RequestStart: int64(5 * time.Second),
+		Start:        int64(5 * time.Second),
+		End:          int64(7 * time.Second),
+	}
+	cfg := TracesConfig{ClockSource: ClockSourceBootTime}
+	traces := GenerateTraces(&cfg, span, nil)
+
+	spans := traces.ResourceSpans().At(0).ScopeSpans().At(0).Spans()
+	require.Equal(t, 1, spans.Len())
+	assert.Equal(t, boot.Add(5*time.Second), spans.At(0).StartTimestamp().AsTime())
+	assert.Equal(t, boot.Add(7*time.Second), spans.At(0).EndTimestamp().AsTime())
+}
+
+func TestClockSource_Realtime(t *testing.T) {
+	span := &request.Span{RequestStart: 100, Start: 200, End: 300}
+	cfg := TracesConfig{}
+	timings := cfg.spanTimings(span)
+	assert.Equal(t, 100*time.Nanosecond, timings.End.Sub(timings.Start))
+	bootCfg := TracesConfig{ClockSource: ClockSourceBootTime}
+	// the boot time offset is captured once, so consecutive conversions are identical
+	assert.Equal(t, bootCfg.spanTimings(span), bootCfg.spanTimings(span))
+}
+
+func TestTimestampPrecision(t *testing.T) {
+	defer func(old func() time.Time) { bootTime = old }(bootTime)
+	boot := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	bootTime = func() time.Time { return boot }
+
+	span := &request.Span{
+		Type:         request.EventTypeHTTP,
+		RequestStart: int64(5*time.Second + 1_233_567),
+		Start:        int64(5*time.Second + 1_234_999),
+		End:          int64(5*time.Second + 1_235_001),
+	}
+	for _, tc := range []struct {
+		precision          string
+		reqStart, start    time.Duration
+		end                time.Duration
+		processingDuration time.Duration
+	}{
+		{precision: "", reqStart: 1_233_567, start: 1_234_999, end: 1_235_001, processingDuration: 2},
+		{precision: TimestampPrecisionNanos, reqStart: 1_233_567, start: 1_234_999, end: 1_235_001, processingDuration: 2},
+		{precision: TimestampPrecisionMicros, reqStart: 1_233_000, start: 1_234_000, end: 1_235_000, processingDuration: time.Microsecond},
+		// the duration becomes zero, but the end never precedes the start
+		{precision: TimestampPrecisionMillis, reqStart: 1_000_000, start: 1_000_000, end: 1_000_000},
+	} {
+		t.Run(tc.precision, func(t *testing.T) {
+			cfg := TracesConfig{ClockSource: ClockSourceBootTime, TimestampPrecision: tc.precision}
+			require.NoError(t, cfg.Validate())
+			spans := GenerateTraces(&cfg, span, nil).ResourceSpans().At(0).ScopeSpans().At(0).Spans()
+
+			// the queue and processing sub-spans are only generated if the start is after the request start
+			if tc.processingDuration > 0 {
+				require.Equal(t, 3, spans.Len())
+				assert.Equal(t, "processing", spans.At(1).Name())
+			} else {
+				require.Equal(t, 1, spans.Len())
+			}
+			parent := spans.At(spans.Len() - 1)
+			assert.Equal(t, boot.Add(5*time.Second+tc.reqStart), parent.StartTimestamp().AsTime())
+			assert.Equal(t, boot.Add(5*time.Second+tc.end), parent.EndTimestamp().AsTime())
+			for i := 0; i < spans.Len(); i++ {
+				sp := spans.At(i)
+				assert.GreaterOrEqual(t, sp.EndTimestamp(), sp.StartTimestamp(), sp.Name())
+				if sp.Name() == "processing" {
+					assert.Equal(t, boot.Add(5*time.Second+tc.start), sp.StartTimestamp().AsTime())
+					assert.Equal(t, tc.processingDuration, sp.EndTimestamp().AsTime().Sub(sp.StartTimestamp().AsTime()))
+				}
+			}
+		})
+	}
+	assert.Error(t, (&TracesConfig{TimestampPrecision: "s"}).Validate())
+}
+
+func TestClockSource_Validate(t *testing.T) {
+	for _, source := range []string{"", ClockSourceRealtime, ClockSourceBootTime} {
+		assert.NoError(t, (&TracesConfig{ClockSource: source}).Validate(), source)
+	}
+	assert.Error(t, (&TracesConfig{ClockSource: "monotonic"}).Validate())
+}
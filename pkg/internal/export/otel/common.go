@@ -5,9 +5,13 @@ import (
 	"crypto/tls"
 	"fmt"
 	"log/slog"
+	"net/url"
 	"os"
+	"strings"
+	"sync"
 
 	"github.com/go-logr/logr"
+	"github.com/google/uuid"
 	"github.com/hashicorp/golang-lru/v2/simplelru"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
@@ -19,6 +23,8 @@ import (
 	semconv "go.opentelemetry.io/otel/semconv/v1.19.0"
 	"google.golang.org/grpc/credentials"
 
+	"github.com/grafana/beyla/pkg/buildinfo"
+	attr "github.com/grafana/beyla/pkg/internal/export/attributes/names"
 	"github.com/grafana/beyla/pkg/internal/svc"
 )
 
@@ -32,6 +38,13 @@ const (
 	ProtocolGRPC         Protocol = "grpc"
 	ProtocolHTTPProtobuf Protocol = "http/protobuf"
 	ProtocolHTTPJSON     Protocol = "http/json"
+	// ProtocolFile is only supported by the traces exporter, which writes OTLP-JSON lines to a local file
+	ProtocolFile Protocol = "file"
+	// ProtocolZipkin is only supported by the traces exporter, which submits the spans to the Zipkin V2 JSON API
+	ProtocolZipkin Protocol = "zipkin"
+	// ProtocolOpenCensus is only supported by the traces exporter, which streams the spans to an
+	// OpenCensus agent or collector through gRPC
+	ProtocolOpenCensus Protocol = "opencensus"
 )
 
 const (
@@ -40,6 +53,55 @@ const (
 	envProtocol        = "OTEL_EXPORTER_OTLP_PROTOCOL"
 )
 
+// parseEndpoint resolves the OTLP endpoint of a signal, from highest to lowest priority:
+// - the signal-specific endpoint (e.g. OTEL_EXPORTER_OTLP_TRACES_ENDPOINT), if defined
+// - the common endpoint (OTEL_EXPORTER_OTLP_ENDPOINT), if defined
+// - the Grafana OTLP gateway, if the Grafana gateway URL or cloud zone are defined
+// It also returns whether the endpoint has been resolved from a common source.
+// Traces and metrics share this function, so they always resolve the same endpoint.
+func parseEndpoint(signalEndpoint, commonEndpoint string, grafana *GrafanaOTLP) (*url.URL, bool, error) {
+	isCommon := false
+	endpoint := signalEndpoint
+	if endpoint == "" {
+		isCommon = true
+		endpoint = commonEndpoint
+		if endpoint == "" && grafana.gatewayDefined() {
+			endpoint = grafana.Endpoint()
+		}
+	}
+
+	murl, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, isCommon, fmt.Errorf("parsing endpoint URL %s: %w", endpoint, err)
+	}
+	if murl.Scheme == "" || murl.Host == "" {
+		return nil, isCommon, fmt.Errorf("URL %q must have a scheme and a host", endpoint)
+	}
+	return murl, isCommon, nil
+}
+
+// guessProtocol returns the protocol of the endpoint resolved by parseEndpoint, when no
+// protocol is explicitly set. The guess is based on the endpoint port
+// (assuming it uses a standard port or a development-like form like 14317, 24317, 14318...)
+// guessProtocol returns the protocol according to the usual OTLP port of the endpoint, or the
+// fallback protocol, if set, when the port does not follow the usual conventions.
+func guessProtocol(signalEndpoint, commonEndpoint string, grafana *GrafanaOTLP, fallback Protocol) Protocol {
+	ep, _, err := parseEndpoint(signalEndpoint, commonEndpoint, grafana)
+	if err == nil {
+		if strings.HasSuffix(ep.Port(), UsualPortGRPC) {
+			return ProtocolGRPC
+		} else if strings.HasSuffix(ep.Port(), UsualPortHTTP) {
+			return ProtocolHTTPProtobuf
+		}
+	}
+	if fallback != "" {
+		return fallback
+	}
+	// Otherwise we return default protocol according to the latest specification:
+	// https://github.com/open-telemetry/opentelemetry-specification/blob/main/specification/protocol/exporter.md?plain=1#L53
+	return ProtocolHTTPProtobuf
+}
+
 // Buckets defines the histograms bucket boundaries, and allows users to
 // redefine them
 type Buckets struct {
@@ -55,10 +117,48 @@ var DefaultBuckets = Buckets{
 	RequestSizeHistogram: []float64{0, 32, 64, 128, 256, 512, 1024, 2048, 4096, 8192},
 }
 
-func getResourceAttrs(service svc.ID) *resource.Resource {
+// Resource attributes that identify Beyla as the OpenTelemetry distribution
+// that generated the telemetry.
+const (
+	telemetryDistroNameKey    = attribute.Key("telemetry.distro.name")
+	telemetryDistroVersionKey = attribute.Key("telemetry.distro.version")
+)
+
+// configFingerprintKey identifies the configuration of the Beyla instance that generated the telemetry
+const configFingerprintKey = attribute.Key("beyla.config.fingerprint")
+
+// distroAttrs returns the resource attributes that identify the Beyla
+// distribution and its compiled-in build version.
+func distroAttrs() []attribute.KeyValue {
+	return []attribute.KeyValue{
+		telemetryDistroNameKey.String("beyla"),
+		telemetryDistroVersionKey.String(buildinfo.Version),
+	}
+}
+
+// processInstanceID is used as service.instance.id when it can't be derived from the
+// service information. It is generated once, so it is stable for the lifetime of the process.
+var processInstanceID = sync.OnceValue(uuid.NewString)
+
+// serviceInstanceID returns the service.instance.id of the service, from highest to lowest priority:
+// - the instance ID of the service, as composed from the hostname and the PID of the process,
+// or as overridden by the user via the BEYLA_INSTANCE_ID configuration property.
+// - the UID of the Kubernetes Pod running the service.
+// - a random UUID that is generated once per Beyla process.
+func serviceInstanceID(service *svc.ID) string {
+	if service.Instance != "" {
+		return service.Instance
+	}
+	if uid := service.Metadata[attr.K8sPodUID]; uid != "" {
+		return uid
+	}
+	return processInstanceID()
+}
+
+func getResourceAttrs(service svc.ID, extra ...attribute.KeyValue) *resource.Resource {
 	attrs := []attribute.KeyValue{
 		semconv.ServiceName(service.Name),
-		semconv.ServiceInstanceID(service.Instance),
+		semconv.ServiceInstanceID(serviceInstanceID(&service)),
 		// SpanMetrics requires an extra attribute besides service name
 		// to generate the traces_target_info metric,
 		// so the service is visible in the ServicesList
@@ -73,8 +173,12 @@ func getResourceAttrs(service svc.ID) *resource.Resource {
 	}
 
 	for k, v := range service.Metadata {
-		attrs = append(attrs, k.OTEL().String(v))
+		// omitting the unresolved metadata (e.g. the workload name of a Pod whose owner is unknown)
+		if v != "" {
+			attrs = append(attrs, k.OTEL().String(v))
+		}
 	}
+	attrs = append(attrs, extra...)
 
 	return resource.NewWithAttributes(semconv.SchemaURL, attrs...)
 }
@@ -185,6 +289,9 @@ func (o *otlpOptions) AsTraceGRPC() []otlptracegrpc.Option {
 	if o.SkipTLSVerify {
 		opts = append(opts, otlptracegrpc.WithTLSCredentials(credentials.NewTLS(&tls.Config{InsecureSkipVerify: true})))
 	}
+	if len(o.HTTPHeaders) > 0 {
+		opts = append(opts, otlptracegrpc.WithHeaders(o.HTTPHeaders))
+	}
 	return opts
 }
 
@@ -4,7 +4,14 @@ import (
 	"fmt"
 	"testing"
 
+	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.19.0"
+
+	attr "github.com/grafana/beyla/pkg/internal/export/attributes/names"
+	"github.com/grafana/beyla/pkg/internal/svc"
 )
 
 func TestOtlpOptions_AsMetricHTTP(t *testing.T) {
@@ -84,3 +91,125 @@ func TestOtlpOptions_AsTraceGRPC(t *testing.T) {
 		})
 	}
 }
+
+func TestEndpointResolutionParity(t *testing.T) {
+	grafana := &GrafanaOTLP{CloudZone: "eu-west-0"}
+	type testCase struct {
+		common   string
+		signal   string
+		grafana  *GrafanaOTLP
+		protocol Protocol
+	}
+	testCases := []testCase{
+		{common: "http://foo:4317", protocol: ProtocolGRPC},
+		{common: "http://foo:14317", protocol: ProtocolGRPC},
+		{common: "http://foo:4318", protocol: ProtocolHTTPProtobuf},
+		{common: "http://foo:24318", protocol: ProtocolHTTPProtobuf},
+		{common: "http://foo:9999", protocol: ProtocolHTTPProtobuf},
+		{common: "http://foo", protocol: ProtocolHTTPProtobuf},
+		{common: "foo:4317", protocol: ProtocolHTTPProtobuf},
+		{common: "http://foo:4318", signal: "http://bar:4317", protocol: ProtocolGRPC},
+		{grafana: grafana, protocol: ProtocolHTTPProtobuf},
+		{common: "http://foo:4317", grafana: grafana, protocol: ProtocolGRPC},
+		{protocol: ProtocolHTTPProtobuf},
+	}
+	for _, tc := range testCases {
+		t.Run(fmt.Sprint(tc), func(t *testing.T) {
+			tcfg := TracesConfig{CommonEndpoint: tc.common, TracesEndpoint: tc.signal, Grafana: tc.grafana}
+			mcfg := MetricsConfig{CommonEndpoint: tc.common, MetricsEndpoint: tc.signal, Grafana: tc.grafana}
+
+			assert.Equal(t, tc.protocol, tcfg.guessProtocol())
+			assert.Equal(t, tc.protocol, mcfg.GuessProtocol())
+
+			turl, tCommon, tErr := parseTracesEndpoint(&tcfg)
+			murl, mCommon, mErr := parseMetricsEndpoint(&mcfg)
+			assert.Equal(t, turl, murl)
+			assert.Equal(t, tCommon, mCommon)
+			assert.Equal(t, tErr, mErr)
+		})
+	}
+}
+
+func TestTracesDefaultProtocol(t *testing.T) {
+	testCases := []struct {
+		cfg      TracesConfig
+		protocol Protocol
+	}{
+		// the fallback is honored for non-standard ports
+		{cfg: TracesConfig{CommonEndpoint: "http://gateway:9999", DefaultProtocol: ProtocolGRPC}, protocol: ProtocolGRPC},
+		{cfg: TracesConfig{TracesEndpoint: "http://gateway", DefaultProtocol: ProtocolGRPC}, protocol: ProtocolGRPC},
+		{cfg: TracesConfig{CommonEndpoint: "http://gateway:9999"}, protocol: ProtocolHTTPProtobuf},
+		// usual ports are still guessed
+		{cfg: TracesConfig{CommonEndpoint: "http://gateway:4318", DefaultProtocol: ProtocolGRPC}, protocol: ProtocolHTTPProtobuf},
+		// explicit protocol settings win
+		{cfg: TracesConfig{CommonEndpoint: "http://gateway:9999", Protocol: ProtocolHTTPJSON, DefaultProtocol: ProtocolGRPC},
+			protocol: ProtocolHTTPJSON},
+		{cfg: TracesConfig{CommonEndpoint: "http://gateway:9999", TracesProtocol: ProtocolHTTPProtobuf, DefaultProtocol: ProtocolGRPC},
+			protocol: ProtocolHTTPProtobuf},
+	}
+	for _, tc := range testCases {
+		t.Run(fmt.Sprint(tc.cfg.CommonEndpoint, tc.cfg.TracesEndpoint, tc.cfg.Protocol, tc.cfg.DefaultProtocol), func(t *testing.T) {
+			assert.Equal(t, tc.protocol, tc.cfg.getProtocol())
+		})
+	}
+}
+
+func TestResourceAttrs_K8sWorkload(t *testing.T) {
+	resourceAttrs := func(metadata map[attr.Name]string) map[attribute.Key]string {
+		attrs := map[attribute.Key]string{}
+		for _, kv := range getResourceAttrs(svc.ID{Name: "svc", Metadata: metadata}).Attributes() {
+			attrs[kv.Key] = kv.Value.AsString()
+		}
+		return attrs
+	}
+	workloads := []attribute.Key{
+		attr.K8sDeploymentName.OTEL(), attr.K8sReplicaSetName.OTEL(),
+		attr.K8sStatefulSetName.OTEL(), attr.K8sDaemonSetName.OTEL(),
+	}
+	t.Run("deployment-owned pod", func(t *testing.T) {
+		attrs := resourceAttrs(map[attr.Name]string{
+			attr.K8sPodName: "cart-5f6b7-x2x", attr.K8sReplicaSetName: "cart-5f6b7", attr.K8sDeploymentName: "cart",
+		})
+		assert.Equal(t, "cart", attrs[attr.K8sDeploymentName.OTEL()])
+		assert.Equal(t, "cart-5f6b7", attrs[attr.K8sReplicaSetName.OTEL()])
+		assert.NotContains(t, attrs, attr.K8sStatefulSetName.OTEL())
+	})
+	t.Run("statefulset-owned pod", func(t *testing.T) {
+		attrs := resourceAttrs(map[attr.Name]string{attr.K8sPodName: "db-0", attr.K8sStatefulSetName: "db"})
+		assert.Equal(t, "db", attrs[attr.K8sStatefulSetName.OTEL()])
+		assert.NotContains(t, attrs, attr.K8sDeploymentName.OTEL())
+	})
+	t.Run("unresolved workload", func(t *testing.T) {
+		attrs := resourceAttrs(map[attr.Name]string{attr.K8sPodName: "standalone", attr.K8sDeploymentName: ""})
+		for _, w := range workloads {
+			assert.NotContains(t, attrs, w)
+		}
+		assert.Equal(t, "standalone", attrs[attr.K8sPodName.OTEL()])
+	})
+}
+
+func TestServiceInstanceID(t *testing.T) {
+	instanceID := func(service svc.ID) string {
+		res := getResourceAttrs(service)
+		id, ok := res.Set().Value(semconv.ServiceInstanceIDKey)
+		require.True(t, ok)
+		return id.AsString()
+	}
+	t.Run("from hostname and PID, or user override", func(t *testing.T) {
+		assert.Equal(t, "host-1234", instanceID(svc.ID{
+			Instance: "host-1234",
+			Metadata: map[attr.Name]string{attr.K8sPodUID: "pod-uid"},
+		}))
+	})
+	t.Run("from pod UID", func(t *testing.T) {
+		assert.Equal(t, "pod-uid", instanceID(svc.ID{
+			Metadata: map[attr.Name]string{attr.K8sPodUID: "pod-uid"},
+		}))
+	})
+	t.Run("random UUID, stable for the process lifetime", func(t *testing.T) {
+		id := instanceID(svc.ID{Name: "foo"})
+		_, err := uuid.Parse(id)
+		require.NoError(t, err)
+		assert.Equal(t, id, instanceID(svc.ID{Name: "bar"}))
+	})
+}
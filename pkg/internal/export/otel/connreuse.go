@@ -0,0 +1,50 @@
+package otel
+
+import (
+	"cmp"
+	"slices"
+
+	lru "github.com/hashicorp/golang-lru/v2"
+
+	"github.com/grafana/beyla/pkg/internal/request"
+)
+
+// clientConnsCacheLen is the number of recent client connections that are remembered to detect their reuse
+const clientConnsCacheLen = 4096
+
+// clientConns remembers the connections of the recent client spans, to detect the requests that
+// reused a connection that was already open. It must be invoked from a single goroutine.
+type clientConns struct {
+	seen *lru.Cache[connection, struct{}]
+}
+
+func newClientConns() *clientConns {
+	seen, _ := lru.New[connection, struct{}](clientConnsCacheLen)
+	return &clientConns{seen: seen}
+}
+
+// markReused sets the ConnectionReuse of the client spans of the batch whose connection was used
+// by a previous client span. The rest of spans are left as unknown, as their connection might have
+// been opened before Beyla started tracking it.
+func (cc *clientConns) markReused(spans []request.Span) {
+	var clients []int
+	for i := range spans {
+		span := &spans[i]
+		if span.IsClientSpan() && span.PeerPort != 0 && span.HostPort != 0 {
+			clients = append(clients, i)
+		}
+	}
+	// the spans of a batch are not necessarily sorted
+	slices.SortFunc(clients, func(a, b int) int { return cmp.Compare(spans[a].Start, spans[b].Start) })
+	for _, i := range clients {
+		span := &spans[i]
+		conn := connection{pid: span.Pid.HostPID,
+			peer: span.Peer, peerPort: span.PeerPort,
+			host: span.Host, hostPort: span.HostPort}
+		if _, ok := cc.seen.Get(conn); ok {
+			span.ConnectionReuse = request.ConnectionReused
+		} else {
+			cc.seen.Add(conn, struct{}{})
+		}
+	}
+}
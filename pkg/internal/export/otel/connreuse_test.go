@@ -0,0 +1,51 @@
+package otel
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/grafana/beyla/pkg/internal/export/attributes"
+	"github.com/grafana/beyla/pkg/internal/request"
+)
+
+func TestTracesReceiver_ConnectionReuse(t *testing.T) {
+	clientSpan := func(path string, peerPort int, start int64) request.Span {
+		return request.Span{Type: request.EventTypeHTTPClient, Path: path, Pid: request.PidInfo{HostPID: 123},
+			Peer: "10.0.0.1", PeerPort: peerPort, Host: "10.0.0.2", HostPort: 8080,
+			RequestStart: start, Start: start, End: start + 10}
+	}
+	tr := newTracesOTELReceiver(context.Background(), TracesConfig{}, nil, attributes.Selection{})
+	in := make(chan []request.Span, 2)
+	batch := []request.Span{
+		// unordered, as they might be reported by different probes
+		clientSpan("/second", 34567, 200),
+		clientSpan("/first", 34567, 100),
+		clientSpan("/other-conn", 45678, 150),
+		// the server spans are ignored
+		{Type: request.EventTypeHTTP, Path: "/server", Pid: request.PidInfo{HostPID: 123},
+			Peer: "10.0.0.3", PeerPort: 34567, Host: "10.0.0.1", HostPort: 80, Start: 300, End: 310},
+		{Type: request.EventTypeHTTP, Path: "/server-again", Pid: request.PidInfo{HostPID: 123},
+			Peer: "10.0.0.3", PeerPort: 34567, Host: "10.0.0.1", HostPort: 80, Start: 400, End: 410},
+	}
+	original := slices.Clone(batch)
+	in <- batch
+	// the connections are remembered across batches
+	in <- []request.Span{clientSpan("/other-conn-later", 45678, 500)}
+	close(in)
+	reuse := map[string]request.ConnectionReuse{}
+	tr.consume(in, func(s *request.Span) { reuse[s.Path] = s.ConnectionReuse })
+
+	assert.Equal(t, map[string]request.ConnectionReuse{
+		"/first":            request.ConnectionReuseUnknown,
+		"/second":           request.ConnectionReused,
+		"/other-conn":       request.ConnectionReuseUnknown,
+		"/server":           request.ConnectionReuseUnknown,
+		"/server-again":     request.ConnectionReuseUnknown,
+		"/other-conn-later": request.ConnectionReused,
+	}, reuse)
+	// the connection reuse is only set in the copy of the batch that is exported as traces
+	assert.Equal(t, original, batch)
+}
@@ -0,0 +1,39 @@
+package otel
+
+import (
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.19.0"
+
+	"github.com/grafana/beyla/pkg/internal/svc"
+)
+
+// containerImageAttrs returns the resource attributes that describe the image of the container
+// running the service, when known
+func containerImageAttrs(service *svc.ID) []attribute.KeyValue {
+	if service.ContainerImage == "" {
+		return nil
+	}
+	name, tag := parseImageReference(service.ContainerImage)
+	attrs := []attribute.KeyValue{semconv.ContainerImageName(name)}
+	if tag != "" {
+		attrs = append(attrs, semconv.ContainerImageTag(tag))
+	}
+	return attrs
+}
+
+// parseImageReference splits an image reference ([registry[:port]/]repository[:tag][@digest]) into
+// the image name and its tag. If the image is pinned by digest and has no tag, the digest
+// (e.g. sha256:...) is returned as tag. The tag is empty if the reference specifies neither.
+func parseImageReference(ref string) (name, tag string) {
+	name, digest, _ := strings.Cut(ref, "@")
+	// the registry port also follows a colon, so the tag is only searched after the last slash
+	if colon := strings.LastIndexByte(name, ':'); colon > strings.LastIndexByte(name, '/') {
+		name, tag = name[:colon], name[colon+1:]
+	}
+	if tag == "" {
+		tag = digest
+	}
+	return name, tag
+}
@@ -0,0 +1,29 @@
+package otel
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseImageReference(t *testing.T) {
+	for _, tc := range []struct {
+		ref  string
+		name string
+		tag  string
+	}{
+		{ref: "repo/name:tag", name: "repo/name", tag: "tag"},
+		{ref: "nginx", name: "nginx"},
+		{ref: "docker.io/grafana/beyla:1.5.0", name: "docker.io/grafana/beyla", tag: "1.5.0"},
+		{ref: "registry:5000/repo/name", name: "registry:5000/repo/name"},
+		{ref: "registry:5000/repo/name:v2", name: "registry:5000/repo/name", tag: "v2"},
+		{ref: "repo/name@sha256:0123abcd", name: "repo/name", tag: "sha256:0123abcd"},
+		{ref: "registry:5000/repo/name:v2@sha256:0123abcd", name: "registry:5000/repo/name", tag: "v2"},
+	} {
+		t.Run(tc.ref, func(t *testing.T) {
+			name, tag := parseImageReference(tc.ref)
+			assert.Equal(t, tc.name, name)
+			assert.Equal(t, tc.tag, tag)
+		})
+	}
+}
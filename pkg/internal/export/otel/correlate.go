@@ -0,0 +1,102 @@
+package otel
+
+import (
+	"github.com/grafana/beyla/pkg/internal/request"
+)
+
+// processKey identifies the process that generated a span
+type processKey struct {
+	pid       uint32
+	namespace uint32
+}
+
+func spanProcess(span *request.Span) processKey {
+	return processKey{pid: span.Pid.HostPID, namespace: span.Pid.Namespace}
+}
+
+// correlateRequests assigns the trace context of the inbound requests to the client spans (e.g. SQL
+// queries) of the same batch that were invoked while serving them, when the eBPF probes couldn't
+// propagate it. A client span without trace ID becomes a child of the server span of the same process
+// that contains it in time. If the client span is contained by multiple concurrent server spans, the
+// request that triggered it can't be known, so it is left uncorrelated.
+// The orphaned SQL spans, whose trace ID is known but their parent isn't, are also re-parented
+// to the HTTP server span that contains them, so they don't appear as the root of their own trace.
+func correlateRequests(spans []request.Span) {
+	servers := serversByProcess(spans)
+	if len(servers) == 0 {
+		return
+	}
+	for i := range spans {
+		child := &spans[i]
+		if !child.IsClientSpan() || (child.TraceID.IsValid() && !orphanedSQL(child)) {
+			continue
+		}
+		server := enclosingServer(spans, servers, child)
+		if server == nil || (child.TraceID.IsValid() && server.Type != request.EventTypeHTTP) {
+			continue
+		}
+		child.TraceID = server.TraceID
+		child.ParentSpanID = server.SpanID
+	}
+}
+
+func orphanedSQL(span *request.Span) bool {
+	return span.Type == request.EventTypeSQLClient && !span.ParentSpanID.IsValid()
+}
+
+// linkRequests links the client spans of the batch to the server span of the same process that
+// contains them in time, following the same criteria as correlateRequests.
+func linkRequests(spans []request.Span) {
+	servers := serversByProcess(spans)
+	if len(servers) == 0 {
+		return
+	}
+	for i := range spans {
+		child := &spans[i]
+		if !child.IsClientSpan() {
+			continue
+		}
+		if server := enclosingServer(spans, servers, child); server != nil {
+			child.LinkTraceID = server.TraceID
+			child.LinkSpanID = server.SpanID
+		}
+	}
+}
+
+// serversByProcess returns the indices of the server spans of the batch, grouped by process
+func serversByProcess(spans []request.Span) map[processKey][]int {
+	servers := map[processKey][]int{}
+	for i := range spans {
+		if !spans[i].IsClientSpan() {
+			servers[spanProcess(&spans[i])] = append(servers[spanProcess(&spans[i])], i)
+		}
+	}
+	return servers
+}
+
+// enclosingServer returns the only server span of the child process that contains it, or nil if there
+// are none or multiple concurrent requests. The trace and span IDs of the returned span are
+// generated if they were missing, so they can be referenced by the child span.
+func enclosingServer(spans []request.Span, servers map[processKey][]int, child *request.Span) *request.Span {
+	parent := -1
+	for _, s := range servers[spanProcess(child)] {
+		if child.Inside(&spans[s]) {
+			if parent >= 0 {
+				// ambiguous: multiple concurrent requests
+				return nil
+			}
+			parent = s
+		}
+	}
+	if parent < 0 {
+		return nil
+	}
+	server := &spans[parent]
+	if !server.TraceID.IsValid() {
+		server.TraceID = randomTraceID()
+	}
+	if !server.SpanID.IsValid() {
+		server.SpanID = randomSpanID()
+	}
+	return server
+}
@@ -0,0 +1,190 @@
+package otel
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	trace2 "go.opentelemetry.io/otel/trace"
+
+	"github.com/grafana/beyla/pkg/internal/export/attributes"
+	"github.com/grafana/beyla/pkg/internal/request"
+)
+
+func TestTracesReceiver_CorrelateRequests(t *testing.T) {
+	pid := request.PidInfo{HostPID: 1234, Namespace: 1}
+	spans := []request.Span{
+		{Type: request.EventTypeSQLClient, Path: "/sql-1", Pid: pid, RequestStart: 110, Start: 110, End: 120},
+		{Type: request.EventTypeSQLClient, Path: "/sql-2", Pid: pid, RequestStart: 130, Start: 130, End: 140},
+		{Type: request.EventTypeHTTP, Path: "/http", Pid: pid, RequestStart: 100, Start: 100, End: 200},
+		// outside the HTTP request
+		{Type: request.EventTypeSQLClient, Path: "/sql-later", Pid: pid, RequestStart: 300, Start: 300, End: 310},
+		// from another process
+		{Type: request.EventTypeSQLClient, Path: "/sql-other", Pid: request.PidInfo{HostPID: 4321, Namespace: 1},
+			RequestStart: 150, Start: 150, End: 160},
+	}
+
+	tr := newTracesOTELReceiver(context.Background(), TracesConfig{CorrelateRequests: true}, nil, attributes.Selection{})
+	in := make(chan []request.Span, 1)
+	in <- spans
+	close(in)
+	exported := map[string]request.Span{}
+	tr.consume(in, func(s *request.Span) { exported[s.Path] = *s })
+	require.Len(t, exported, 5)
+
+	server := exported["/http"]
+	require.True(t, server.TraceID.IsValid())
+	require.True(t, server.SpanID.IsValid())
+	for _, child := range []string{"/sql-1", "/sql-2"} {
+		assert.Equal(t, server.TraceID, exported[child].TraceID, child)
+		assert.Equal(t, server.SpanID, exported[child].ParentSpanID, child)
+	}
+	for _, other := range []string{"/sql-later", "/sql-other"} {
+		assert.False(t, exported[other].TraceID.IsValid(), other)
+		assert.False(t, exported[other].ParentSpanID.IsValid(), other)
+	}
+
+	// the generated traces share the trace ID
+	httpTraces := GenerateTraces(&TracesConfig{}, &server, nil)
+	sql := exported["/sql-1"]
+	sqlTraces := GenerateTraces(&TracesConfig{}, &sql, nil)
+	assert.Equal(t,
+		httpTraces.ResourceSpans().At(0).ScopeSpans().At(0).Spans().At(0).TraceID(),
+		sqlTraces.ResourceSpans().At(0).ScopeSpans().At(0).Spans().At(0).TraceID())
+}
+
+func TestTracesReceiver_CorrelateRequests_SharedBatch(t *testing.T) {
+	pid := request.PidInfo{HostPID: 1234, Namespace: 1}
+	spans := []request.Span{
+		{Type: request.EventTypeHTTP, Path: "/http", Pid: pid, RequestStart: 100, Start: 100, End: 200},
+		{Type: request.EventTypeSQLClient, Path: "/sql", Pid: pid, RequestStart: 110, Start: 110, End: 120},
+	}
+	original := slices.Clone(spans)
+
+	tr := newTracesOTELReceiver(context.Background(), TracesConfig{CorrelateRequests: true}, nil, attributes.Selection{})
+	in := make(chan []request.Span, 1)
+	in <- spans
+	close(in)
+	exported := map[string]request.Span{}
+	tr.consume(in, func(s *request.Span) { exported[s.Path] = *s })
+	require.Len(t, exported, 2)
+	assert.Equal(t, exported["/http"].SpanID, exported["/sql"].ParentSpanID)
+
+	// the batch is also read by the other exporters, so it must not be modified
+	assert.Equal(t, original, spans)
+}
+
+func TestCorrelateRequests_KeepsPropagatedContext(t *testing.T) {
+	traceID := trace2.TraceID{1, 2, 3}
+	parentID := trace2.SpanID{4, 5, 6}
+	spans := []request.Span{
+		{Type: request.EventTypeHTTP, RequestStart: 100, Start: 100, End: 200},
+		{Type: request.EventTypeSQLClient, TraceID: traceID, ParentSpanID: parentID, RequestStart: 110, Start: 110, End: 120},
+	}
+	correlateRequests(spans)
+	assert.Equal(t, traceID, spans[1].TraceID)
+	assert.Equal(t, parentID, spans[1].ParentSpanID)
+	assert.False(t, spans[0].TraceID.IsValid())
+}
+
+func TestCorrelateRequests_OrphanedSQL(t *testing.T) {
+	orphanTraceID := trace2.TraceID{7, 8, 9}
+	spans := []request.Span{
+		{Type: request.EventTypeHTTP, Path: "/http", RequestStart: 100, Start: 100, End: 200},
+		{Type: request.EventTypeSQLClient, Path: "/sql", TraceID: orphanTraceID, RequestStart: 110, Start: 110, End: 120},
+		// outside the HTTP request
+		{Type: request.EventTypeSQLClient, Path: "/sql-later", TraceID: orphanTraceID, RequestStart: 300, Start: 300, End: 310},
+	}
+
+	tr := newTracesOTELReceiver(context.Background(), TracesConfig{CorrelateRequests: true}, nil, attributes.Selection{})
+	in := make(chan []request.Span, 1)
+	in <- spans
+	close(in)
+	exported := map[string]request.Span{}
+	tr.consume(in, func(s *request.Span) { exported[s.Path] = *s })
+	require.Len(t, exported, 3)
+
+	server := exported["/http"]
+	require.True(t, server.SpanID.IsValid())
+	assert.Equal(t, server.TraceID, exported["/sql"].TraceID)
+	assert.Equal(t, server.SpanID, exported["/sql"].ParentSpanID)
+
+	assert.Equal(t, orphanTraceID, exported["/sql-later"].TraceID)
+	assert.False(t, exported["/sql-later"].ParentSpanID.IsValid())
+
+	// the re-parented SQL span is not a root span anymore
+	sql := exported["/sql"]
+	sqlSpan := GenerateTraces(&TracesConfig{}, &sql, nil).ResourceSpans().At(0).ScopeSpans().At(0).Spans().At(0)
+	assert.Equal(t, pcommon.SpanID(server.SpanID), sqlSpan.ParentSpanID())
+}
+
+func TestCorrelateRequests_OrphanedSQLOnlyUnderHTTP(t *testing.T) {
+	orphanTraceID := trace2.TraceID{7, 8, 9}
+	spans := []request.Span{
+		{Type: request.EventTypeGRPC, RequestStart: 100, Start: 100, End: 200},
+		{Type: request.EventTypeSQLClient, TraceID: orphanTraceID, RequestStart: 110, Start: 110, End: 120},
+		{Type: request.EventTypeHTTPClient, TraceID: orphanTraceID, RequestStart: 130, Start: 130, End: 140},
+	}
+	correlateRequests(spans)
+	for _, child := range spans[1:] {
+		assert.Equal(t, orphanTraceID, child.TraceID)
+		assert.False(t, child.ParentSpanID.IsValid())
+	}
+}
+
+func TestCorrelateRequests_Ambiguous(t *testing.T) {
+	spans := []request.Span{
+		{Type: request.EventTypeHTTP, RequestStart: 100, Start: 100, End: 200},
+		{Type: request.EventTypeHTTP, RequestStart: 105, Start: 105, End: 205},
+		{Type: request.EventTypeSQLClient, RequestStart: 110, Start: 110, End: 120},
+	}
+	correlateRequests(spans)
+	assert.False(t, spans[2].TraceID.IsValid())
+}
+
+func TestTracesReceiver_EnableSpanLinks(t *testing.T) {
+	pid := request.PidInfo{HostPID: 1234, Namespace: 1}
+	propagatedTrace := trace2.TraceID{7, 8, 9}
+	spans := []request.Span{
+		{Type: request.EventTypeHTTP, Path: "/http", Pid: pid, RequestStart: 100, Start: 100, End: 200},
+		{Type: request.EventTypeHTTPClient, Path: "/client", Pid: pid, RequestStart: 110, Start: 110, End: 120},
+		// client spans with propagated context are linked, too
+		{Type: request.EventTypeGRPCClient, Path: "/grpc", Pid: pid, TraceID: propagatedTrace,
+			ParentSpanID: trace2.SpanID{1}, RequestStart: 130, Start: 130, End: 140},
+		{Type: request.EventTypeHTTPClient, Path: "/client-later", Pid: pid, RequestStart: 300, Start: 300, End: 310},
+	}
+	original := slices.Clone(spans)
+
+	tr := newTracesOTELReceiver(context.Background(), TracesConfig{EnableSpanLinks: true}, nil, attributes.Selection{})
+	in := make(chan []request.Span, 1)
+	in <- spans
+	close(in)
+	exported := map[string]request.Span{}
+	tr.consume(in, func(s *request.Span) { exported[s.Path] = *s })
+	require.Len(t, exported, 4)
+
+	server := exported["/http"]
+	require.True(t, server.SpanID.IsValid())
+	for _, child := range []string{"/client", "/grpc"} {
+		span := exported[child]
+		traces := GenerateTraces(&TracesConfig{}, &span, nil)
+		links := traces.ResourceSpans().At(0).ScopeSpans().At(0).Spans().At(0).Links()
+		require.Equal(t, 1, links.Len(), child)
+		assert.Equal(t, server.TraceID, trace2.TraceID(links.At(0).TraceID()), child)
+		assert.Equal(t, server.SpanID, trace2.SpanID(links.At(0).SpanID()), child)
+	}
+	// links don't modify the trace context of the children
+	assert.False(t, exported["/client"].TraceID.IsValid())
+	assert.Equal(t, propagatedTrace, exported["/grpc"].TraceID)
+
+	for _, path := range []string{"/http", "/client-later"} {
+		span := exported[path]
+		traces := GenerateTraces(&TracesConfig{}, &span, nil)
+		assert.Zero(t, traces.ResourceSpans().At(0).ScopeSpans().At(0).Spans().At(0).Links().Len(), path)
+	}
+	// the links are only set in the copy of the batch that is exported as traces
+	assert.Equal(t, original, spans)
+}
@@ -0,0 +1,48 @@
+package otel
+
+import (
+	"time"
+
+	lru "github.com/hashicorp/golang-lru/v2"
+	trace2 "go.opentelemetry.io/otel/trace"
+
+	"github.com/grafana/beyla/pkg/internal/request"
+)
+
+// dedupCacheLen is the number of recently exported spans that are remembered to detect duplicates
+const dedupCacheLen = 4096
+
+type spanIdentity struct {
+	traceID trace2.TraceID
+	spanID  trace2.SpanID
+	start   int64
+}
+
+// spansDedup detects the spans with the same identity (trace ID, span ID and start time) that are
+// received again within a time window, e.g. because of retries or the re-entry of the eBPF probes.
+// It must be invoked from a single goroutine.
+type spansDedup struct {
+	window time.Duration
+	seen   *lru.Cache[spanIdentity, time.Time]
+	now    func() time.Time
+}
+
+func newSpansDedup(window time.Duration) *spansDedup {
+	seen, _ := lru.New[spanIdentity, time.Time](dedupCacheLen)
+	return &spansDedup{window: window, seen: seen, now: time.Now}
+}
+
+// isDuplicate returns whether a span with the same identity was already seen within the window
+func (sd *spansDedup) isDuplicate(span *request.Span) bool {
+	if !span.TraceID.IsValid() || !span.SpanID.IsValid() {
+		// the span will get random IDs, so it can't be identified
+		return false
+	}
+	id := spanIdentity{traceID: span.TraceID, spanID: span.SpanID, start: span.Start}
+	now := sd.now()
+	if last, ok := sd.seen.Get(id); ok && now.Sub(last) < sd.window {
+		return true
+	}
+	sd.seen.Add(id, now)
+	return false
+}
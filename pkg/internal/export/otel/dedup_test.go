@@ -0,0 +1,66 @@
+package otel
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	trace2 "go.opentelemetry.io/otel/trace"
+
+	"github.com/grafana/beyla/pkg/internal/imetrics"
+	"github.com/grafana/beyla/pkg/internal/pipe/global"
+	"github.com/grafana/beyla/pkg/internal/request"
+)
+
+type fakeDuplicateSpans struct {
+	imetrics.NoopReporter
+	duplicates atomic.Int32
+}
+
+func (f *fakeDuplicateSpans) OTELTraceDuplicateSpan() {
+	f.duplicates.Add(1)
+}
+
+func TestTracesReceiver_DedupWindow(t *testing.T) {
+	metrics := &fakeDuplicateSpans{}
+	tr := newTracesOTELReceiver(context.Background(), TracesConfig{DedupWindow: time.Second},
+		&global.ContextInfo{Metrics: metrics}, nil)
+	require.NotNil(t, tr.dedup)
+	now := time.Now()
+	tr.dedup.now = func() time.Time { return now }
+
+	traceID := trace2.TraceID{1, 2, 3}
+	span := func(path string, spanID byte, start int64) request.Span {
+		return request.Span{Type: request.EventTypeHTTP, Path: path, TraceID: traceID,
+			SpanID: trace2.SpanID{spanID}, Start: start, End: start + 10}
+	}
+	var exported []string
+	consume := func(spans ...request.Span) {
+		in := make(chan []request.Span, 1)
+		in <- spans
+		close(in)
+		tr.consume(in, func(s *request.Span) { exported = append(exported, s.Path) })
+	}
+
+	consume(
+		span("/original", 1, 100),
+		span("/duplicate", 1, 100),
+		span("/other-start", 1, 200),
+		span("/other-span", 2, 100),
+		// spans without identity are never deduplicated
+		request.Span{Type: request.EventTypeHTTP, Path: "/no-ids"},
+		request.Span{Type: request.EventTypeHTTP, Path: "/no-ids"},
+	)
+	// a duplicate received within the window is dropped
+	now = now.Add(500 * time.Millisecond)
+	consume(span("/duplicate-in-window", 1, 100))
+	// a duplicate received outside the window is exported
+	now = now.Add(2 * time.Second)
+	consume(span("/duplicate-after-window", 2, 100))
+
+	assert.Equal(t, []string{"/original", "/other-start", "/other-span", "/no-ids", "/no-ids", "/duplicate-after-window"}, exported)
+	assert.EqualValues(t, 2, metrics.duplicates.Load())
+}
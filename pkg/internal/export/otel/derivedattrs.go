@@ -0,0 +1,89 @@
+package otel
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"text/template"
+
+	"go.opentelemetry.io/collector/pdata/ptrace"
+
+	"github.com/grafana/beyla/pkg/internal/request"
+)
+
+// derivedAttrTemplates caches the parsed templates of the DerivedAttributes, by template text,
+// so they are parsed only once
+var derivedAttrTemplates sync.Map
+
+// derivedAttrData is the data the DerivedAttributes templates are evaluated against, e.g.
+// {{.Header "x-org"}} or {{.Span.Route}}
+type derivedAttrData struct {
+	Span *request.Span
+	// missing is set when the template references a header that wasn't captured
+	missing bool
+}
+
+// Header returns the first captured value of the given header, ignoring the case of its name
+func (d *derivedAttrData) Header(name string) string {
+	values := d.Span.RequestHeaders[strings.ToLower(name)]
+	if len(values) == 0 {
+		d.missing = true
+		return ""
+	}
+	return values[0]
+}
+
+func parseDerivedAttrTemplate(text string) (*template.Template, error) {
+	if tmpl, ok := derivedAttrTemplates.Load(text); ok {
+		return tmpl.(*template.Template), nil
+	}
+	tmpl, err := template.New("").Parse(text)
+	if err != nil {
+		return nil, err
+	}
+	derivedAttrTemplates.Store(text, tmpl)
+	return tmpl, nil
+}
+
+func validateDerivedAttributes(attrs map[string]string) error {
+	for name, text := range attrs {
+		if _, err := parseDerivedAttrTemplate(text); err != nil {
+			return fmt.Errorf("invalid template for derived attribute %q: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// addDerivedAttributes renders the DerivedAttributes templates for the span, and adds them to its
+// attributes. Unless DerivedAttributesAllowMissing is set, the attributes whose template references
+// any header that wasn't captured are omitted.
+func addDerivedAttributes(cfg *TracesConfig, span *request.Span, s ptrace.Span) {
+	if len(cfg.DerivedAttributes) == 0 {
+		return
+	}
+	names := make([]string, 0, len(cfg.DerivedAttributes))
+	for name := range cfg.DerivedAttributes {
+		names = append(names, name)
+	}
+	// sorting the names keeps the attributes order stable
+	slices.Sort(names)
+	sb := strings.Builder{}
+	for _, name := range names {
+		tmpl, err := parseDerivedAttrTemplate(cfg.DerivedAttributes[name])
+		if err != nil {
+			tlog().Debug("can't parse derived attribute template. Omitting it", "attribute", name, "error", err)
+			continue
+		}
+		data := derivedAttrData{Span: span}
+		sb.Reset()
+		if err := tmpl.Execute(&sb, &data); err != nil {
+			tlog().Debug("can't render derived attribute. Omitting it", "attribute", name, "error", err)
+			continue
+		}
+		if data.missing && !cfg.DerivedAttributesAllowMissing {
+			continue
+		}
+		s.Attributes().PutStr(name, sb.String())
+	}
+}
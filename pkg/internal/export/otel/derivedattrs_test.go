@@ -0,0 +1,53 @@
+package otel
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+
+	attr "github.com/grafana/beyla/pkg/internal/export/attributes/names"
+	"github.com/grafana/beyla/pkg/internal/request"
+)
+
+func TestDerivedAttributes(t *testing.T) {
+	derived := map[string]string{
+		"beyla.tenant": `{{.Header "X-Org"}}-{{.Header "x-env"}}`,
+		"beyla.route":  `{{.Span.Method}} {{.Span.Route}}`,
+	}
+	spanAttrs := func(cfg *TracesConfig, headers map[string][]string) pcommon.Map {
+		span := &request.Span{Type: request.EventTypeHTTP, Method: "GET", Route: "/users", RequestHeaders: headers}
+		traces := GenerateTraces(cfg, span, map[attr.Name]struct{}{})
+		return traces.ResourceSpans().At(0).ScopeSpans().At(0).Spans().At(0).Attributes()
+	}
+
+	t.Run("all headers available", func(t *testing.T) {
+		attrs := spanAttrs(&TracesConfig{DerivedAttributes: derived},
+			map[string][]string{"x-org": {"acme", "other"}, "x-env": {"prod"}})
+		ensureTraceStrAttr(t, attrs, "beyla.tenant", "acme-prod")
+		ensureTraceStrAttr(t, attrs, "beyla.route", "GET /users")
+	})
+	t.Run("missing headers", func(t *testing.T) {
+		headers := map[string][]string{"x-org": {"acme"}}
+		attrs := spanAttrs(&TracesConfig{DerivedAttributes: derived}, headers)
+		ensureTraceAttrNotExists(t, attrs, "beyla.tenant")
+		ensureTraceStrAttr(t, attrs, "beyla.route", "GET /users")
+
+		attrs = spanAttrs(&TracesConfig{DerivedAttributes: derived, DerivedAttributesAllowMissing: true}, headers)
+		ensureTraceStrAttr(t, attrs, "beyla.tenant", "acme-")
+		ensureTraceStrAttr(t, attrs, "beyla.route", "GET /users")
+	})
+	t.Run("no derived attributes", func(t *testing.T) {
+		attrs := spanAttrs(&TracesConfig{}, map[string][]string{"x-org": {"acme"}, "x-env": {"prod"}})
+		ensureTraceAttrNotExists(t, attrs, "beyla.tenant")
+	})
+}
+
+func TestDerivedAttributes_Invalid(t *testing.T) {
+	cfg := TracesConfig{DerivedAttributes: map[string]string{"beyla.tenant": `{{.Header "x-org"`}}
+	require.Error(t, cfg.Validate())
+
+	cfg = TracesConfig{DerivedAttributes: map[string]string{"beyla.tenant": `{{.Header "x-org"}}`}}
+	assert.NoError(t, cfg.Validate())
+}
@@ -0,0 +1,119 @@
+package otel
+
+import (
+	"fmt"
+	"log/slog"
+	"net/url"
+	"slices"
+)
+
+// EndpointSource identifies each of the configuration sources of the traces endpoint
+type EndpointSource string
+
+const (
+	// EndpointSourceTraces is the OTEL_EXPORTER_OTLP_TRACES_ENDPOINT variable, or the endpoint YAML property
+	EndpointSourceTraces EndpointSource = "traces"
+	// EndpointSourceCommon is the OTEL_EXPORTER_OTLP_ENDPOINT variable
+	EndpointSourceCommon EndpointSource = "common"
+	// EndpointSourceGrafana is the OTLP gateway of the Grafana Cloud configuration
+	EndpointSourceGrafana EndpointSource = "grafana"
+)
+
+var defaultEndpointPriority = []EndpointSource{EndpointSourceTraces, EndpointSourceCommon, EndpointSourceGrafana}
+
+func validateEndpointPriority(priority []EndpointSource) error {
+	seen := map[EndpointSource]struct{}{}
+	for _, src := range priority {
+		switch src {
+		case EndpointSourceTraces, EndpointSourceCommon, EndpointSourceGrafana:
+		default:
+			return fmt.Errorf("invalid endpoint priority source %q. Accepted values are: %s, %s, %s",
+				src, EndpointSourceTraces, EndpointSourceCommon, EndpointSourceGrafana)
+		}
+		if _, ok := seen[src]; ok {
+			return fmt.Errorf("endpoint priority source %q is repeated", src)
+		}
+		seen[src] = struct{}{}
+	}
+	return nil
+}
+
+// endpointSources returns the configured traces endpoints, sorted by the EndpointPriority. The
+// sources that are missing in the EndpointPriority are appended in their default order.
+func (m *TracesConfig) endpointSources() []endpointSource {
+	priority := make([]EndpointSource, 0, len(defaultEndpointPriority))
+	priority = append(priority, m.EndpointPriority...)
+	for _, src := range defaultEndpointPriority {
+		if !slices.Contains(priority, src) {
+			priority = append(priority, src)
+		}
+	}
+	var sources []endpointSource
+	for _, src := range priority {
+		switch src {
+		case EndpointSourceTraces:
+			if m.TracesEndpoint != "" {
+				sources = append(sources, endpointSource{source: src, endpoint: m.TracesEndpoint})
+			}
+		case EndpointSourceCommon:
+			if m.CommonEndpoint != "" {
+				sources = append(sources, endpointSource{source: src, endpoint: m.CommonEndpoint})
+			}
+		case EndpointSourceGrafana:
+			if m.Grafana.gatewayDefined() {
+				sources = append(sources, endpointSource{source: src, endpoint: m.Grafana.Endpoint()})
+			}
+		}
+	}
+	return sources
+}
+
+type endpointSource struct {
+	source   EndpointSource
+	endpoint string
+}
+
+// effectiveEndpoint returns the traces endpoint with the highest priority, as well as its source.
+// The source is empty if no endpoint is configured.
+func (m *TracesConfig) effectiveEndpoint() (string, EndpointSource) {
+	if sources := m.endpointSources(); len(sources) > 0 {
+		return sources[0].endpoint, sources[0].source
+	}
+	return "", ""
+}
+
+// signalEndpoints returns the effective endpoint as a signal-specific or a common endpoint, as expected
+// by parseEndpoint and guessProtocol. The Grafana endpoint is considered common, so the signal path is appended.
+func (m *TracesConfig) signalEndpoints() (signal, common string) {
+	endpoint, source := m.effectiveEndpoint()
+	if source == EndpointSourceTraces {
+		return endpoint, ""
+	}
+	return "", endpoint
+}
+
+// warnMultipleEndpoints logs which endpoint is used when the traces endpoint is configured from
+// multiple sources, as the lower priority ones are ignored.
+func (m *TracesConfig) warnMultipleEndpoints(log *slog.Logger) {
+	sources := m.endpointSources()
+	if len(sources) < 2 {
+		return
+	}
+	ignored := make([]string, 0, len(sources)-1)
+	for _, src := range sources[1:] {
+		ignored = append(ignored, string(src.source))
+	}
+	log.Warn("traces endpoint is configured from multiple sources. Only the highest priority one is used."+
+		" You can change it with the endpoint_priority property",
+		"endpoint", redactEndpoint(sources[0].endpoint), "source", sources[0].source, "ignored", ignored)
+}
+
+// redactEndpoint removes the user information from the endpoint, if any, so it can be logged
+func redactEndpoint(endpoint string) string {
+	u, err := url.Parse(endpoint)
+	if err != nil || u.User == nil {
+		return endpoint
+	}
+	u.User = nil
+	return u.String()
+}
@@ -0,0 +1,69 @@
+package otel
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/consumer"
+	"go.opentelemetry.io/collector/exporter"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+)
+
+// fanOutTracesExporter forwards the traces to multiple exporters
+type fanOutTracesExporter []exporter.Traces
+
+func (f fanOutTracesExporter) Start(ctx context.Context, host component.Host) error {
+	for _, exp := range f {
+		if err := exp.Start(ctx, host); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (f fanOutTracesExporter) Shutdown(ctx context.Context) error {
+	var errs []error
+	for _, exp := range f {
+		errs = append(errs, exp.Shutdown(ctx))
+	}
+	return errors.Join(errs...)
+}
+
+func (f fanOutTracesExporter) Capabilities() consumer.Capabilities {
+	return consumer.Capabilities{MutatesData: false}
+}
+
+// ConsumeTraces forwards the traces to all the exporters, even if some of them fail
+func (f fanOutTracesExporter) ConsumeTraces(ctx context.Context, td ptrace.Traces) error {
+	var errs []error
+	for _, exp := range f {
+		errs = append(errs, exp.ConsumeTraces(ctx, td))
+	}
+	return errors.Join(errs...)
+}
+
+// roundRobinTracesExporter distributes the traces across a pool of exporters
+type roundRobinTracesExporter struct {
+	exporters []exporter.Traces
+	next      atomic.Uint64
+}
+
+func (rr *roundRobinTracesExporter) Start(ctx context.Context, host component.Host) error {
+	return fanOutTracesExporter(rr.exporters).Start(ctx, host)
+}
+
+// Shutdown closes all the exporters of the pool
+func (rr *roundRobinTracesExporter) Shutdown(ctx context.Context) error {
+	return fanOutTracesExporter(rr.exporters).Shutdown(ctx)
+}
+
+func (rr *roundRobinTracesExporter) Capabilities() consumer.Capabilities {
+	return consumer.Capabilities{MutatesData: false}
+}
+
+func (rr *roundRobinTracesExporter) ConsumeTraces(ctx context.Context, td ptrace.Traces) error {
+	n := rr.next.Add(1) - 1
+	return rr.exporters[n%uint64(len(rr.exporters))].ConsumeTraces(ctx, td)
+}
@@ -0,0 +1,139 @@
+package otel
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/consumer"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+
+	"github.com/grafana/beyla/pkg/internal/pipe/global"
+)
+
+type countingExporter struct {
+	consumed int
+	shutdown bool
+}
+
+func (c *countingExporter) Start(_ context.Context, _ component.Host) error { return nil }
+
+func (c *countingExporter) Shutdown(_ context.Context) error {
+	c.shutdown = true
+	return nil
+}
+
+func (c *countingExporter) Capabilities() consumer.Capabilities { return consumer.Capabilities{} }
+
+func (c *countingExporter) ConsumeTraces(_ context.Context, _ ptrace.Traces) error {
+	c.consumed++
+	return nil
+}
+
+func TestGRPCConnPool(t *testing.T) {
+	cfg := TracesConfig{TracesEndpoint: "http://localhost:4317", Protocol: ProtocolGRPC}
+
+	t.Run("default pool size", func(t *testing.T) {
+		exp, err := getTracesExporter(context.Background(), cfg, &global.ContextInfo{})
+		require.NoError(t, err)
+		_, isPool := exp.(*roundRobinTracesExporter)
+		assert.False(t, isPool)
+	})
+
+	t.Run("pool of 3 connections", func(t *testing.T) {
+		poolCfg := cfg
+		poolCfg.GRPCConnPoolSize = 3
+		exp, err := getTracesExporter(context.Background(), poolCfg, &global.ContextInfo{})
+		require.NoError(t, err)
+		pool, ok := exp.(*roundRobinTracesExporter)
+		require.True(t, ok)
+		require.Len(t, pool.exporters, 3)
+		assert.NotSame(t, pool.exporters[0], pool.exporters[1])
+		assert.NotSame(t, pool.exporters[1], pool.exporters[2])
+	})
+}
+
+func TestRoundRobinTracesExporter(t *testing.T) {
+	exporters := []*countingExporter{{}, {}, {}}
+	pool := &roundRobinTracesExporter{}
+	for _, e := range exporters {
+		pool.exporters = append(pool.exporters, e)
+	}
+
+	for i := 0; i < 7; i++ {
+		require.NoError(t, pool.ConsumeTraces(context.Background(), ptrace.NewTraces()))
+	}
+	assert.Equal(t, 3, exporters[0].consumed)
+	assert.Equal(t, 2, exporters[1].consumed)
+	assert.Equal(t, 2, exporters[2].consumed)
+
+	require.NoError(t, pool.Shutdown(context.Background()))
+	for _, e := range exporters {
+		assert.True(t, e.shutdown)
+	}
+}
+
+func TestTracesProtocols(t *testing.T) {
+	t.Run("one exporter per protocol", func(t *testing.T) {
+		defer restoreEnvAfterExecution()()
+		cfg := TracesConfig{
+			CommonEndpoint: "http://localhost:4318",
+			Protocols:      []Protocol{ProtocolGRPC, ProtocolHTTPProtobuf},
+		}
+		exp, err := getTracesExporter(context.Background(), cfg, &global.ContextInfo{})
+		require.NoError(t, err)
+		fanOut, ok := exp.(fanOutTracesExporter)
+		require.True(t, ok)
+		assert.Len(t, fanOut, 2)
+	})
+
+	type testCase struct {
+		name  string
+		cfg   TracesConfig
+		ports []string
+	}
+	for _, tc := range []testCase{{
+		name: "common endpoint with HTTP port",
+		cfg: TracesConfig{
+			CommonEndpoint: "http://localhost:4318",
+			Protocols:      []Protocol{ProtocolGRPC, ProtocolHTTPProtobuf},
+		},
+		ports: []string{"4317", "4318"},
+	}, {
+		name: "traces endpoint with gRPC port",
+		cfg: TracesConfig{
+			TracesEndpoint: "https://collector:4317/v1/traces",
+			Protocols:      []Protocol{ProtocolGRPC, ProtocolHTTPJSON},
+		},
+		ports: []string{"4317", "4318"},
+	}, {
+		name: "port ending as the usual port is kept",
+		cfg: TracesConfig{
+			CommonEndpoint: "http://localhost:54318",
+			Protocols:      []Protocol{ProtocolGRPC, ProtocolHTTPProtobuf},
+		},
+		ports: []string{"54318", "54318"},
+	}, {
+		name: "non-standard port is kept",
+		cfg: TracesConfig{
+			CommonEndpoint: "http://localhost:9999",
+			Protocols:      []Protocol{ProtocolGRPC, ProtocolHTTPProtobuf},
+		},
+		ports: []string{"9999", "9999"},
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			configs, err := tc.cfg.protocolsConfigs()
+			require.NoError(t, err)
+			require.Len(t, configs, len(tc.cfg.Protocols))
+			for i := range configs {
+				assert.Equal(t, tc.cfg.Protocols[i], configs[i].getProtocol())
+				endpoint, _, err := parseTracesEndpoint(&configs[i])
+				require.NoError(t, err)
+				assert.Equal(t, tc.ports[i], endpoint.Port())
+				assert.Empty(t, configs[i].Protocols)
+			}
+		})
+	}
+}
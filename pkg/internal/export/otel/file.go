@@ -0,0 +1,140 @@
+package otel
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/consumer"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+)
+
+// defaultFileMaxBytes is the default size of the traces file before it is rotated
+const defaultFileMaxBytes = 100 * 1024 * 1024
+
+func flog() *slog.Logger {
+	return slog.With("component", "otel.fileTracesExporter")
+}
+
+// fileTracesExporter writes the traces as OTLP-JSON lines into a file. When the file would exceed the
+// maximum size, it is rotated and up to a maximum number of backups are kept, so the disk usage is bounded
+// to (backups + 1) * maxBytes.
+type fileTracesExporter struct {
+	path       string
+	maxBytes   int64
+	maxBackups int
+
+	marshaler ptrace.JSONMarshaler
+
+	mt   sync.Mutex
+	file *os.File
+	size int64
+}
+
+func newFileTracesExporter(cfg *TracesConfig) (*fileTracesExporter, error) {
+	if cfg.FilePath == "" {
+		return nil, errors.New("file protocol requires a traces file path")
+	}
+	fe := &fileTracesExporter{
+		path:       cfg.FilePath,
+		maxBytes:   cfg.FileMaxBytes,
+		maxBackups: cfg.FileMaxBackups,
+	}
+	if fe.maxBytes <= 0 {
+		fe.maxBytes = defaultFileMaxBytes
+	}
+	return fe, nil
+}
+
+func (fe *fileTracesExporter) Start(_ context.Context, _ component.Host) error {
+	fe.mt.Lock()
+	defer fe.mt.Unlock()
+	return fe.open()
+}
+
+func (fe *fileTracesExporter) Shutdown(_ context.Context) error {
+	fe.mt.Lock()
+	defer fe.mt.Unlock()
+	if fe.file == nil {
+		return nil
+	}
+	err := fe.file.Close()
+	fe.file = nil
+	return err
+}
+
+func (fe *fileTracesExporter) Capabilities() consumer.Capabilities {
+	return consumer.Capabilities{MutatesData: false}
+}
+
+func (fe *fileTracesExporter) ConsumeTraces(_ context.Context, td ptrace.Traces) error {
+	line, err := fe.marshaler.MarshalTraces(td)
+	if err != nil {
+		return fmt.Errorf("marshaling traces: %w", err)
+	}
+	line = append(line, '\n')
+
+	fe.mt.Lock()
+	defer fe.mt.Unlock()
+	if fe.file == nil {
+		return errors.New("traces file is not open")
+	}
+	if fe.size > 0 && fe.size+int64(len(line)) > fe.maxBytes {
+		if err := fe.rotate(); err != nil {
+			return fmt.Errorf("rotating traces file: %w", err)
+		}
+	}
+	n, err := fe.file.Write(line)
+	fe.size += int64(n)
+	return err
+}
+
+func (fe *fileTracesExporter) open() error {
+	file, err := os.OpenFile(fe.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return fmt.Errorf("opening traces file: %w", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return fmt.Errorf("reading traces file info: %w", err)
+	}
+	fe.file = file
+	fe.size = info.Size()
+	return nil
+}
+
+// rotate renames the current file as path.1, shifting the existing backups (path.1 to path.2 and so on)
+// and removing the oldest one, then opens a new file.
+func (fe *fileTracesExporter) rotate() error {
+	if err := fe.file.Close(); err != nil {
+		flog().Debug("closing traces file", "error", err)
+	}
+	fe.file = nil
+	if fe.maxBackups <= 0 {
+		if err := os.Remove(fe.path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return fe.open()
+	}
+	if err := os.Remove(fe.backupPath(fe.maxBackups)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	for i := fe.maxBackups - 1; i > 0; i-- {
+		if err := os.Rename(fe.backupPath(i), fe.backupPath(i+1)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	if err := os.Rename(fe.path, fe.backupPath(1)); err != nil {
+		return err
+	}
+	return fe.open()
+}
+
+func (fe *fileTracesExporter) backupPath(n int) string {
+	return fmt.Sprintf("%s.%d", fe.path, n)
+}
@@ -0,0 +1,88 @@
+package otel
+
+import (
+	"bufio"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+
+	"github.com/grafana/beyla/pkg/internal/pipe/global"
+	"github.com/grafana/beyla/pkg/internal/request"
+	"github.com/grafana/beyla/pkg/internal/svc"
+)
+
+func readTracesLines(t *testing.T, path string) []ptrace.Traces {
+	file, err := os.Open(path)
+	require.NoError(t, err)
+	defer file.Close()
+	var traces []ptrace.Traces
+	unmarshaler := ptrace.JSONUnmarshaler{}
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(nil, 1024*1024)
+	for scanner.Scan() {
+		td, err := unmarshaler.UnmarshalTraces(scanner.Bytes())
+		require.NoError(t, err)
+		traces = append(traces, td)
+	}
+	require.NoError(t, scanner.Err())
+	return traces
+}
+
+func TestFileTracesExporter(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "traces.json")
+	cfg := TracesConfig{Protocol: ProtocolFile, FilePath: path}
+	require.True(t, cfg.Enabled())
+
+	exp, err := getTracesExporter(context.Background(), cfg, &global.ContextInfo{})
+	require.NoError(t, err)
+	require.NoError(t, exp.Start(context.Background(), nil))
+
+	for _, p := range []string{"/foo", "/bar"} {
+		span := request.Span{Type: request.EventTypeHTTP, Method: "GET", Route: p, ServiceID: svc.ID{Name: "svc"}}
+		require.NoError(t, exp.ConsumeTraces(context.Background(), GenerateTraces(&TracesConfig{}, &span, nil)))
+	}
+	require.NoError(t, exp.Shutdown(context.Background()))
+
+	traces := readTracesLines(t, path)
+	require.Len(t, traces, 2)
+	for i, name := range []string{"GET /foo", "GET /bar"} {
+		rs := traces[i].ResourceSpans().At(0)
+		svcName, ok := rs.Resource().Attributes().Get("service.name")
+		require.True(t, ok)
+		assert.Equal(t, "svc", svcName.Str())
+		assert.Equal(t, name, rs.ScopeSpans().At(0).Spans().At(0).Name())
+	}
+}
+
+func TestFileTracesExporter_Rotation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "traces.json")
+	span := request.Span{Type: request.EventTypeHTTP, Method: "GET", Route: "/foo"}
+	line, err := (&ptrace.JSONMarshaler{}).MarshalTraces(GenerateTraces(&TracesConfig{}, &span, nil))
+	require.NoError(t, err)
+
+	// each file fits up to 3 lines
+	exp, err := newFileTracesExporter(&TracesConfig{FilePath: path, FileMaxBytes: int64(3*(len(line)+1) + 10), FileMaxBackups: 2})
+	require.NoError(t, err)
+	require.NoError(t, exp.Start(context.Background(), nil))
+	for i := 0; i < 10; i++ {
+		require.NoError(t, exp.ConsumeTraces(context.Background(), GenerateTraces(&TracesConfig{}, &span, nil)))
+	}
+	require.NoError(t, exp.Shutdown(context.Background()))
+
+	// 10 lines: the oldest file with 3 lines was removed, as we only keep 2 backups
+	assert.Len(t, readTracesLines(t, path), 1)
+	assert.Len(t, readTracesLines(t, path+".1"), 3)
+	assert.Len(t, readTracesLines(t, path+".2"), 3)
+	assert.NoFileExists(t, path+".3")
+}
+
+func TestFileTracesExporter_NoPath(t *testing.T) {
+	assert.False(t, TracesConfig{Protocol: ProtocolFile}.Enabled())
+	_, err := newFileTracesExporter(&TracesConfig{Protocol: ProtocolFile})
+	assert.Error(t, err)
+}
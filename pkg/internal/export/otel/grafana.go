@@ -3,6 +3,7 @@ package otel
 import (
 	"encoding/base64"
 	"fmt"
+	"net/url"
 	"strings"
 )
 
@@ -31,6 +32,11 @@ type GrafanaOTLP struct {
 	// CloudZone of your Grafana Endpoint. For example: prod-eu-west-0.
 	CloudZone string `yaml:"cloud_zone" env:"GRAFANA_CLOUD_ZONE"`
 
+	// GatewayURL overrides the OTLP gateway URL that is derived from the CloudZone, for Grafana
+	// instances whose gateway doesn't follow the Grafana Cloud URL template.
+	// For example: https://otlp.grafana.example.com/otlp
+	GatewayURL string `yaml:"cloud_gateway_url" env:"GRAFANA_CLOUD_GATEWAY_URL"`
+
 	// InstanceID is your Grafana user name. It is usually a number but it must be set as a
 	// string inside the YAML file.
 	InstanceID string `yaml:"cloud_instance_id" env:"GRAFANA_CLOUD_INSTANCE_ID"`
@@ -54,7 +60,12 @@ func (cfg *GrafanaOTLP) endpointEnabled() bool {
 	// we could force an AND condition below, but the error could
 	// remain unnoticed. This way, if the user forgets a field, they will
 	// see an error log during the metrics submission
-	return cfg.InstanceID != "" || cfg.APIKey != "" || cfg.CloudZone != ""
+	return cfg.InstanceID != "" || cfg.APIKey != "" || cfg.CloudZone != "" || cfg.GatewayURL != ""
+}
+
+// gatewayDefined returns whether the OTLP gateway can be resolved from the configuration
+func (cfg *GrafanaOTLP) gatewayDefined() bool {
+	return cfg != nil && (cfg.CloudZone != "" || cfg.GatewayURL != "")
 }
 
 func (cfg *GrafanaOTLP) submits(s string) bool {
@@ -69,10 +80,30 @@ func (cfg *GrafanaOTLP) submits(s string) bool {
 	return false
 }
 
+// Endpoint returns the explicit GatewayURL, if set, or otherwise the Grafana Cloud
+// OTLP gateway of the CloudZone.
 func (cfg *GrafanaOTLP) Endpoint() string {
+	if cfg.GatewayURL != "" {
+		return strings.TrimSuffix(cfg.GatewayURL, "/")
+	}
 	return fmt.Sprintf(grafanaOTLP, cfg.CloudZone)
 }
 
+// Validate checks that the GatewayURL, if set, is an absolute HTTP(S) URL
+func (cfg *GrafanaOTLP) Validate() error {
+	if cfg == nil || cfg.GatewayURL == "" {
+		return nil
+	}
+	gwURL, err := url.Parse(cfg.GatewayURL)
+	if err != nil {
+		return fmt.Errorf("invalid Grafana gateway URL %q: %w", cfg.GatewayURL, err)
+	}
+	if (gwURL.Scheme != "http" && gwURL.Scheme != "https") || gwURL.Host == "" {
+		return fmt.Errorf("invalid Grafana gateway URL %q: it must be an http or https URL with a host", cfg.GatewayURL)
+	}
+	return nil
+}
+
 func (cfg *GrafanaOTLP) AuthHeader() string {
 	return "Basic " + base64.StdEncoding.EncodeToString([]byte(cfg.InstanceID+":"+cfg.APIKey))
 }
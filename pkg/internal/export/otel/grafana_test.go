@@ -0,0 +1,24 @@
+package otel
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGrafanaOTLP_Endpoint(t *testing.T) {
+	assert.Equal(t, "https://otlp-gateway-prod-eu-west-0.grafana.net/otlp",
+		(&GrafanaOTLP{CloudZone: "prod-eu-west-0"}).Endpoint())
+	assert.Equal(t, "https://grafana.internal:8443/otlp",
+		(&GrafanaOTLP{CloudZone: "prod-eu-west-0", GatewayURL: "https://grafana.internal:8443/otlp/"}).Endpoint())
+}
+
+func TestGrafanaOTLP_Validate(t *testing.T) {
+	var nilCfg *GrafanaOTLP
+	assert.NoError(t, nilCfg.Validate())
+	assert.NoError(t, (&GrafanaOTLP{CloudZone: "prod-eu-west-0"}).Validate())
+	assert.NoError(t, (&GrafanaOTLP{GatewayURL: "http://grafana.internal/otlp"}).Validate())
+	for _, gw := range []string{"grafana.internal/otlp", "ftp://grafana.internal", "https://", "http://a b/%"} {
+		assert.Errorf(t, (&GrafanaOTLP{GatewayURL: gw}).Validate(), "expected error for %q", gw)
+	}
+}
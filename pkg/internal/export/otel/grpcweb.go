@@ -0,0 +1,55 @@
+package otel
+
+import (
+	"net/url"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.19.0"
+
+	"github.com/grafana/beyla/pkg/internal/request"
+)
+
+// gRPC-Web content types: application/grpc-web, application/grpc-web+proto, application/grpc-web-text...
+const grpcWebContentType = "application/grpc-web"
+
+// isGRPCWeb returns whether the HTTP span is a gRPC-Web request, which is reported with
+// the RPC semantics instead of the HTTP ones
+func isGRPCWeb(span *request.Span) bool {
+	if span.Type != request.EventTypeHTTP && span.Type != request.EventTypeHTTPClient {
+		return false
+	}
+	return strings.HasPrefix(strings.ToLower(span.RequestContentType), grpcWebContentType)
+}
+
+// grpcWebMethod returns the full gRPC method (/package.Service/Method) from the path of the request.
+// The HTTP client spans might contain the full URL.
+func grpcWebMethod(span *request.Span) string {
+	path := span.Path
+	if span.Type == request.EventTypeHTTPClient && strings.Contains(path, "://") {
+		if u, err := url.Parse(path); err == nil {
+			path = u.Path
+		}
+	}
+	if i := strings.IndexByte(path, '?'); i >= 0 {
+		path = path[:i]
+	}
+	return path
+}
+
+// grpcWebAttributes returns the RPC attributes of a gRPC-Web span. The gRPC status is sent in the
+// trailers of the response body, which are not captured, so the HTTP status code is reported instead.
+func grpcWebAttributes(cfg *TracesConfig, span *request.Span) []attribute.KeyValue {
+	attrs := []attribute.KeyValue{
+		semconv.RPCMethod(grpcWebMethod(span)),
+		semconv.RPCSystemGRPC,
+		request.HTTPResponseStatusCode(span.Status),
+	}
+	if span.Type == request.EventTypeHTTP {
+		attrs = append(attrs, request.ClientAddr(cfg.spanPeer(span)))
+	}
+	return append(attrs,
+		request.ServerAddr(cfg.spanHost(span)),
+		request.ServerPort(span.HostPort),
+	)
+}
@@ -0,0 +1,28 @@
+package otel
+
+import (
+	"go.opentelemetry.io/otel/attribute"
+
+	attr "github.com/grafana/beyla/pkg/internal/export/attributes/names"
+	"github.com/grafana/beyla/pkg/internal/request"
+)
+
+// httpSpanNameMethod replaces the unknown HTTP methods in the span names, following the semantic conventions
+const httpSpanNameMethod = "HTTP"
+
+// appendHTTPMethodOriginal adds the http.request.method_original attribute when the method of
+// the span is unknown, so it is reported as _OTHER in the http.request.method attribute
+func appendHTTPMethodOriginal(attrs []attribute.KeyValue, span *request.Span, knownMethods request.HTTPMethods) []attribute.KeyValue {
+	if span.Method == "" || knownMethods.Known(span.Method) {
+		return attrs
+	}
+	return append(attrs, attr.HTTPRequestMethodOriginal.OTEL().String(span.Method))
+}
+
+// spanNameMethod returns the method as reported in the names of the HTTP spans
+func spanNameMethod(method string, knownMethods request.HTTPMethods) string {
+	if knownMethods.Known(method) {
+		return method
+	}
+	return httpSpanNameMethod
+}
@@ -0,0 +1,79 @@
+package otel
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+
+	attr "github.com/grafana/beyla/pkg/internal/export/attributes/names"
+	"github.com/grafana/beyla/pkg/internal/request"
+)
+
+func TestTraceAttributes_HTTPMethod(t *testing.T) {
+	cfg := &TracesConfig{KnownHTTPMethods: request.HTTPMethods{"PROPFIND"}}
+	generate := func(spanType request.EventType, method string) ptrace.Span {
+		span := &request.Span{Type: spanType, Method: method, Path: "/files"}
+		return GenerateTraces(cfg, span, map[attr.Name]struct{}{}).
+			ResourceSpans().At(0).ScopeSpans().At(0).Spans().At(0)
+	}
+	spanAttrs := func(spanType request.EventType, method string) pcommon.Map {
+		return generate(spanType, method).Attributes()
+	}
+	for name, spanType := range map[string]request.EventType{"server": request.EventTypeHTTP, "client": request.EventTypeHTTPClient} {
+		t.Run(name, func(t *testing.T) {
+			// standard method
+			attrs := spanAttrs(spanType, "GET")
+			ensureTraceStrAttr(t, attrs, attr.HTTPRequestMethod.OTEL(), "GET")
+			ensureTraceAttrNotExists(t, attrs, attr.HTTPRequestMethodOriginal.OTEL())
+
+			// custom allowed method
+			attrs = spanAttrs(spanType, "PROPFIND")
+			ensureTraceStrAttr(t, attrs, attr.HTTPRequestMethod.OTEL(), "PROPFIND")
+			ensureTraceAttrNotExists(t, attrs, attr.HTTPRequestMethodOriginal.OTEL())
+
+			// unknown methods are normalized
+			attrs = spanAttrs(spanType, "MKCOL")
+			ensureTraceStrAttr(t, attrs, attr.HTTPRequestMethod.OTEL(), "_OTHER")
+			ensureTraceStrAttr(t, attrs, attr.HTTPRequestMethodOriginal.OTEL(), "MKCOL")
+
+			// the methods are case-sensitive
+			attrs = spanAttrs(spanType, "get")
+			ensureTraceStrAttr(t, attrs, attr.HTTPRequestMethod.OTEL(), "_OTHER")
+			ensureTraceStrAttr(t, attrs, attr.HTTPRequestMethodOriginal.OTEL(), "get")
+
+			// the original method is not reported when it is unknown
+			attrs = spanAttrs(spanType, "")
+			ensureTraceStrAttr(t, attrs, attr.HTTPRequestMethod.OTEL(), "_OTHER")
+			ensureTraceAttrNotExists(t, attrs, attr.HTTPRequestMethodOriginal.OTEL())
+
+			// the span names replace the unknown methods by HTTP
+			assert.Equal(t, "GET", generate(request.EventTypeHTTPClient, "GET").Name())
+			assert.Equal(t, "PROPFIND", generate(request.EventTypeHTTPClient, "PROPFIND").Name())
+			assert.Equal(t, "HTTP", generate(request.EventTypeHTTPClient, "MKCOL").Name())
+		})
+	}
+}
+
+func TestMetricsAttributes_HTTPMethod(t *testing.T) {
+	knownMethods := request.HTTPMethods{"PROPFIND"}
+	otelGetter, ok := request.SpanOTELGetters(knownMethods)(attr.HTTPRequestMethod)
+	require.True(t, ok)
+	promGetter, ok := request.SpanPromGetters(knownMethods)(attr.HTTPRequestMethod)
+	require.True(t, ok)
+	for method, expected := range map[string]string{"GET": "GET", "PROPFIND": "PROPFIND", "MKCOL": "_OTHER"} {
+		span := &request.Span{Type: request.EventTypeHTTP, Method: method}
+		assert.Equal(t, expected, otelGetter(span).Value.AsString())
+		assert.Equal(t, expected, promGetter(span))
+	}
+	assert.Equal(t, "HTTP /files/{id}",
+		TraceName(&request.Span{Type: request.EventTypeHTTP, Method: "MKCOL", Route: "/files/{id}"}, knownMethods))
+	assert.Equal(t, "PROPFIND /files/{id}",
+		TraceName(&request.Span{Type: request.EventTypeHTTP, Method: "PROPFIND", Route: "/files/{id}"}, knownMethods))
+
+	// without extra known methods, only the standard methods are reported as they are
+	otelGetter, _ = request.SpanOTELGetters(nil)(attr.HTTPRequestMethod)
+	assert.Equal(t, "_OTHER", otelGetter(&request.Span{Type: request.EventTypeHTTP, Method: "PROPFIND"}).Value.AsString())
+}
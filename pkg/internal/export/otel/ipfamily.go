@@ -0,0 +1,108 @@
+package otel
+
+import (
+	"context"
+	"fmt"
+	"net"
+
+	"google.golang.org/grpc/resolver"
+)
+
+// Accepted values for the TracesConfig.IPFamily option
+const (
+	IPFamilyAuto = "auto"
+	IPFamilyIPv4 = "ipv4"
+	IPFamilyIPv6 = "ipv6"
+)
+
+// gRPC resolver schemes that only resolve the addresses of a given IP family
+const (
+	resolverSchemeIPv4 = "beyla-ipv4"
+	resolverSchemeIPv6 = "beyla-ipv6"
+)
+
+func init() {
+	resolver.Register(&ipFamilyResolverBuilder{scheme: resolverSchemeIPv4, network: "ip4"})
+	resolver.Register(&ipFamilyResolverBuilder{scheme: resolverSchemeIPv6, network: "ip6"})
+}
+
+func validateIPFamily(family string) error {
+	switch family {
+	case "", IPFamilyAuto, IPFamilyIPv4, IPFamilyIPv6:
+		return nil
+	}
+	return fmt.Errorf("invalid IP family %q. Accepted values are: %s, %s, %s",
+		family, IPFamilyAuto, IPFamilyIPv4, IPFamilyIPv6)
+}
+
+// ipFamilyTarget returns the gRPC dial target that only connects to the addresses of the given IP family,
+// or the unmodified endpoint if the family is automatically selected.
+func ipFamilyTarget(family, endpoint string, hostPort string) string {
+	switch family {
+	case IPFamilyIPv4:
+		return resolverSchemeIPv4 + ":///" + hostPort
+	case IPFamilyIPv6:
+		return resolverSchemeIPv6 + ":///" + hostPort
+	}
+	return endpoint
+}
+
+// ipFamilyResolverBuilder builds gRPC name resolvers that discard the addresses of the target
+// host that don't belong to the configured IP family (ip4 or ip6).
+type ipFamilyResolverBuilder struct {
+	scheme  string
+	network string
+}
+
+func (b *ipFamilyResolverBuilder) Scheme() string {
+	return b.scheme
+}
+
+func (b *ipFamilyResolverBuilder) Build(target resolver.Target, cc resolver.ClientConn, _ resolver.BuildOptions) (resolver.Resolver, error) {
+	r := &ipFamilyResolver{network: b.network, endpoint: target.Endpoint(), cc: cc}
+	r.resolve()
+	return r, nil
+}
+
+type ipFamilyResolver struct {
+	network  string
+	endpoint string
+	cc       resolver.ClientConn
+}
+
+func (r *ipFamilyResolver) ResolveNow(resolver.ResolveNowOptions) {
+	go r.resolve()
+}
+
+func (r *ipFamilyResolver) Close() {}
+
+func (r *ipFamilyResolver) resolve() {
+	addrs, err := lookupFamily(context.Background(), r.network, r.endpoint)
+	if err != nil {
+		r.cc.ReportError(err)
+		return
+	}
+	state := resolver.State{Addresses: make([]resolver.Address, 0, len(addrs))}
+	for _, addr := range addrs {
+		state.Addresses = append(state.Addresses, resolver.Address{Addr: addr})
+	}
+	_ = r.cc.UpdateState(state)
+}
+
+// lookupFamily resolves the host of a host:port endpoint, returning only the
+// addresses of the given network (ip4 or ip6)
+func lookupFamily(ctx context.Context, network, endpoint string) ([]string, error) {
+	host, port, err := net.SplitHostPort(endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("parsing endpoint %q: %w", endpoint, err)
+	}
+	ips, err := net.DefaultResolver.LookupIP(ctx, network, host)
+	if err != nil {
+		return nil, fmt.Errorf("resolving %s addresses of %q: %w", network, host, err)
+	}
+	addrs := make([]string, 0, len(ips))
+	for _, ip := range ips {
+		addrs = append(addrs, net.JoinHostPort(ip.String(), port))
+	}
+	return addrs, nil
+}
@@ -0,0 +1,80 @@
+package otel
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/connectivity"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+func TestValidateIPFamily(t *testing.T) {
+	for _, family := range []string{"", IPFamilyAuto, IPFamilyIPv4, IPFamilyIPv6} {
+		assert.NoError(t, (&TracesConfig{IPFamily: family}).Validate(), family)
+	}
+	assert.Error(t, (&TracesConfig{IPFamily: "ipv5"}).Validate())
+	assert.Error(t, (&TracesConfig{IPFamily: "IPv4"}).Validate())
+}
+
+func TestIPFamilyTarget(t *testing.T) {
+	assert.Equal(t, "https://host:4317", ipFamilyTarget("", "https://host:4317", "host:4317"))
+	assert.Equal(t, "https://host:4317", ipFamilyTarget(IPFamilyAuto, "https://host:4317", "host:4317"))
+	assert.Equal(t, "beyla-ipv4:///host:4317", ipFamilyTarget(IPFamilyIPv4, "https://host:4317", "host:4317"))
+	assert.Equal(t, "beyla-ipv6:///host:4317", ipFamilyTarget(IPFamilyIPv6, "https://host:4317", "host:4317"))
+}
+
+func TestLookupFamily(t *testing.T) {
+	ctx := context.Background()
+	addrs, err := lookupFamily(ctx, "ip4", "127.0.0.1:4317")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"127.0.0.1:4317"}, addrs)
+	_, err = lookupFamily(ctx, "ip6", "127.0.0.1:4317")
+	assert.Error(t, err)
+
+	addrs, err = lookupFamily(ctx, "ip6", "[::1]:4317")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"[::1]:4317"}, addrs)
+	_, err = lookupFamily(ctx, "ip4", "[::1]:4317")
+	assert.Error(t, err)
+
+	_, err = lookupFamily(ctx, "ip4", "missing-port")
+	assert.Error(t, err)
+}
+
+func TestIPFamilyDialer(t *testing.T) {
+	lis, err := net.Listen("tcp4", "127.0.0.1:0")
+	require.NoError(t, err)
+	server := grpc.NewServer()
+	go func() { _ = server.Serve(lis) }()
+	defer server.Stop()
+	hostPort := lis.Addr().String()
+
+	dial := func(family string) *grpc.ClientConn {
+		conn, err := grpc.Dial(ipFamilyTarget(family, hostPort, hostPort),
+			grpc.WithTransportCredentials(insecure.NewCredentials()))
+		require.NoError(t, err)
+		conn.Connect()
+		return conn
+	}
+
+	// the IPv4 listener is reachable when the dialer is restricted to IPv4 addresses
+	ipv4 := dial(IPFamilyIPv4)
+	defer ipv4.Close()
+	require.Eventually(t, func() bool {
+		return ipv4.GetState() == connectivity.Ready
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// but not when it is restricted to IPv6 addresses, as the listener address has no IPv6 address
+	ipv6 := dial(IPFamilyIPv6)
+	defer ipv6.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	for state := ipv6.GetState(); state != connectivity.Ready && ipv6.WaitForStateChange(ctx, state); state = ipv6.GetState() {
+	}
+	assert.NotEqual(t, connectivity.Ready, ipv6.GetState())
+}
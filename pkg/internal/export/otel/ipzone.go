@@ -0,0 +1,37 @@
+package otel
+
+import (
+	"net/netip"
+	"strings"
+
+	"github.com/grafana/beyla/pkg/internal/request"
+)
+
+// stripIPv6Zone removes the zone identifier of the IPv6 addresses (e.g. fe80::1%eth0 becomes fe80::1).
+// Other addresses and host names are returned as they are.
+func stripIPv6Zone(address string) string {
+	zoneStart := strings.IndexByte(address, '%')
+	if zoneStart < 0 {
+		return address
+	}
+	if ip, err := netip.ParseAddr(address); err != nil || !ip.Is6() {
+		return address
+	}
+	return address[:zoneStart]
+}
+
+// spanHost returns the server address of the span, without IPv6 zone identifier unless KeepIPv6Zone is set
+func (m *TracesConfig) spanHost(span *request.Span) string {
+	if m.KeepIPv6Zone {
+		return request.SpanHost(span)
+	}
+	return stripIPv6Zone(request.SpanHost(span))
+}
+
+// spanPeer returns the client address of the span, without IPv6 zone identifier unless KeepIPv6Zone is set
+func (m *TracesConfig) spanPeer(span *request.Span) string {
+	if m.KeepIPv6Zone {
+		return request.SpanPeer(span)
+	}
+	return stripIPv6Zone(request.SpanPeer(span))
+}
@@ -0,0 +1,49 @@
+package otel
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	attr "github.com/grafana/beyla/pkg/internal/export/attributes/names"
+	"github.com/grafana/beyla/pkg/internal/request"
+)
+
+func TestStripIPv6Zone(t *testing.T) {
+	for _, tc := range []struct {
+		address  string
+		expected string
+	}{
+		{address: "fe80::1%eth0", expected: "fe80::1"},
+		{address: "fe80::a00:27ff:fe4e:66a1%enp0s3", expected: "fe80::a00:27ff:fe4e:66a1"},
+		{address: "fe80::1", expected: "fe80::1"},
+		{address: "2001:db8::1", expected: "2001:db8::1"},
+		{address: "10.0.0.1", expected: "10.0.0.1"},
+		{address: "my-service.svc%weird", expected: "my-service.svc%weird"},
+		{address: "", expected: ""},
+	} {
+		t.Run(tc.address, func(t *testing.T) {
+			assert.Equal(t, tc.expected, stripIPv6Zone(tc.address))
+		})
+	}
+}
+
+func TestTraceAttributes_IPv6Zone(t *testing.T) {
+	span := &request.Span{Type: request.EventTypeHTTP, Method: "GET", Peer: "fe80::2%eth0", Host: "fe80::1%eth1", HostPort: 8080}
+
+	traces := GenerateTraces(&TracesConfig{}, span, map[attr.Name]struct{}{})
+	attrs := traces.ResourceSpans().At(0).ScopeSpans().At(0).Spans().At(0).Attributes()
+	ensureTraceStrAttr(t, attrs, attr.ClientAddr.OTEL(), "fe80::2")
+	ensureTraceStrAttr(t, attrs, attr.ServerAddr.OTEL(), "fe80::1")
+
+	traces = GenerateTraces(&TracesConfig{KeepIPv6Zone: true}, span, map[attr.Name]struct{}{})
+	attrs = traces.ResourceSpans().At(0).ScopeSpans().At(0).Spans().At(0).Attributes()
+	ensureTraceStrAttr(t, attrs, attr.ClientAddr.OTEL(), "fe80::2%eth0")
+	ensureTraceStrAttr(t, attrs, attr.ServerAddr.OTEL(), "fe80::1%eth1")
+
+	// addresses without zone are reported as they are
+	client := &request.Span{Type: request.EventTypeHTTPClient, Method: "GET", Host: "2001:db8::1", HostPort: 443}
+	traces = GenerateTraces(&TracesConfig{}, client, map[attr.Name]struct{}{})
+	attrs = traces.ResourceSpans().At(0).ScopeSpans().At(0).Spans().At(0).Attributes()
+	ensureTraceStrAttr(t, attrs, attr.ServerAddr.OTEL(), "2001:db8::1")
+}
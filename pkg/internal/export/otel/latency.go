@@ -0,0 +1,23 @@
+package otel
+
+import "time"
+
+// LatencyBucket classifies the spans whose duration is lower than UpTo with the given Label,
+// which is reported in the beyla.latency_bucket attribute.
+type LatencyBucket struct {
+	// UpTo is the exclusive upper bound of the bucket. Zero means unbounded, so it can be used
+	// as the last bucket to classify the rest of spans.
+	UpTo  time.Duration `yaml:"up_to"`
+	Label string        `yaml:"label"`
+}
+
+// latencyBucket returns the label of the first bucket that accepts the given duration, or an empty
+// string if none of them accepts it. The buckets are expected to be sorted by ascending UpTo.
+func latencyBucket(buckets []LatencyBucket, duration time.Duration) string {
+	for i := range buckets {
+		if buckets[i].UpTo == 0 || duration < buckets[i].UpTo {
+			return buckets[i].Label
+		}
+	}
+	return ""
+}
@@ -96,6 +96,10 @@ type MetricsConfig struct {
 
 	// Grafana configuration needs to be explicitly set up before building the graph
 	Grafana *GrafanaOTLP `yaml:"-"`
+
+	// KnownHTTPMethods needs to be explicitly set up from the TracesConfig before building the graph,
+	// so the traces and the metrics report the same HTTP methods
+	KnownHTTPMethods request.HTTPMethods `yaml:"-"`
 }
 
 func (m *MetricsConfig) GetProtocol() Protocol {
@@ -109,19 +113,22 @@ func (m *MetricsConfig) GetProtocol() Protocol {
 }
 
 func (m *MetricsConfig) GuessProtocol() Protocol {
-	// If no explicit protocol is set, we guess it it from the metrics enpdoint port
-	// (assuming it uses a standard port or a development-like form like 14317, 24317, 14318...)
-	ep, _, err := parseMetricsEndpoint(m)
-	if err == nil {
-		if strings.HasSuffix(ep.Port(), UsualPortGRPC) {
-			return ProtocolGRPC
-		} else if strings.HasSuffix(ep.Port(), UsualPortHTTP) {
-			return ProtocolHTTPProtobuf
-		}
+	return guessProtocol(m.MetricsEndpoint, m.CommonEndpoint, m.Grafana, "")
+}
+
+// Validate rejects the protocols that are only supported by the traces exporter, which
+// could be set through the OTEL_EXPORTER_OTLP_PROTOCOL variable shared with the traces.
+func (m *MetricsConfig) Validate() error {
+	if !m.EndpointEnabled() {
+		return nil
+	}
+	switch proto := m.GetProtocol(); proto {
+	case ProtocolUnset, ProtocolGRPC, ProtocolHTTPJSON, ProtocolHTTPProtobuf:
+		return nil
+	default:
+		return fmt.Errorf("invalid metrics protocol value: %q. Accepted values are: %s, %s, %s",
+			proto, ProtocolGRPC, ProtocolHTTPJSON, ProtocolHTTPProtobuf)
 	}
-	// Otherwise we return default protocol according to the latest specification:
-	// https://github.com/open-telemetry/opentelemetry-specification/blob/main/specification/protocol/exporter.md?plain=1#L53
-	return ProtocolHTTPProtobuf
 }
 
 // EndpointEnabled specifies that the OTEL metrics node is enabled if and only if
@@ -232,20 +239,21 @@ func newMetricsReporter(
 		attributes: attribProvider,
 	}
 	// initialize attribute getters
+	spanGetters := request.SpanOTELGetters(cfg.KnownHTTPMethods)
 	mr.attrHTTPDuration = attributes.OpenTelemetryGetters(
-		request.SpanOTELGetters, mr.attributes.For(attributes.HTTPServerDuration))
+		spanGetters, mr.attributes.For(attributes.HTTPServerDuration))
 	mr.attrHTTPClientDuration = attributes.OpenTelemetryGetters(
-		request.SpanOTELGetters, mr.attributes.For(attributes.HTTPClientDuration))
+		spanGetters, mr.attributes.For(attributes.HTTPClientDuration))
 	mr.attrHTTPRequestSize = attributes.OpenTelemetryGetters(
-		request.SpanOTELGetters, mr.attributes.For(attributes.HTTPServerRequestSize))
+		spanGetters, mr.attributes.For(attributes.HTTPServerRequestSize))
 	mr.attrHTTPClientRequestSize = attributes.OpenTelemetryGetters(
-		request.SpanOTELGetters, mr.attributes.For(attributes.HTTPClientRequestSize))
+		spanGetters, mr.attributes.For(attributes.HTTPClientRequestSize))
 	mr.attrGRPCServer = attributes.OpenTelemetryGetters(
-		request.SpanOTELGetters, mr.attributes.For(attributes.RPCServerDuration))
+		spanGetters, mr.attributes.For(attributes.RPCServerDuration))
 	mr.attrGRPCClient = attributes.OpenTelemetryGetters(
-		request.SpanOTELGetters, mr.attributes.For(attributes.RPCClientDuration))
+		spanGetters, mr.attributes.For(attributes.RPCClientDuration))
 	mr.attrSQLClient = attributes.OpenTelemetryGetters(
-		request.SpanOTELGetters, mr.attributes.For(attributes.SQLClientDuration))
+		spanGetters, mr.attributes.For(attributes.SQLClientDuration))
 
 	mr.reporters = NewReporterPool[*Metrics](cfg.ReportersCacheLen,
 		func(id svc.UID, v *Metrics) {
@@ -606,7 +614,7 @@ func (mr *MetricsReporter) spanMetricAttributes(span *request.Span) attribute.Se
 		semconv.ServiceInstanceID(span.ServiceID.Instance),
 		semconv.ServiceNamespace(span.ServiceID.Namespace),
 		request.SpanKindMetric(SpanKindString(span)),
-		request.SpanNameMetric(TraceName(span)),
+		request.SpanNameMetric(TraceName(span, mr.cfg.KnownHTTPMethods)),
 		request.StatusCodeMetric(int(SpanStatusCode(span))),
 		request.SourceMetric("beyla"),
 	}
@@ -799,24 +807,7 @@ func getGRPCMetricEndpointOptions(cfg *MetricsConfig) (otlpOptions, error) {
 // If, by some reason, Grafana changes its OTLP Gateway URL in a distant future, you can still point to the
 // correct URL with the OTLP_EXPORTER_... variables.
 func parseMetricsEndpoint(cfg *MetricsConfig) (*url.URL, bool, error) {
-	isCommon := false
-	endpoint := cfg.MetricsEndpoint
-	if endpoint == "" {
-		isCommon = true
-		endpoint = cfg.CommonEndpoint
-		if endpoint == "" && cfg.Grafana != nil && cfg.Grafana.CloudZone != "" {
-			endpoint = cfg.Grafana.Endpoint()
-		}
-	}
-
-	murl, err := url.Parse(endpoint)
-	if err != nil {
-		return nil, isCommon, fmt.Errorf("parsing endpoint URL %s: %w", endpoint, err)
-	}
-	if murl.Scheme == "" || murl.Host == "" {
-		return nil, isCommon, fmt.Errorf("URL %q must have a scheme and a host", endpoint)
-	}
-	return murl, isCommon, nil
+	return parseEndpoint(cfg.MetricsEndpoint, cfg.CommonEndpoint, cfg.Grafana)
 }
 
 // HACK: at the time of writing this, the otelpmetrichttp API does not support explicitly
@@ -356,6 +356,16 @@ func TestMetricsConfig_Disabled(t *testing.T) {
 	assert.False(t, MetricsConfig{Grafana: &GrafanaOTLP{Submit: []string{"traces", "metrics"}, InstanceID: "33221"}}.Enabled())
 }
 
+func TestMetricsConfig_Validate(t *testing.T) {
+	assert.NoError(t, (&MetricsConfig{MetricsEndpoint: "foo", Protocol: ProtocolGRPC}).Validate())
+	assert.NoError(t, (&MetricsConfig{MetricsEndpoint: "foo"}).Validate())
+	// the traces-only protocols are not accepted
+	assert.Error(t, (&MetricsConfig{MetricsEndpoint: "foo", Protocol: ProtocolFile}).Validate())
+	assert.Error(t, (&MetricsConfig{MetricsEndpoint: "foo", MetricsProtocol: ProtocolZipkin}).Validate())
+	// unless the metrics exporter is disabled, as the protocol can be set for the traces
+	assert.NoError(t, (&MetricsConfig{Protocol: ProtocolFile}).Validate())
+}
+
 func (f *fakeInternalMetrics) OTELMetricExport(len int) {
 	fakeMux.Lock()
 	defer fakeMux.Unlock()
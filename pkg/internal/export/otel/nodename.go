@@ -0,0 +1,42 @@
+package otel
+
+import (
+	"os"
+	"sync"
+
+	"go.opentelemetry.io/otel/attribute"
+
+	attr "github.com/grafana/beyla/pkg/internal/export/attributes/names"
+	"github.com/grafana/beyla/pkg/internal/svc"
+)
+
+// envNodeName is the environment variable that provides the name of the node where Beyla runs.
+// In Kubernetes, it is usually populated from the spec.nodeName field, via the downward API.
+const envNodeName = "K8S_NODE_NAME"
+
+// nodeName is resolved only once, as it doesn't change during the Beyla execution
+var nodeName = sync.OnceValue(func() string {
+	return lookupNodeName(os.Getenv, os.Hostname)
+})
+
+// lookupNodeName returns the node name from the environment, falling back to the hostname.
+func lookupNodeName(getenv func(string) string, hostname func() (string, error)) string {
+	if name := getenv(envNodeName); name != "" {
+		return name
+	}
+	name, err := hostname()
+	if err != nil {
+		tlog().Debug("can't get the hostname. Omitting the node name", "error", err)
+		return ""
+	}
+	return name
+}
+
+// nodeNameAttrs returns the k8s.node.name resource attribute, unless the service metadata
+// already provides it (e.g. from the Kubernetes decoration of the service Pod).
+func nodeNameAttrs(service *svc.ID, node string) []attribute.KeyValue {
+	if _, ok := service.Metadata[attr.K8sNodeName]; ok || node == "" {
+		return nil
+	}
+	return []attribute.KeyValue{attr.K8sNodeName.OTEL().String(node)}
+}
@@ -0,0 +1,50 @@
+package otel
+
+import (
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	attr "github.com/grafana/beyla/pkg/internal/export/attributes/names"
+	"github.com/grafana/beyla/pkg/internal/request"
+	"github.com/grafana/beyla/pkg/internal/svc"
+)
+
+func TestLookupNodeName(t *testing.T) {
+	hostname := func() (string, error) { return "the-host", nil }
+	failingHostname := func() (string, error) { return "", errors.New("boom") }
+
+	t.Setenv(envNodeName, "")
+	assert.Equal(t, "the-host", lookupNodeName(os.Getenv, hostname))
+	assert.Empty(t, lookupNodeName(os.Getenv, failingHostname))
+
+	t.Setenv(envNodeName, "node-1")
+	assert.Equal(t, "node-1", lookupNodeName(os.Getenv, hostname))
+	assert.Equal(t, "node-1", lookupNodeName(os.Getenv, failingHostname))
+}
+
+func TestTraces_EmitNodeName(t *testing.T) {
+	t.Setenv(envNodeName, "node-1")
+	oldNodeName := nodeName
+	nodeName = func() string { return lookupNodeName(os.Getenv, os.Hostname) }
+	defer func() { nodeName = oldNodeName }()
+
+	resourceAttrs := func(cfg *TracesConfig, span *request.Span) map[string]any {
+		traces := GenerateTraces(cfg, span, map[attr.Name]struct{}{})
+		return traces.ResourceSpans().At(0).Resource().Attributes().AsRaw()
+	}
+	span := &request.Span{Type: request.EventTypeHTTP, ServiceID: svc.ID{Name: "svc"}}
+
+	assert.NotContains(t, resourceAttrs(&TracesConfig{}, span), string(attr.K8sNodeName))
+	attrs := resourceAttrs(&TracesConfig{EmitNodeName: true}, span)
+	require.Contains(t, attrs, string(attr.K8sNodeName))
+	assert.Equal(t, "node-1", attrs[string(attr.K8sNodeName)])
+
+	// the node name from the Kubernetes metadata of the service is kept
+	span.ServiceID.Metadata = map[attr.Name]string{attr.K8sNodeName: "pod-node"}
+	attrs = resourceAttrs(&TracesConfig{EmitNodeName: true}, span)
+	assert.Equal(t, "pod-node", attrs[string(attr.K8sNodeName)])
+}
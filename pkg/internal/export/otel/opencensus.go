@@ -0,0 +1,27 @@
+package otel
+
+import (
+	"context"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/exporter/opencensusexporter"
+	"go.opentelemetry.io/collector/exporter"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+// newOpenCensusTracesExporter instantiates the OpenCensus exporter of the OpenTelemetry collector, which
+// translates the generated traces to the OpenCensus format and streams them to an OpenCensus agent or
+// collector through gRPC. As the Zipkin exporter, it does not trace its own telemetry.
+func newOpenCensusTracesExporter(ctx context.Context, cfg *TracesConfig) (exporter.Traces, error) {
+	opts, err := getGRPCTracesEndpointOptions(cfg)
+	if err != nil {
+		return nil, err
+	}
+	factory := opencensusexporter.NewFactory()
+	config := factory.CreateDefaultConfig().(*opencensusexporter.Config)
+	config.QueueSettings.Enabled = false
+	config.Endpoint = opts.Endpoint
+	config.TLSSetting.Insecure = opts.Insecure
+	config.TLSSetting.InsecureSkipVerify = cfg.InsecureSkipVerify
+	config.Headers = convertHeaders(opts.HTTPHeaders)
+	return factory.CreateTracesExporter(ctx, exporterSettings(noop.NewTracerProvider()), config)
+}
@@ -0,0 +1,80 @@
+package otel
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	agenttracepb "github.com/census-instrumentation/opencensus-proto/gen-go/agent/trace/v1"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/translator/opencensus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+
+	attr "github.com/grafana/beyla/pkg/internal/export/attributes/names"
+	"github.com/grafana/beyla/pkg/internal/request"
+	"github.com/grafana/beyla/pkg/internal/svc"
+)
+
+// fakeOCAgent receives the spans that are streamed by the OpenCensus exporter
+type fakeOCAgent struct {
+	agenttracepb.UnimplementedTraceServiceServer
+	received chan *agenttracepb.ExportTraceServiceRequest
+}
+
+func (f *fakeOCAgent) Export(stream agenttracepb.TraceService_ExportServer) error {
+	for {
+		req, err := stream.Recv()
+		if err != nil {
+			return err
+		}
+		f.received <- req
+	}
+}
+
+func TestOpenCensusTracesExporter(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	agent := &fakeOCAgent{received: make(chan *agenttracepb.ExportTraceServiceRequest, 1)}
+	server := grpc.NewServer()
+	agenttracepb.RegisterTraceServiceServer(server, agent)
+	go func() { _ = server.Serve(listener) }()
+	defer server.Stop()
+
+	cfg := TracesConfig{CommonEndpoint: "http://" + listener.Addr().String(), Protocol: ProtocolOpenCensus}
+	exp, err := getEndpointTracesExporter(context.Background(), cfg, nil)
+	require.NoError(t, err)
+	require.NoError(t, exp.Start(context.Background(), nil))
+	defer func() { require.NoError(t, exp.Shutdown(context.Background())) }()
+
+	span := request.Span{Type: request.EventTypeHTTP, Method: "GET", Route: "/users", Status: 200,
+		RequestStart: 100, Start: 100, End: 200,
+		TraceID:   trace.TraceID{1, 2, 3},
+		ServiceID: svc.ID{Name: "users-svc"}}
+	traces := GenerateTraces(&TracesConfig{}, &span, map[attr.Name]struct{}{})
+	require.NoError(t, exp.ConsumeTraces(context.Background(), traces))
+	req := <-agent.received
+	assert.Equal(t, "users-svc", req.Node.GetServiceInfo().GetName())
+	require.Len(t, req.Spans, 1)
+	assert.Equal(t, "GET /users", req.Spans[0].GetName().GetValue())
+
+	// the received OpenCensus span is translated back to the generated span
+	roundTrip := opencensus.OCToTraces(req.Node, req.Resource, req.Spans)
+	require.Equal(t, 1, roundTrip.SpanCount())
+	expected := traces.ResourceSpans().At(0).ScopeSpans().At(0).Spans().At(0)
+	actual := roundTrip.ResourceSpans().At(0).ScopeSpans().At(0).Spans().At(0)
+	assert.Equal(t, expected.Name(), actual.Name())
+	assert.Equal(t, expected.TraceID(), actual.TraceID())
+	assert.Equal(t, expected.SpanID(), actual.SpanID())
+	assert.Equal(t, ptrace.SpanKindServer, actual.Kind())
+	assert.Equal(t, expected.StartTimestamp(), actual.StartTimestamp())
+	assert.Equal(t, expected.EndTimestamp(), actual.EndTimestamp())
+	method, ok := actual.Attributes().Get(string(attr.HTTPRequestMethod))
+	require.True(t, ok)
+	assert.Equal(t, "GET", method.Str())
+	serviceName, ok := roundTrip.ResourceSpans().At(0).Resource().Attributes().Get("service.name")
+	require.True(t, ok)
+	assert.Equal(t, "users-svc", serviceName.Str())
+}
@@ -0,0 +1,94 @@
+package otel
+
+import (
+	"sync"
+	"time"
+
+	"github.com/grafana/beyla/pkg/internal/imetrics"
+	"github.com/grafana/beyla/pkg/internal/request"
+)
+
+// Accepted values for the TracesConfig.QueueOverflowPolicy option
+const (
+	QueueOverflowDropNew    = "drop_new"
+	QueueOverflowDropOldest = "drop_oldest"
+	QueueOverflowBlock      = "block"
+)
+
+const (
+	// same default as the batch span processor of the OTEL SDK
+	defaultMaxQueueSize      = 2048
+	defaultQueueBlockTimeout = time.Second
+)
+
+// queueOverflow applies the configured policy when the queue of spans waiting to be exported is full
+type queueOverflow struct {
+	policy  string
+	timeout time.Duration
+	metrics imetrics.Reporter
+	// evictMt serializes the enqueuing of spans in drop_oldest policy
+	evictMt sync.Mutex
+}
+
+// newOverflowExportPool returns an export pool whose queue has the MaxQueueSize, and applies the
+// QueueOverflowPolicy when it is full
+func newOverflowExportPool(workers int, cfg *TracesConfig, metrics imetrics.Reporter, send func(*request.Span)) *exportPool {
+	policy := cfg.QueueOverflowPolicy
+	switch policy {
+	case QueueOverflowDropNew, QueueOverflowDropOldest, QueueOverflowBlock:
+	default:
+		tlog().Warn("unknown queue overflow policy. Defaulting to "+QueueOverflowDropNew, "policy", policy)
+		policy = QueueOverflowDropNew
+	}
+	size := cfg.MaxQueueSize
+	if size <= 0 {
+		size = defaultMaxQueueSize
+	}
+	timeout := cfg.QueueBlockTimeout
+	if timeout <= 0 {
+		timeout = defaultQueueBlockTimeout
+	}
+	p := newQueuedExportPool(workers, size, send)
+	p.overflow = &queueOverflow{policy: policy, timeout: timeout, metrics: metrics}
+	return p
+}
+
+func (o *queueOverflow) enqueue(queue chan request.Span, span *request.Span) {
+	switch o.policy {
+	case QueueOverflowDropOldest:
+		o.evictMt.Lock()
+		defer o.evictMt.Unlock()
+		for {
+			select {
+			case queue <- *span:
+				return
+			default:
+			}
+			// queue is full: drop the oldest span to make room for the new one
+			select {
+			case <-queue:
+				o.metrics.OTELTraceQueueOverflow(o.policy)
+			default:
+			}
+		}
+	case QueueOverflowBlock:
+		select {
+		case queue <- *span:
+			return
+		default:
+		}
+		timer := time.NewTimer(o.timeout)
+		defer timer.Stop()
+		select {
+		case queue <- *span:
+		case <-timer.C:
+			o.metrics.OTELTraceQueueOverflow(o.policy)
+		}
+	default:
+		select {
+		case queue <- *span:
+		default:
+			o.metrics.OTELTraceQueueOverflow(o.policy)
+		}
+	}
+}
@@ -0,0 +1,157 @@
+package otel
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/consumer"
+	"go.opentelemetry.io/collector/exporter"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+
+	"github.com/grafana/beyla/pkg/internal/imetrics"
+	"github.com/grafana/beyla/pkg/internal/pipe/global"
+	"github.com/grafana/beyla/pkg/internal/request"
+)
+
+const overflowTestTimeout = 5 * time.Second
+
+// blockingExporter simulates a slow collector, by blocking the submission
+// of traces until it is unblocked
+type blockingExporter struct {
+	received chan string
+	unblock  chan struct{}
+	mt       sync.Mutex
+	names    []string
+}
+
+func newBlockingExporter() *blockingExporter {
+	return &blockingExporter{received: make(chan string, 10), unblock: make(chan struct{})}
+}
+
+func (be *blockingExporter) Start(_ context.Context, _ component.Host) error { return nil }
+
+func (be *blockingExporter) Shutdown(_ context.Context) error { return nil }
+
+func (be *blockingExporter) Capabilities() consumer.Capabilities { return consumer.Capabilities{} }
+
+func (be *blockingExporter) ConsumeTraces(_ context.Context, traces ptrace.Traces) error {
+	name := traces.ResourceSpans().At(0).ScopeSpans().At(0).Spans().At(0).Name()
+	be.received <- name
+	<-be.unblock
+	be.mt.Lock()
+	be.names = append(be.names, name)
+	be.mt.Unlock()
+	return nil
+}
+
+type fakeQueueOverflows struct {
+	imetrics.NoopReporter
+	overflows atomic.Int32
+	policy    atomic.Value
+}
+
+func (f *fakeQueueOverflows) OTELTraceQueueOverflow(policy string) {
+	f.overflows.Add(1)
+	f.policy.Store(policy)
+}
+
+func routeSpan(route string) request.Span {
+	return request.Span{Type: request.EventTypeHTTP, Method: "GET", Route: route, Status: 200}
+}
+
+// overflowingReceiver starts a traces node whose exporter is blocked. It submits the first span,
+// which blocks the exporter, and then fills the export queue of 2 spans
+func overflowingReceiver(t *testing.T, cfg TracesConfig, metrics imetrics.Reporter) (chan<- []request.Span, <-chan struct{}, *blockingExporter) {
+	t.Setenv(envTracesProtocol, "")
+	cfg.TracesEndpoint = "http://collector:4318"
+	cfg.MaxQueueSize = 2
+	exp := newBlockingExporter()
+	tr := newTracesOTELReceiver(context.Background(), cfg, &global.ContextInfo{Metrics: metrics}, nil)
+	tr.newExporter = func(_ context.Context, _ TracesConfig, _ *global.ContextInfo) (exporter.Traces, error) {
+		return exp, nil
+	}
+	loop, err := tr.provideLoop()
+	require.NoError(t, err)
+	in := make(chan []request.Span, 10)
+	done := make(chan struct{})
+	go func() {
+		loop(in)
+		close(done)
+	}()
+
+	in <- []request.Span{routeSpan("/1")}
+	select {
+	case <-exp.received:
+	case <-time.After(overflowTestTimeout):
+		require.Fail(t, "timeout waiting for the first span")
+	}
+	in <- []request.Span{routeSpan("/2"), routeSpan("/3")}
+	return in, done, exp
+}
+
+func waitDone(t *testing.T, done <-chan struct{}) {
+	select {
+	case <-done:
+	case <-time.After(overflowTestTimeout):
+		require.Fail(t, "timeout waiting for the traces node to finish")
+	}
+}
+
+func TestTracesReceiver_QueueOverflowPolicy(t *testing.T) {
+	for _, tc := range []struct {
+		policy   string
+		exported []string
+	}{
+		{policy: QueueOverflowDropNew, exported: []string{"GET /1", "GET /2", "GET /3"}},
+		{policy: QueueOverflowDropOldest, exported: []string{"GET /1", "GET /3", "GET /4"}},
+		{policy: QueueOverflowBlock, exported: []string{"GET /1", "GET /2", "GET /3"}},
+	} {
+		t.Run(tc.policy, func(t *testing.T) {
+			metrics := &fakeQueueOverflows{}
+			in, done, exp := overflowingReceiver(t, TracesConfig{
+				QueueOverflowPolicy: tc.policy,
+				QueueBlockTimeout:   20 * time.Millisecond,
+			}, metrics)
+
+			in <- []request.Span{routeSpan("/4")}
+			assert.Eventually(t, func() bool {
+				return metrics.overflows.Load() == 1
+			}, overflowTestTimeout, 10*time.Millisecond)
+			assert.Equal(t, tc.policy, metrics.policy.Load())
+
+			close(exp.unblock)
+			close(in)
+			waitDone(t, done)
+			assert.Equal(t, tc.exported, exp.names)
+			assert.EqualValues(t, 1, metrics.overflows.Load())
+		})
+	}
+}
+
+func TestTracesReceiver_QueueOverflowBlockUntilRoom(t *testing.T) {
+	metrics := &fakeQueueOverflows{}
+	in, done, exp := overflowingReceiver(t, TracesConfig{
+		QueueOverflowPolicy: QueueOverflowBlock,
+		QueueBlockTimeout:   overflowTestTimeout,
+	}, metrics)
+
+	in <- []request.Span{routeSpan("/4")}
+	// the span is blocked until there is room in the queue
+	select {
+	case name := <-exp.received:
+		require.Failf(t, "no span should have been exported", "got %s", name)
+	case <-time.After(50 * time.Millisecond):
+	}
+	// releasing the exporter makes room in the queue
+	close(exp.unblock)
+	close(in)
+	waitDone(t, done)
+	assert.Zero(t, metrics.overflows.Load())
+	assert.Equal(t, []string{"GET /1", "GET /2", "GET /3", "GET /4"}, exp.names)
+}
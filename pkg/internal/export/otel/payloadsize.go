@@ -0,0 +1,14 @@
+package otel
+
+import (
+	"go.opentelemetry.io/collector/pdata/ptrace"
+)
+
+// measurePayloadSize reports the size of the traces once serialized as an OTLP protobuf message.
+// For exporters using other encodings (e.g. OTLP/JSON or Zipkin) it is an estimation, but still
+// useful to tune the MaxExportBatchSize.
+func (tr *tracesOTELReceiver) measurePayloadSize(traces ptrace.Traces) {
+	size := (&ptrace.ProtoMarshaler{}).TracesSize(traces)
+	tlog().Debug("submitting traces", "spans", traces.SpanCount(), "bytes", size)
+	tr.internalMetrics().OTELTraceExportBytes(size)
+}
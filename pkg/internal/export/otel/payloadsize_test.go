@@ -0,0 +1,63 @@
+package otel
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+
+	attr "github.com/grafana/beyla/pkg/internal/export/attributes/names"
+	"github.com/grafana/beyla/pkg/internal/imetrics"
+	"github.com/grafana/beyla/pkg/internal/pipe/global"
+	"github.com/grafana/beyla/pkg/internal/request"
+)
+
+type fakeExportBytes struct {
+	imetrics.NoopReporter
+	mt    sync.Mutex
+	sizes []int
+}
+
+func (f *fakeExportBytes) OTELTraceExportBytes(size int) {
+	f.mt.Lock()
+	defer f.mt.Unlock()
+	f.sizes = append(f.sizes, size)
+}
+
+func TestConsumeTraces_MeasurePayloadSize(t *testing.T) {
+	small := GenerateTraces(&TracesConfig{}, &request.Span{Type: request.EventTypeHTTP, Path: "/a"},
+		map[attr.Name]struct{}{})
+	big := GenerateTraces(&TracesConfig{}, &request.Span{Type: request.EventTypeHTTP, Path: "/a/much/longer/path"},
+		map[attr.Name]struct{}{})
+
+	metrics := &fakeExportBytes{}
+	tr := newTracesOTELReceiver(context.Background(), TracesConfig{MeasurePayloadSize: true},
+		&global.ContextInfo{Metrics: metrics}, nil)
+	exp := &blockingConsumer{unblock: make(chan struct{})}
+	close(exp.unblock)
+
+	require.NoError(t, tr.consumeTraces(exp, small))
+	require.NoError(t, tr.consumeTraces(exp, big))
+	require.NoError(t, tr.consumeTraces(exp, ptrace.NewTraces()))
+
+	// one observation per export, with the serialized size of each submission
+	require.Len(t, metrics.sizes, 3)
+	assert.Equal(t, (&ptrace.ProtoMarshaler{}).TracesSize(small), metrics.sizes[0])
+	assert.Greater(t, metrics.sizes[1], metrics.sizes[0])
+	assert.Zero(t, metrics.sizes[2])
+	assert.EqualValues(t, 3, exp.calls.Load())
+}
+
+func TestConsumeTraces_DoNotMeasurePayloadSize(t *testing.T) {
+	metrics := &fakeExportBytes{}
+	tr := newTracesOTELReceiver(context.Background(), TracesConfig{},
+		&global.ContextInfo{Metrics: metrics}, nil)
+	exp := &blockingConsumer{unblock: make(chan struct{})}
+	close(exp.unblock)
+
+	require.NoError(t, tr.consumeTraces(exp, ptrace.NewTraces()))
+	assert.Empty(t, metrics.sizes)
+}
@@ -0,0 +1,66 @@
+package otel
+
+import (
+	"fmt"
+	"net/netip"
+	"strings"
+
+	"github.com/grafana/beyla/pkg/internal/request"
+)
+
+// peerCIDRs matches the peer IPs of the spans against a set of CIDRs, which are
+// split by IP family so each address is only compared with the prefixes of its family.
+type peerCIDRs struct {
+	ipv4 []netip.Prefix
+	ipv6 []netip.Prefix
+}
+
+// parsePeerCIDRs accepts CIDRs (e.g. 10.0.0.0/8) as well as single IP addresses.
+func parsePeerCIDRs(cidrs []string) (*peerCIDRs, error) {
+	pc := &peerCIDRs{}
+	for _, cidr := range cidrs {
+		cidr = strings.TrimSpace(cidr)
+		prefix, err := netip.ParsePrefix(cidr)
+		if err != nil {
+			addr, addrErr := netip.ParseAddr(cidr)
+			if addrErr != nil {
+				return nil, fmt.Errorf("invalid CIDR %q: %w", cidr, err)
+			}
+			addr = addr.Unmap()
+			prefix = netip.PrefixFrom(addr, addr.BitLen())
+		}
+		prefix = prefix.Masked()
+		if prefix.Addr().Is4() {
+			pc.ipv4 = append(pc.ipv4, prefix)
+		} else {
+			pc.ipv6 = append(pc.ipv6, prefix)
+		}
+	}
+	return pc, nil
+}
+
+func (pc *peerCIDRs) contains(ip string) bool {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return false
+	}
+	// IPv4-mapped IPv6 addresses (e.g. ::ffff:10.0.0.1) are matched against the IPv4 CIDRs
+	addr = addr.Unmap()
+	prefixes := pc.ipv6
+	if addr.Is4() {
+		prefixes = pc.ipv4
+	}
+	for _, prefix := range prefixes {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// ignoredPeer returns whether the server span has to be dropped because its peer IP is in
+// any of the IgnorePeerCIDRs. The Peer of the client spans is the local address, so they
+// are never dropped.
+func (pc *peerCIDRs) ignoredPeer(span *request.Span) bool {
+	return !span.IsClientSpan() && pc.contains(span.Peer)
+}
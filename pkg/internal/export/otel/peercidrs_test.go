@@ -0,0 +1,51 @@
+package otel
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/beyla/pkg/internal/export/attributes"
+	"github.com/grafana/beyla/pkg/internal/request"
+)
+
+func TestIgnorePeerCIDRs(t *testing.T) {
+	spans := []request.Span{
+		{Type: request.EventTypeHTTP, Path: "/scanner", Peer: "10.1.2.3", Status: 404},
+		{Type: request.EventTypeHTTP, Path: "/monitor", Peer: "192.168.1.7", Status: 200},
+		{Type: request.EventTypeHTTP, Path: "/mapped", Peer: "::ffff:10.0.0.1", Status: 200},
+		{Type: request.EventTypeHTTP, Path: "/ipv6", Peer: "fd12::1", Status: 200},
+		{Type: request.EventTypeHTTP, Path: "/scanner-error", Peer: "10.1.2.3", Status: 500},
+		{Type: request.EventTypeHTTP, Path: "/outside", Peer: "11.0.0.1", Status: 200},
+		{Type: request.EventTypeHTTP, Path: "/outside-host", Peer: "192.168.1.8", Status: 200},
+		{Type: request.EventTypeHTTP, Path: "/outside-ipv6", Peer: "fe80::1", Status: 200},
+		{Type: request.EventTypeHTTP, Path: "/no-peer", Status: 200},
+		// the peer of the client spans is the local address
+		{Type: request.EventTypeHTTPClient, Path: "/client", Peer: "10.1.2.3", Status: 200},
+	}
+	consume := func(cfg TracesConfig) []string {
+		require.NoError(t, cfg.Validate())
+		tr := newTracesOTELReceiver(context.Background(), cfg, nil, attributes.Selection{})
+		in := make(chan []request.Span, 1)
+		in <- spans
+		close(in)
+		var exported []string
+		tr.consume(in, func(s *request.Span) { exported = append(exported, s.Path) })
+		return exported
+	}
+	cidrs := []string{"10.0.0.0/8", "192.168.1.7", "fd00::/8"}
+
+	assert.Equal(t, []string{"/scanner-error", "/outside", "/outside-host", "/outside-ipv6", "/no-peer", "/client"},
+		consume(TracesConfig{IgnorePeerCIDRs: cidrs, AlwaysSampleErrors: true}))
+	assert.Equal(t, []string{"/outside", "/outside-host", "/outside-ipv6", "/no-peer", "/client"},
+		consume(TracesConfig{IgnorePeerCIDRs: cidrs}))
+}
+
+func TestIgnorePeerCIDRs_Invalid(t *testing.T) {
+	for _, cidr := range []string{"10.0.0.0/33", "10.0.0", "localhost"} {
+		cfg := TracesConfig{IgnorePeerCIDRs: []string{"10.0.0.0/8", cidr}}
+		assert.Errorf(t, cfg.Validate(), "expected error for %q", cidr)
+	}
+}
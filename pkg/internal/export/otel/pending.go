@@ -0,0 +1,120 @@
+package otel
+
+import (
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/grafana/beyla/pkg/internal/request"
+	"github.com/grafana/beyla/pkg/internal/svc"
+)
+
+// Accepted values for the TracesConfig.UnresolvedServiceFallback option
+const (
+	UnresolvedServiceEmit = "emit"
+	UnresolvedServiceDrop = "drop"
+)
+
+// maxPendingSpans limits the number of spans that are buffered for a given service instance.
+// When the limit is reached, the buffered spans are handled as if their grace period expired.
+const maxPendingSpans = 1000
+
+func plog() *slog.Logger {
+	return slog.With("component", "otel.pendingServices")
+}
+
+// pendingServices buffers the spans from service instances whose name hasn't been resolved yet
+// (e.g. because the service discovery is still in progress) until a span from the same service
+// instance provides the resolved service information, or a grace period expires.
+type pendingServices struct {
+	gracePeriod time.Duration
+	dropExpired bool
+	clock       func() time.Time
+	services    map[svc.UID]*pendingService
+}
+
+type pendingService struct {
+	since time.Time
+	spans []request.Span
+}
+
+func validateUnresolvedServiceFallback(fallback string) error {
+	switch fallback {
+	case "", UnresolvedServiceEmit, UnresolvedServiceDrop:
+		return nil
+	}
+	return fmt.Errorf("invalid unresolved_service_fallback %q. Accepted values: %s, %s", fallback,
+		UnresolvedServiceEmit, UnresolvedServiceDrop)
+}
+
+func newPendingServices(gracePeriod time.Duration, fallback string) *pendingServices {
+	return &pendingServices{
+		gracePeriod: gracePeriod,
+		dropExpired: fallback == UnresolvedServiceDrop,
+		clock:       time.Now,
+		services:    map[svc.UID]*pendingService{},
+	}
+}
+
+// forward invokes the export function for the provided span, unless the name of its service is still
+// pending. In that case, the span is buffered. If the span provides the resolved name of a pending service,
+// the buffered spans of that service are forwarded before it, using the resolved service information.
+func (ps *pendingServices) forward(span *request.Span, export func(*request.Span)) {
+	uid := span.ServiceID.UID
+	if span.ServiceID.Name == "" {
+		p, ok := ps.services[uid]
+		if !ok {
+			p = &pendingService{since: ps.clock()}
+			ps.services[uid] = p
+		}
+		p.spans = append(p.spans, *span)
+		if len(p.spans) >= maxPendingSpans {
+			delete(ps.services, uid)
+			ps.fallback(p, export)
+		}
+		return
+	}
+	if p, ok := ps.services[uid]; ok {
+		delete(ps.services, uid)
+		for i := range p.spans {
+			p.spans[i].ServiceID = span.ServiceID
+			export(&p.spans[i])
+		}
+	}
+	export(span)
+}
+
+// expirePeriod returns how often the grace period of the pending services must be checked, so
+// the fallback is applied no later than half a grace period after it expires
+func (ps *pendingServices) expirePeriod() time.Duration {
+	return max(ps.gracePeriod/2, time.Millisecond)
+}
+
+// expire applies the configured fallback to the spans of the services whose grace period has expired
+func (ps *pendingServices) expire(export func(*request.Span)) {
+	now := ps.clock()
+	for uid, p := range ps.services {
+		if now.Sub(p.since) >= ps.gracePeriod {
+			delete(ps.services, uid)
+			ps.fallback(p, export)
+		}
+	}
+}
+
+// flush applies the configured fallback to all the pending spans, regardless of their grace period
+func (ps *pendingServices) flush(export func(*request.Span)) {
+	for uid, p := range ps.services {
+		delete(ps.services, uid)
+		ps.fallback(p, export)
+	}
+}
+
+func (ps *pendingServices) fallback(p *pendingService, export func(*request.Span)) {
+	if ps.dropExpired {
+		plog().Debug("dropping spans from unresolved service", "spans", len(p.spans))
+		return
+	}
+	for i := range p.spans {
+		export(&p.spans[i])
+	}
+}
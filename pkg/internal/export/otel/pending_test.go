@@ -0,0 +1,114 @@
+package otel
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/beyla/pkg/internal/request"
+	"github.com/grafana/beyla/pkg/internal/svc"
+)
+
+func TestPendingServices_ResolvedWithinGracePeriod(t *testing.T) {
+	now := time.Now()
+	ps := newPendingServices(time.Minute, UnresolvedServiceDrop)
+	ps.clock = func() time.Time { return now }
+
+	var exported []request.Span
+	export := func(s *request.Span) { exported = append(exported, *s) }
+
+	ps.forward(&request.Span{Path: "/first", ServiceID: svc.ID{UID: "1"}}, export)
+	ps.forward(&request.Span{Path: "/second", ServiceID: svc.ID{UID: "1"}}, export)
+	ps.forward(&request.Span{Path: "/other", ServiceID: svc.ID{UID: "2", Name: "other"}}, export)
+	// only the span from the resolved service is exported
+	assert.Len(t, exported, 1)
+	assert.Equal(t, "/other", exported[0].Path)
+
+	now = now.Add(30 * time.Second)
+	ps.expire(export)
+	assert.Len(t, exported, 1)
+
+	ps.forward(&request.Span{Path: "/third", ServiceID: svc.ID{UID: "1", Name: "resolved"}}, export)
+	assert.Len(t, exported, 4)
+	for i, path := range []string{"/first", "/second", "/third"} {
+		assert.Equal(t, path, exported[i+1].Path)
+		assert.Equal(t, "resolved", exported[i+1].ServiceID.Name)
+	}
+	assert.Empty(t, ps.services)
+}
+
+func TestPendingServices_AfterGracePeriod(t *testing.T) {
+	for _, tc := range []struct {
+		fallback string
+		exported int
+	}{
+		{fallback: "", exported: 2},
+		{fallback: UnresolvedServiceEmit, exported: 2},
+		{fallback: UnresolvedServiceDrop, exported: 0},
+	} {
+		t.Run("fallback: "+tc.fallback, func(t *testing.T) {
+			now := time.Now()
+			ps := newPendingServices(time.Minute, tc.fallback)
+			ps.clock = func() time.Time { return now }
+
+			var exported []request.Span
+			export := func(s *request.Span) { exported = append(exported, *s) }
+
+			ps.forward(&request.Span{Path: "/first", ServiceID: svc.ID{UID: "1"}}, export)
+			ps.forward(&request.Span{Path: "/second", ServiceID: svc.ID{UID: "1"}}, export)
+			assert.Empty(t, exported)
+
+			now = now.Add(time.Minute)
+			ps.expire(export)
+			assert.Len(t, exported, tc.exported)
+			for _, s := range exported {
+				assert.Empty(t, s.ServiceID.Name)
+			}
+			assert.Empty(t, ps.services)
+
+			// further resolution of the service does not export the already expired spans
+			ps.forward(&request.Span{Path: "/third", ServiceID: svc.ID{UID: "1", Name: "resolved"}}, export)
+			assert.Len(t, exported, tc.exported+1)
+		})
+	}
+}
+
+func TestTracesReceiver_FlushPendingOnShutdown(t *testing.T) {
+	tr := newTracesOTELReceiver(context.Background(), TracesConfig{ServiceIDGracePeriod: time.Hour}, nil, nil)
+	in := make(chan []request.Span, 1)
+	in <- []request.Span{{Path: "/pending", ServiceID: svc.ID{UID: "1"}}}
+	close(in)
+
+	var exported []request.Span
+	tr.consume(in, func(s *request.Span) { exported = append(exported, *s) })
+	assert.Len(t, exported, 1)
+	assert.Equal(t, "/pending", exported[0].Path)
+}
+
+func TestTracesReceiver_ExpirePendingWithoutInput(t *testing.T) {
+	tr := newTracesOTELReceiver(context.Background(), TracesConfig{ServiceIDGracePeriod: 50 * time.Millisecond}, nil, nil)
+	in := make(chan []request.Span, 1)
+	in <- []request.Span{{Path: "/pending", ServiceID: svc.ID{UID: "1"}}}
+	defer close(in)
+
+	exported := make(chan string, 1)
+	go tr.consume(in, func(s *request.Span) { exported <- s.Path })
+
+	// the span is exported after the grace period, even if no further spans are received
+	select {
+	case path := <-exported:
+		assert.Equal(t, "/pending", path)
+	case <-time.After(timeout):
+		require.Fail(t, "the pending span was not exported after the grace period")
+	}
+}
+
+func TestUnresolvedServiceFallback_Validate(t *testing.T) {
+	for _, fallback := range []string{"", UnresolvedServiceEmit, UnresolvedServiceDrop} {
+		assert.NoError(t, (&TracesConfig{UnresolvedServiceFallback: fallback}).Validate(), fallback)
+	}
+	assert.Error(t, (&TracesConfig{UnresolvedServiceFallback: "buffer"}).Validate())
+}
@@ -0,0 +1,50 @@
+package otel
+
+import (
+	"sync"
+
+	"github.com/grafana/beyla/pkg/internal/request"
+)
+
+// exportPool submits the spans to a pool of workers that concurrently send them
+type exportPool struct {
+	spans chan request.Span
+	wg    sync.WaitGroup
+
+	// overflow is only set when the QueueOverflowPolicy is defined
+	overflow *queueOverflow
+}
+
+func newExportPool(workers int, send func(*request.Span)) *exportPool {
+	return newQueuedExportPool(workers, workers, send)
+}
+
+func newQueuedExportPool(workers, queueSize int, send func(*request.Span)) *exportPool {
+	p := &exportPool{spans: make(chan request.Span, queueSize)}
+	p.wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go func() {
+			defer p.wg.Done()
+			for span := range p.spans {
+				send(&span)
+			}
+		}()
+	}
+	return p
+}
+
+// submit enqueues a copy of the span. If no overflow policy is defined, it blocks
+// while the queue is full
+func (p *exportPool) submit(span *request.Span) {
+	if p.overflow != nil {
+		p.overflow.enqueue(p.spans, span)
+		return
+	}
+	p.spans <- *span
+}
+
+// close stops accepting spans and waits for the workers to send all the enqueued spans
+func (p *exportPool) close() {
+	close(p.spans)
+	p.wg.Wait()
+}
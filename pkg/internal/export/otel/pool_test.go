@@ -0,0 +1,45 @@
+package otel
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/grafana/beyla/pkg/internal/request"
+)
+
+// exportBacklog exports the given number of spans through a pool with the provided number
+// of workers, where each export takes a fixed time. It returns the exported paths and the
+// time it took to export all of them
+func exportBacklog(workers, spans int) (map[string]struct{}, time.Duration) {
+	mt := sync.Mutex{}
+	exported := map[string]struct{}{}
+	pool := newExportPool(workers, func(span *request.Span) {
+		time.Sleep(10 * time.Millisecond)
+		mt.Lock()
+		defer mt.Unlock()
+		exported[span.Path] = struct{}{}
+	})
+	start := time.Now()
+	span := request.Span{}
+	for i := 0; i < spans; i++ {
+		span.Path = "/path" + string(rune('a'+i))
+		// the pool must keep its own copy of the span, as the submitted span is reused
+		pool.submit(&span)
+	}
+	pool.close()
+	return exported, time.Since(start)
+}
+
+func TestExportPool(t *testing.T) {
+	const spans = 20
+	serial, serialTime := exportBacklog(1, spans)
+	concurrent, concurrentTime := exportBacklog(5, spans)
+
+	// closing the pool waits for all the spans to be exported
+	assert.Len(t, serial, spans)
+	assert.Len(t, concurrent, spans)
+	assert.Less(t, concurrentTime, serialTime/2)
+}
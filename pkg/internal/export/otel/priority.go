@@ -0,0 +1,55 @@
+package otel
+
+import (
+	"strings"
+
+	"github.com/grafana/beyla/pkg/internal/request"
+)
+
+// name of the sampling priority, as reported in the internal metrics
+const samplerPriority = "priority"
+
+// Accepted decisions in the SamplingPriority values
+const (
+	priorityKeep = "keep"
+	priorityDrop = "drop"
+)
+
+// default mapping of the sampling priority values, following the Datadog convention:
+// USER_REJECT (-1) and AUTO_REJECT (0) drop the trace, AUTO_KEEP (1) and USER_KEEP (2) keep it.
+var defaultPriorityValues = map[string]string{
+	"-1": priorityDrop,
+	"0":  priorityDrop,
+	"1":  priorityKeep,
+	"2":  priorityKeep,
+}
+
+// SamplingPriority forces the sampling decision of the requests that carry a sampling priority
+// header (e.g. x-datadog-sampling-priority), overriding the configured sampler.
+type SamplingPriority struct {
+	// Header name, case-insensitive. If unset, the sampling priority is ignored.
+	Header string `yaml:"header" env:"BEYLA_OTLP_TRACES_SAMPLING_PRIORITY_HEADER"`
+	// Values maps the header values to the "keep" or "drop" decision. Unmapped values are ignored.
+	// Defaults to the Datadog priorities: -1 and 0 drop, 1 and 2 keep.
+	Values map[string]string `yaml:"values"`
+}
+
+// decision returns the sampling decision forced by the span headers, and whether it is forced
+func (sp *SamplingPriority) decision(span *request.Span) (keep, forced bool) {
+	if sp.Header == "" {
+		return false, false
+	}
+	values := sp.Values
+	if len(values) == 0 {
+		values = defaultPriorityValues
+	}
+	for _, value := range span.RequestHeaders[strings.ToLower(sp.Header)] {
+		switch strings.ToLower(values[strings.TrimSpace(value)]) {
+		case priorityKeep:
+			return true, true
+		case priorityDrop:
+			return false, true
+		}
+	}
+	return false, false
+}
@@ -0,0 +1,101 @@
+package otel
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	trace2 "go.opentelemetry.io/otel/trace"
+	"gopkg.in/yaml.v3"
+
+	"github.com/grafana/beyla/pkg/internal/request"
+)
+
+func TestSamplingPriority(t *testing.T) {
+	withPriority := func(traceID byte, priority string) request.Span {
+		span := request.Span{Type: request.EventTypeHTTP, TraceID: trace2.TraceID{traceID}, SpanID: trace2.SpanID{traceID}}
+		if priority != "" {
+			span.RequestHeaders = map[string][]string{"x-datadog-sampling-priority": {priority}}
+		}
+		return span
+	}
+
+	t.Run("priority forces keep over the sampler", func(t *testing.T) {
+		tr := newTracesOTELReceiver(context.Background(), TracesConfig{
+			Sampler:          Sampler{Name: "always_off"},
+			SamplingPriority: SamplingPriority{Header: "X-Datadog-Sampling-Priority"},
+		}, nil, nil)
+		for _, p := range []string{"1", "2"} {
+			span := withPriority(1, p)
+			assert.True(t, tr.sample(&span), p)
+		}
+		span := withPriority(1, "")
+		assert.False(t, tr.sample(&span))
+	})
+
+	t.Run("forced keep has probability 1", func(t *testing.T) {
+		tr := newTracesOTELReceiver(context.Background(), TracesConfig{
+			Sampler:          Sampler{Name: "traceidratio", Arg: "0.1"},
+			SamplingPriority: SamplingPriority{Header: "x-datadog-sampling-priority"},
+		}, nil, nil)
+		// the sampler would keep the span with a probability of 0.1, but the priority forces it
+		span := withPriority(1, "2")
+		p, ok := sampledProbability(t, tr, &span)
+		require.True(t, ok)
+		assert.Equal(t, 1.0, p)
+		// unmapped values are decided by the sampler
+		span = withPriority(1, "foo")
+		p, ok = sampledProbability(t, tr, &span)
+		require.True(t, ok)
+		assert.Equal(t, 0.1, p)
+	})
+
+	t.Run("priority forces drop over the sampler", func(t *testing.T) {
+		tr := newTracesOTELReceiver(context.Background(), TracesConfig{
+			Sampler:          Sampler{Name: "always_on"},
+			SamplingPriority: SamplingPriority{Header: "x-datadog-sampling-priority"},
+		}, nil, nil)
+		for _, p := range []string{"0", "-1"} {
+			span := withPriority(1, p)
+			assert.False(t, tr.sample(&span), p)
+		}
+		// unmapped values are decided by the sampler
+		span := withPriority(1, "foo")
+		assert.True(t, tr.sample(&span))
+	})
+
+	t.Run("forced decision is shared by the rest of the trace", func(t *testing.T) {
+		tr := newTracesOTELReceiver(context.Background(), TracesConfig{
+			Sampler:                   Sampler{Name: "always_on"},
+			SamplingDecisionsCacheLen: 10,
+			SamplingPriority:          SamplingPriority{Header: "x-datadog-sampling-priority"},
+		}, nil, nil)
+		root := withPriority(1, "0")
+		assert.False(t, tr.sample(&root))
+		child := request.Span{Type: request.EventTypeSQLClient, TraceID: root.TraceID, ParentSpanID: root.SpanID}
+		assert.False(t, tr.sample(&child))
+	})
+
+	t.Run("custom values mapping", func(t *testing.T) {
+		cfg := TracesConfig{Sampler: Sampler{Name: "always_off"}}
+		assert.NoError(t, yaml.Unmarshal([]byte(`
+sampling_priority:
+  header: x-priority
+  values:
+    high: keep
+    low: drop
+`), &cfg))
+		tr := newTracesOTELReceiver(context.Background(), cfg, nil, nil)
+		span := request.Span{Type: request.EventTypeHTTP, RequestHeaders: map[string][]string{"x-priority": {"high"}}}
+		assert.True(t, tr.sample(&span))
+		span.RequestHeaders["x-priority"] = []string{"1"}
+		assert.False(t, tr.sample(&span))
+	})
+
+	t.Run("disabled by default", func(t *testing.T) {
+		tr := newTracesOTELReceiver(context.Background(), TracesConfig{Sampler: Sampler{Name: "always_off"}}, nil, nil)
+		span := withPriority(1, "2")
+		assert.False(t, tr.sample(&span))
+	})
+}
@@ -0,0 +1,62 @@
+package otel
+
+import (
+	"path/filepath"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.19.0"
+
+	"github.com/grafana/beyla/pkg/internal/svc"
+)
+
+const redactedValue = "REDACTED"
+
+var defaultRedactCommandLineFlags = []string{"password", "passwd", "secret", "token", "key", "credential"}
+
+// processAttrs returns the resource attributes that describe the instrumented process
+func processAttrs(service *svc.ID, redactFlags []string) []attribute.KeyValue {
+	var attrs []attribute.KeyValue
+	if service.ExePath != "" {
+		attrs = append(attrs, semconv.ProcessExecutableName(filepath.Base(service.ExePath)))
+	}
+	if service.CmdLine != "" {
+		attrs = append(attrs, semconv.ProcessCommandLine(redactCommandLine(service.CmdLine, redactFlags)))
+	}
+	return attrs
+}
+
+// redactCommandLine replaces the values of the command line flags whose name contains
+// any of the provided words, either in the --flag=value or the --flag value forms.
+func redactCommandLine(cmdLine string, redactFlags []string) string {
+	if len(redactFlags) == 0 {
+		redactFlags = defaultRedactCommandLineFlags
+	}
+	args := strings.Fields(cmdLine)
+	for i := 0; i < len(args); i++ {
+		if !strings.HasPrefix(args[i], "-") {
+			continue
+		}
+		name, _, hasValue := strings.Cut(strings.TrimLeft(args[i], "-"), "=")
+		if !isSensitiveFlag(name, redactFlags) {
+			continue
+		}
+		if hasValue {
+			args[i] = args[i][:strings.IndexByte(args[i], '=')+1] + redactedValue
+		} else if i+1 < len(args) && !strings.HasPrefix(args[i+1], "-") {
+			i++
+			args[i] = redactedValue
+		}
+	}
+	return strings.Join(args, " ")
+}
+
+func isSensitiveFlag(name string, redactFlags []string) bool {
+	name = strings.ToLower(name)
+	for _, word := range redactFlags {
+		if strings.Contains(name, strings.ToLower(word)) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,54 @@
+package otel
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	attr "github.com/grafana/beyla/pkg/internal/export/attributes/names"
+	"github.com/grafana/beyla/pkg/internal/request"
+	"github.com/grafana/beyla/pkg/internal/svc"
+)
+
+func TestRedactCommandLine(t *testing.T) {
+	for _, tc := range []struct {
+		in, out string
+		flags   []string
+	}{
+		{in: "/usr/bin/app --port 8080 -v", out: "/usr/bin/app --port 8080 -v"},
+		{in: "/usr/bin/app --db-password=s3cr3t --port=8080", out: "/usr/bin/app --db-password=REDACTED --port=8080"},
+		{in: "/usr/bin/app -apiKey abcd -v", out: "/usr/bin/app -apiKey REDACTED -v"},
+		{in: "/usr/bin/app --token", out: "/usr/bin/app --token"},
+		{in: "/usr/bin/app --token -v", out: "/usr/bin/app --token -v"},
+		{in: "/usr/bin/app --user=admin --token=abc", out: "/usr/bin/app --user=REDACTED --token=abc", flags: []string{"USER"}},
+	} {
+		t.Run(tc.in, func(t *testing.T) {
+			assert.Equal(t, tc.out, redactCommandLine(tc.in, tc.flags))
+		})
+	}
+}
+
+func TestGenerateTraces_ProcessAttributes(t *testing.T) {
+	span := &request.Span{Type: request.EventTypeHTTP, Method: "GET", ServiceID: svc.ID{
+		Name:    "app",
+		ExePath: "/usr/local/bin/app",
+		CmdLine: "/usr/local/bin/app --listen :8080 --secret-file=/etc/app/secret",
+	}}
+
+	traces := GenerateTraces(&TracesConfig{EmitProcessAttributes: true}, span, map[attr.Name]struct{}{})
+	resAttrs := traces.ResourceSpans().At(0).Resource().Attributes()
+	ensureTraceStrAttr(t, resAttrs, "process.executable.name", "app")
+	ensureTraceStrAttr(t, resAttrs, "process.command_line", "/usr/local/bin/app --listen :8080 --secret-file=REDACTED")
+
+	traces = GenerateTraces(&TracesConfig{}, span, map[attr.Name]struct{}{})
+	resAttrs = traces.ResourceSpans().At(0).Resource().Attributes()
+	ensureTraceAttrNotExists(t, resAttrs, "process.executable.name")
+	ensureTraceAttrNotExists(t, resAttrs, "process.command_line")
+
+	// unknown process information
+	span.ServiceID = svc.ID{Name: "app"}
+	traces = GenerateTraces(&TracesConfig{EmitProcessAttributes: true}, span, map[attr.Name]struct{}{})
+	resAttrs = traces.ResourceSpans().At(0).Resource().Attributes()
+	ensureTraceAttrNotExists(t, resAttrs, "process.executable.name")
+	ensureTraceAttrNotExists(t, resAttrs, "process.command_line")
+}
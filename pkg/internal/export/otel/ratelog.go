@@ -0,0 +1,77 @@
+package otel
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/grafana/beyla/pkg/internal/imetrics"
+)
+
+// exportErrorLogInterval is the minimum time between two log lines for the same export error
+const exportErrorLogInterval = 10 * time.Second
+
+// maxDistinctErrors bounds the number of distinct error messages that are tracked
+const maxDistinctErrors = 100
+
+// rateLimitedLogger logs the first occurrence of an error, and then logs it at most once
+// every interval, along with the number of occurrences that were suppressed in between.
+// It avoids flooding the logs with identical messages (e.g. during a collector outage).
+// Each omitted log line is accounted in the internal metrics.
+type rateLimitedLogger struct {
+	log      *slog.Logger
+	interval time.Duration
+	clock    func() time.Time
+	metrics  imetrics.Reporter
+
+	mt     sync.Mutex
+	errors map[string]*loggedError
+}
+
+type loggedError struct {
+	lastLog    time.Time
+	suppressed int
+}
+
+func newRateLimitedLogger(log *slog.Logger, interval time.Duration, metrics imetrics.Reporter) *rateLimitedLogger {
+	return &rateLimitedLogger{
+		log:      log,
+		interval: interval,
+		clock:    time.Now,
+		metrics:  metrics,
+		errors:   map[string]*loggedError{},
+	}
+}
+
+func (rl *rateLimitedLogger) Error(msg string, err error) {
+	rl.logAt(slog.LevelError, msg, err)
+}
+
+func (rl *rateLimitedLogger) Warn(msg string, err error) {
+	rl.logAt(slog.LevelWarn, msg, err)
+}
+
+func (rl *rateLimitedLogger) logAt(level slog.Level, msg string, err error) {
+	rl.mt.Lock()
+	defer rl.mt.Unlock()
+	key := msg + ": " + err.Error()
+	now := rl.clock()
+	le, ok := rl.errors[key]
+	if !ok {
+		if len(rl.errors) >= maxDistinctErrors {
+			rl.errors = map[string]*loggedError{}
+		}
+		rl.errors[key] = &loggedError{lastLog: now}
+		rl.log.Log(context.Background(), level, msg, "error", err)
+		return
+	}
+	if now.Sub(le.lastLog) < rl.interval {
+		le.suppressed++
+		rl.metrics.OTELTraceSuppressedLog(level.String())
+		return
+	}
+	rl.log.Log(context.Background(), level, msg, "error", err, "suppressed", le.suppressed)
+	le.lastLog = now
+	le.suppressed = 0
+}
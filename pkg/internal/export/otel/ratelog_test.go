@@ -0,0 +1,53 @@
+package otel
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/grafana/beyla/pkg/internal/imetrics"
+)
+
+type suppressedLogsCounter struct {
+	imetrics.NoopReporter
+	suppressed map[string]int
+}
+
+func (s *suppressedLogsCounter) OTELTraceSuppressedLog(level string) {
+	s.suppressed[level]++
+}
+
+func TestRateLimitedLogger(t *testing.T) {
+	out := &bytes.Buffer{}
+	now := time.Now()
+	metrics := &suppressedLogsCounter{suppressed: map[string]int{}}
+	rl := newRateLimitedLogger(slog.New(slog.NewTextHandler(out, nil)), 10*time.Second, metrics)
+	rl.clock = func() time.Time { return now }
+
+	outage := errors.New("connection refused")
+	for i := 0; i < 1000; i++ {
+		rl.Error("error sending trace to consumer", outage)
+	}
+	// only the first occurrence is logged
+	assert.Equal(t, 1, strings.Count(out.String(), "\n"))
+	assert.Equal(t, map[string]int{"ERROR": 999}, metrics.suppressed)
+
+	// a different error is logged as well
+	rl.Error("error sending trace to consumer", errors.New("context deadline exceeded"))
+	assert.Equal(t, 2, strings.Count(out.String(), "\n"))
+
+	// after the interval, the error is logged again with the count of suppressed lines
+	now = now.Add(10 * time.Second)
+	out.Reset()
+	for i := 0; i < 1000; i++ {
+		rl.Error("error sending trace to consumer", outage)
+	}
+	assert.Equal(t, 1, strings.Count(out.String(), "\n"))
+	assert.Contains(t, out.String(), "suppressed=999")
+	assert.Equal(t, map[string]int{"ERROR": 999 + 999}, metrics.suppressed)
+}
@@ -0,0 +1,151 @@
+package otel
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.19.0"
+)
+
+const (
+	defaultRemoteSamplingInterval = time.Minute
+	// the only remote strategy type that is supported, at the moment
+	remoteStrategyProbabilistic = "probabilistic"
+)
+
+// remoteStrategiesDoc follows the format of the Jaeger sampling strategies file:
+// https://www.jaegertracing.io/docs/latest/sampling/#file-based-sampling-configuration
+type remoteStrategiesDoc struct {
+	DefaultStrategy   *remoteStrategy  `json:"default_strategy"`
+	ServiceStrategies []remoteStrategy `json:"service_strategies"`
+}
+
+type remoteStrategy struct {
+	Service string  `json:"service"`
+	Type    string  `json:"type"`
+	Param   float64 `json:"param"`
+}
+
+// remoteStrategies are the samplers of the last successfully fetched strategies document
+type remoteStrategies struct {
+	services map[string]trace.Sampler
+	// def is nil if the document does not define a default strategy
+	def trace.Sampler
+}
+
+// remoteSampler periodically fetches the per-service sampling strategies from a remote
+// endpoint, and samples each span according to the strategy of its service. Until the
+// strategies are fetched for the first time, or if neither the service nor the default
+// strategy are defined, it uses the locally configured sampler.
+type remoteSampler struct {
+	url        string
+	interval   time.Duration
+	client     *http.Client
+	local      trace.Sampler
+	strategies atomic.Pointer[remoteStrategies]
+}
+
+func newRemoteSampler(url string, interval time.Duration, local trace.Sampler) *remoteSampler {
+	if interval <= 0 {
+		interval = defaultRemoteSamplingInterval
+	}
+	return &remoteSampler{
+		url:      url,
+		interval: interval,
+		client:   &http.Client{Timeout: interval},
+		local:    local,
+	}
+}
+
+func (rs *remoteSampler) ShouldSample(p trace.SamplingParameters) trace.SamplingResult {
+	return rs.samplerFor(p).ShouldSample(p)
+}
+
+func (rs *remoteSampler) Description() string {
+	return "RemoteSampler{" + rs.url + "}"
+}
+
+func (rs *remoteSampler) samplerFor(p trace.SamplingParameters) trace.Sampler {
+	strategies := rs.strategies.Load()
+	if strategies == nil {
+		return rs.local
+	}
+	for _, a := range p.Attributes {
+		if a.Key == semconv.ServiceNameKey {
+			if sampler, ok := strategies.services[a.Value.AsString()]; ok {
+				return sampler
+			}
+			break
+		}
+	}
+	if strategies.def != nil {
+		return strategies.def
+	}
+	return rs.local
+}
+
+// poll updates the sampling strategies every interval, until the context is cancelled.
+// If an update fails, the last fetched strategies are kept.
+func (rs *remoteSampler) poll(ctx context.Context) {
+	ticker := time.NewTicker(rs.interval)
+	defer ticker.Stop()
+	for {
+		if err := rs.update(ctx); err != nil {
+			tlog().Warn("can't update the remote sampling strategies. Keeping the last known ones",
+				"url", rs.url, "error", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (rs *remoteSampler) update(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rs.url, nil)
+	if err != nil {
+		return fmt.Errorf("creating request: %w", err)
+	}
+	resp, err := rs.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("fetching strategies: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected response status: %s", resp.Status)
+	}
+	doc := remoteStrategiesDoc{}
+	if err := json.NewDecoder(resp.Body).Decode(&doc); err != nil {
+		return fmt.Errorf("decoding strategies: %w", err)
+	}
+	strategies := &remoteStrategies{services: map[string]trace.Sampler{}}
+	if doc.DefaultStrategy != nil {
+		if strategies.def, err = doc.DefaultStrategy.sampler(); err != nil {
+			return fmt.Errorf("default strategy: %w", err)
+		}
+	}
+	for i := range doc.ServiceStrategies {
+		st := &doc.ServiceStrategies[i]
+		if strategies.services[st.Service], err = st.sampler(); err != nil {
+			return fmt.Errorf("strategy of service %q: %w", st.Service, err)
+		}
+	}
+	rs.strategies.Store(strategies)
+	return nil
+}
+
+func (st *remoteStrategy) sampler() (trace.Sampler, error) {
+	if st.Type != remoteStrategyProbabilistic {
+		return nil, fmt.Errorf("unsupported strategy type %q", st.Type)
+	}
+	if st.Param < 0 || st.Param > 1 {
+		return nil, fmt.Errorf("sampling probability %v out of the [0, 1] range", st.Param)
+	}
+	return trace.ParentBased(newRatioSampler(st.Param)), nil
+}
@@ -0,0 +1,129 @@
+package otel
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/sdk/trace"
+	trace2 "go.opentelemetry.io/otel/trace"
+
+	"github.com/grafana/beyla/pkg/internal/request"
+	"github.com/grafana/beyla/pkg/internal/svc"
+)
+
+func TestRemoteSampler(t *testing.T) {
+	var response atomic.Value
+	response.Store(`{
+		"default_strategy": {"type": "probabilistic", "param": 1},
+		"service_strategies": [{"service": "checkout", "type": "probabilistic", "param": 0}]
+	}`)
+	var failing atomic.Bool
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, _ *http.Request) {
+		if failing.Load() {
+			rw.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		_, _ = rw.Write([]byte(response.Load().(string)))
+	}))
+	defer server.Close()
+
+	rs := newRemoteSampler(server.URL, time.Minute, trace.NeverSample())
+	// the local sampler is used until the strategies are fetched
+	assert.False(t, shouldSample(rs, serviceSpan("checkout"), nil))
+	assert.False(t, shouldSample(rs, serviceSpan("cart"), nil))
+
+	require.NoError(t, rs.update(context.Background()))
+	assert.False(t, shouldSample(rs, serviceSpan("checkout"), nil))
+	assert.True(t, shouldSample(rs, serviceSpan("cart"), nil))
+
+	// failures keep the last known strategies
+	failing.Store(true)
+	require.Error(t, rs.update(context.Background()))
+	assert.False(t, shouldSample(rs, serviceSpan("checkout"), nil))
+	assert.True(t, shouldSample(rs, serviceSpan("cart"), nil))
+
+	failing.Store(false)
+	response.Store(`{"service_strategies": [{"service": "checkout", "type": "ratelimiting", "param": 10}]}`)
+	require.Error(t, rs.update(context.Background()))
+	assert.False(t, shouldSample(rs, serviceSpan("checkout"), nil))
+
+	// without default strategy, the services without strategy use the local sampler
+	response.Store(`{"service_strategies": [{"service": "checkout", "type": "probabilistic", "param": 1}]}`)
+	require.NoError(t, rs.update(context.Background()))
+	assert.True(t, shouldSample(rs, serviceSpan("checkout"), nil))
+	assert.False(t, shouldSample(rs, serviceSpan("cart"), nil))
+}
+
+func TestTracesReceiver_RemoteSampling(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, _ *http.Request) {
+		requests.Add(1)
+		_, _ = rw.Write([]byte(`{"default_strategy": {"type": "probabilistic", "param": 0}}`))
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	tr := newTracesOTELReceiver(ctx, TracesConfig{
+		Sampler:                Sampler{Name: "always_on"},
+		RemoteSamplingURL:      server.URL,
+		RemoteSamplingInterval: 10 * time.Millisecond,
+	}, nil, nil)
+	require.NotNil(t, tr.remoteSampler)
+	assert.True(t, tr.sample(serviceSpan("cart")))
+
+	go tr.remoteSampler.poll(ctx)
+	require.Eventually(t, func() bool {
+		return requests.Load() >= 2
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.False(t, tr.sample(serviceSpan("cart")))
+}
+
+func TestTracesReceiver_RemoteSampling_Probability(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, _ *http.Request) {
+		_, _ = rw.Write([]byte(`{
+			"default_strategy": {"type": "probabilistic", "param": 0.5},
+			"service_strategies": [{"service": "checkout", "type": "probabilistic", "param": 0.25}]
+		}`))
+	}))
+	defer server.Close()
+
+	tr := newTracesOTELReceiver(context.Background(), TracesConfig{
+		Sampler:           Sampler{Name: "traceidratio", Arg: "0.1"},
+		RemoteSamplingURL: server.URL,
+	}, nil, nil)
+	// the trace ID is below the bound of any ratio, so the spans are kept
+	keptSpan := func(service string) *request.Span {
+		span := serviceSpan(service)
+		span.TraceID = trace2.TraceID{1}
+		return span
+	}
+	// the local sampler decides until the strategies are fetched
+	p, ok := sampledProbability(t, tr, keptSpan("checkout"))
+	require.True(t, ok)
+	assert.Equal(t, 0.1, p)
+
+	require.NoError(t, tr.remoteSampler.update(context.Background()))
+	p, ok = sampledProbability(t, tr, keptSpan("checkout"))
+	require.True(t, ok)
+	assert.Equal(t, 0.25, p)
+	p, ok = sampledProbability(t, tr, keptSpan("cart"))
+	require.True(t, ok)
+	assert.Equal(t, 0.5, p)
+
+	// the strategies are parent-based, so the probability is unknown when the remote parent decided
+	child := keptSpan("cart")
+	child.ParentSpanID, child.Flags = trace2.SpanID{1}, 1
+	_, ok = sampledProbability(t, tr, child)
+	assert.False(t, ok)
+}
+
+func serviceSpan(service string) *request.Span {
+	return &request.Span{Type: request.EventTypeHTTP, ServiceID: svc.ID{Name: service}}
+}
@@ -0,0 +1,54 @@
+package otel
+
+import (
+	"log/slog"
+	"slices"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// renameAttributes renames the keys of the span attributes according to the AttributeRenames table.
+// If several attributes end up with the same key (e.g. two sources renamed to the same target, or
+// an attribute renamed to the name of another existing attribute), only the attribute with the
+// lowest original key, in lexicographical order, is kept, so the result doesn't depend on the
+// order of the attributes.
+func renameAttributes(attrs []attribute.KeyValue, renames map[string]string) []attribute.KeyValue {
+	if len(renames) == 0 {
+		return attrs
+	}
+	renamed := make([]attribute.KeyValue, 0, len(attrs))
+	// original keys of the renamed attributes, to resolve the collisions
+	sources := make([]attribute.Key, 0, len(attrs))
+	positions := make(map[attribute.Key]int, len(attrs))
+	for _, a := range attrs {
+		source := a.Key
+		if target, ok := renames[string(a.Key)]; ok {
+			a.Key = attribute.Key(target)
+		}
+		if i, collides := positions[a.Key]; collides {
+			if source < sources[i] {
+				renamed[i], sources[i] = a, source
+			}
+			continue
+		}
+		positions[a.Key] = len(renamed)
+		renamed = append(renamed, a)
+		sources = append(sources, source)
+	}
+	return renamed
+}
+
+// warnRenameCollisions logs the entries of the AttributeRenames table that share the same target
+func warnRenameCollisions(log *slog.Logger, renames map[string]string) {
+	byTarget := map[string][]string{}
+	for source, target := range renames {
+		byTarget[target] = append(byTarget[target], source)
+	}
+	for target, sources := range byTarget {
+		if len(sources) > 1 {
+			slices.Sort(sources)
+			log.Warn("multiple attributes are renamed to the same name. Only the one with the lowest original name is kept",
+				"target", target, "sources", sources, "kept", sources[0])
+		}
+	}
+}
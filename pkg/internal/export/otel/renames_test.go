@@ -0,0 +1,46 @@
+package otel
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/attribute"
+
+	attr "github.com/grafana/beyla/pkg/internal/export/attributes/names"
+	"github.com/grafana/beyla/pkg/internal/request"
+)
+
+func TestGenerateTraces_AttributeRenames(t *testing.T) {
+	span := request.Span{Type: request.EventTypeHTTP, Method: "GET", Path: "/users", Status: 200}
+	traces := GenerateTraces(&TracesConfig{AttributeRenames: map[string]string{"url.path": "http.target"}},
+		&span, map[attr.Name]struct{}{})
+	attrs := traces.ResourceSpans().At(0).ScopeSpans().At(0).Spans().At(0).Attributes()
+	ensureTraceStrAttr(t, attrs, "http.target", "/users")
+	ensureTraceAttrNotExists(t, attrs, attr.HTTPUrlPath.OTEL())
+	ensureTraceStrAttr(t, attrs, attr.HTTPRequestMethod.OTEL(), "GET")
+}
+
+func TestRenameAttributes_Collisions(t *testing.T) {
+	renames := map[string]string{"b": "target", "a": "target", "c": "d"}
+	expected := []attribute.KeyValue{
+		attribute.String("target", "from a"),
+		attribute.String("d", "from c"),
+	}
+	// the result does not depend on the order of the attributes
+	assert.Equal(t, expected, renameAttributes([]attribute.KeyValue{
+		attribute.String("b", "from b"),
+		attribute.String("a", "from a"),
+		attribute.String("c", "from c"),
+		attribute.String("d", "from d"),
+	}, renames))
+	assert.Equal(t, expected, renameAttributes([]attribute.KeyValue{
+		attribute.String("a", "from a"),
+		attribute.String("d", "from d"),
+		attribute.String("b", "from b"),
+		attribute.String("c", "from c"),
+	}, renames))
+
+	// without renames, the attributes are kept as they are
+	attrs := []attribute.KeyValue{attribute.String("a", "1")}
+	assert.Equal(t, attrs, renameAttributes(attrs, nil))
+}
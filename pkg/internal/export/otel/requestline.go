@@ -0,0 +1,57 @@
+package otel
+
+import (
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+
+	attr "github.com/grafana/beyla/pkg/internal/export/attributes/names"
+	"github.com/grafana/beyla/pkg/internal/request"
+)
+
+// defaultRequestLineMaxLength is the maximum length of the beyla.http.request_line attribute
+// when the RequestLineMaxLength is not set
+const defaultRequestLineMaxLength = 1024
+
+// appendRequestLine adds the captured request line, with the sensitive query parameters redacted
+// and truncated to the configured maximum length
+func appendRequestLine(attrs []attribute.KeyValue, cfg *TracesConfig, span *request.Span, optionalAttrs map[attr.Name]struct{}) []attribute.KeyValue {
+	if _, ok := optionalAttrs[attr.BeylaHTTPRequestLine]; !ok || span.RequestLine == "" {
+		return attrs
+	}
+	maxLen := cfg.RequestLineMaxLength
+	if maxLen <= 0 {
+		maxLen = defaultRequestLineMaxLength
+	}
+	line := redactRequestLine(span.RequestLine, cfg.RedactQueryParams)
+	return append(attrs, attr.BeylaHTTPRequestLine.OTEL().String(truncateName(line, maxLen)))
+}
+
+// redactRequestLine replaces the values of the query parameters whose name contains any of the
+// provided words (e.g. GET /login?user=bill&password=REDACTED HTTP/1.1)
+func redactRequestLine(line string, redactParams []string) string {
+	if len(redactParams) == 0 {
+		redactParams = defaultRedactCommandLineFlags
+	}
+	method, rest, ok := strings.Cut(line, " ")
+	if !ok {
+		return line
+	}
+	target, version, _ := strings.Cut(rest, " ")
+	path, query, ok := strings.Cut(target, "?")
+	if !ok {
+		return line
+	}
+	params := strings.Split(query, "&")
+	for i, param := range params {
+		name, _, hasValue := strings.Cut(param, "=")
+		if hasValue && isSensitiveFlag(name, redactParams) {
+			params[i] = name + "=" + redactedValue
+		}
+	}
+	redacted := method + " " + path + "?" + strings.Join(params, "&")
+	if version != "" {
+		redacted += " " + version
+	}
+	return redacted
+}
@@ -0,0 +1,57 @@
+package otel
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	attr "github.com/grafana/beyla/pkg/internal/export/attributes/names"
+	"github.com/grafana/beyla/pkg/internal/request"
+)
+
+func TestTraceAttributes_RequestLine(t *testing.T) {
+	selected := map[attr.Name]struct{}{attr.BeylaHTTPRequestLine: {}}
+	requestLine := func(cfg *TracesConfig, span *request.Span, selection map[attr.Name]struct{}) (string, bool) {
+		traces := GenerateTraces(cfg, span, selection)
+		v, ok := traces.ResourceSpans().At(0).ScopeSpans().At(0).Spans().At(0).Attributes().
+			Get(string(attr.BeylaHTTPRequestLine.OTEL()))
+		return v.Str(), ok
+	}
+
+	t.Run("present", func(t *testing.T) {
+		for _, spanType := range []request.EventType{request.EventTypeHTTP, request.EventTypeHTTPClient} {
+			span := &request.Span{Type: spanType, Method: "GET", Path: "/foo", RequestLine: "GET /foo?x=1 HTTP/1.1"}
+			line, ok := requestLine(&TracesConfig{}, span, selected)
+			assert.True(t, ok)
+			assert.Equal(t, "GET /foo?x=1 HTTP/1.1", line)
+		}
+	})
+	t.Run("not selected or not captured", func(t *testing.T) {
+		span := &request.Span{Type: request.EventTypeHTTP, Method: "GET", Path: "/foo", RequestLine: "GET /foo HTTP/1.1"}
+		_, ok := requestLine(&TracesConfig{}, span, map[attr.Name]struct{}{})
+		assert.False(t, ok)
+
+		span.RequestLine = ""
+		_, ok = requestLine(&TracesConfig{}, span, selected)
+		assert.False(t, ok)
+	})
+	t.Run("redacted", func(t *testing.T) {
+		span := &request.Span{Type: request.EventTypeHTTP, Method: "GET", Path: "/login",
+			RequestLine: "GET /login?user=bill&Password=1234&api_key=abc&flag HTTP/1.1"}
+		line, _ := requestLine(&TracesConfig{}, span, selected)
+		assert.Equal(t, "GET /login?user=bill&Password=REDACTED&api_key=REDACTED&flag HTTP/1.1", line)
+
+		line, _ = requestLine(&TracesConfig{RedactQueryParams: []string{"user"}}, span, selected)
+		assert.Equal(t, "GET /login?user=REDACTED&Password=1234&api_key=abc&flag HTTP/1.1", line)
+	})
+	t.Run("truncated", func(t *testing.T) {
+		span := &request.Span{Type: request.EventTypeHTTP, Method: "GET", Path: "/foo",
+			RequestLine: "GET /foo?x=" + strings.Repeat("a", 2000) + " HTTP/1.1"}
+		line, _ := requestLine(&TracesConfig{RequestLineMaxLength: 20}, span, selected)
+		assert.Equal(t, "GET /foo?x=aaaaaa...", line)
+
+		line, _ = requestLine(&TracesConfig{}, span, selected)
+		assert.Len(t, line, defaultRequestLineMaxLength)
+	})
+}
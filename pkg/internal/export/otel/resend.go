@@ -0,0 +1,55 @@
+package otel
+
+import (
+	"cmp"
+	"slices"
+
+	trace2 "go.opentelemetry.io/otel/trace"
+
+	"github.com/grafana/beyla/pkg/internal/request"
+)
+
+// attemptKey identifies the attempts of the same HTTP client request, which are invoked by the
+// same parent span against the same URL
+type attemptKey struct {
+	process  processKey
+	parent   trace2.SpanID
+	method   string
+	host     string
+	hostPort int
+	path     string
+}
+
+// retriable returns whether the response of the HTTP client request is usually retried: server
+// errors, timeouts, rate limits and the failed requests without response status
+func retriable(span *request.Span) bool {
+	return span.Status == 0 || span.Status == 408 || span.Status == 429 || span.Status >= 500
+}
+
+// countResends sets the ResendCount of the HTTP client spans of the batch that repeat a previous
+// attempt of the same parent span and URL, which failed with a retriable response and finished before
+// the new attempt started. The attempts reported in different batches are not counted.
+func countResends(spans []request.Span) {
+	attempts := map[attemptKey][]int{}
+	for i := range spans {
+		span := &spans[i]
+		if span.Type != request.EventTypeHTTPClient || !span.ParentSpanID.IsValid() {
+			continue
+		}
+		key := attemptKey{process: spanProcess(span), parent: span.ParentSpanID,
+			method: span.Method, host: span.Host, hostPort: span.HostPort, path: span.Path}
+		attempts[key] = append(attempts[key], i)
+	}
+	for _, indices := range attempts {
+		if len(indices) < 2 {
+			continue
+		}
+		slices.SortFunc(indices, func(a, b int) int { return cmp.Compare(spans[a].Start, spans[b].Start) })
+		for n := 1; n < len(indices); n++ {
+			prev, current := &spans[indices[n-1]], &spans[indices[n]]
+			if retriable(prev) && prev.End <= current.Start {
+				current.ResendCount = prev.ResendCount + 1
+			}
+		}
+	}
+}
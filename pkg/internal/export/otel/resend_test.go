@@ -0,0 +1,59 @@
+package otel
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	trace2 "go.opentelemetry.io/otel/trace"
+
+	"github.com/grafana/beyla/pkg/internal/export/attributes"
+	"github.com/grafana/beyla/pkg/internal/request"
+)
+
+func TestTracesReceiver_ResendCount(t *testing.T) {
+	pid := request.PidInfo{HostPID: 1234, Namespace: 1}
+	parent := trace2.SpanID{1, 2, 3}
+	attempt := func(name string, status int, start, end int64) request.Span {
+		return request.Span{Type: request.EventTypeHTTPClient, Method: "GET", Path: "/api", Host: "10.0.0.2",
+			HostPort: 8080, Pid: pid, ParentSpanID: parent, Status: status, Route: name,
+			RequestStart: start, Start: start, End: end}
+	}
+	otherURL := attempt("other-url", 200, 400, 410)
+	otherURL.Path = "/other"
+	otherParent := attempt("other-parent", 200, 400, 410)
+	otherParent.ParentSpanID = trace2.SpanID{4, 5, 6}
+	spans := []request.Span{
+		// unordered, as they might be reported by different probes
+		attempt("second", 429, 200, 210),
+		attempt("first", 503, 100, 110),
+		attempt("third", 200, 300, 310),
+		// the successful requests are not retried
+		attempt("repeated", 200, 500, 510),
+		otherURL,
+		otherParent,
+	}
+
+	original := slices.Clone(spans)
+	resends := func(cfg TracesConfig) map[string]int {
+		tr := newTracesOTELReceiver(context.Background(), cfg, nil, attributes.Selection{})
+		in := make(chan []request.Span, 1)
+		in <- spans
+		close(in)
+		resends := map[string]int{}
+		tr.consume(in, func(s *request.Span) { resends[s.Route] = s.ResendCount })
+		return resends
+	}
+
+	assert.Equal(t, map[string]int{
+		"first": 0, "second": 1, "third": 2, "repeated": 0, "other-url": 0, "other-parent": 0,
+	}, resends(TracesConfig{CountResends: true}))
+	// the resends are only counted in the copy of the batch that is exported as traces
+	assert.Equal(t, original, spans)
+
+	// the resends are not counted by default
+	assert.Equal(t, map[string]int{
+		"first": 0, "second": 0, "third": 0, "repeated": 0, "other-url": 0, "other-parent": 0,
+	}, resends(TracesConfig{}))
+}
@@ -0,0 +1,39 @@
+package otel
+
+import (
+	"time"
+
+	"go.opentelemetry.io/collector/exporter"
+)
+
+const (
+	defaultExporterInitRetryInterval = time.Second
+	maxExporterInitRetryInterval     = time.Minute
+)
+
+// createTracesExporter creates the traces exporter. If the ExporterInitMaxAttempts is configured,
+// failed attempts are retried with an exponential backoff, until the exporter is successfully created,
+// the maximum number of attempts is reached, or the receiver context is cancelled.
+func (tr *tracesOTELReceiver) createTracesExporter() (exporter.Traces, error) {
+	interval := tr.cfg.ExporterInitRetryInterval
+	if interval <= 0 {
+		interval = defaultExporterInitRetryInterval
+	}
+	for attempt := 1; ; attempt++ {
+		exp, err := tr.newExporter(tr.ctx, tr.cfg, tr.ctxInfo)
+		if err != nil && tr.selfTracer != nil {
+			tr.selfTracer.exporterInitFailure(err, attempt)
+		}
+		if err == nil || attempt >= tr.cfg.ExporterInitMaxAttempts {
+			return exp, err
+		}
+		tlog().Warn("can't create traces exporter. Retrying",
+			"attempt", attempt, "maxAttempts", tr.cfg.ExporterInitMaxAttempts, "retryIn", interval, "error", err)
+		select {
+		case <-tr.ctx.Done():
+			return nil, err
+		case <-time.After(interval):
+		}
+		interval = min(2*interval, maxExporterInitRetryInterval)
+	}
+}
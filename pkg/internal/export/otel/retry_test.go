@@ -0,0 +1,107 @@
+package otel
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/exporter"
+
+	"github.com/grafana/beyla/pkg/internal/pipe/global"
+	"github.com/grafana/beyla/pkg/internal/request"
+)
+
+// failingExporterFactory fails the given number of times before successfully returning the exporter
+func failingExporterFactory(failures int, exp exporter.Traces) (func(context.Context, TracesConfig, *global.ContextInfo) (exporter.Traces, error), *int) {
+	attempts := 0
+	return func(_ context.Context, _ TracesConfig, _ *global.ContextInfo) (exporter.Traces, error) {
+		attempts++
+		if attempts <= failures {
+			return nil, errors.New("can't resolve collector address")
+		}
+		return exp, nil
+	}, &attempts
+}
+
+func TestCreateTracesExporter_Retry(t *testing.T) {
+	tr := newTracesOTELReceiver(context.Background(), TracesConfig{
+		ExporterInitMaxAttempts:   5,
+		ExporterInitRetryInterval: time.Millisecond,
+	}, nil, nil)
+	expected := &countingExporter{}
+	var attempts *int
+	tr.newExporter, attempts = failingExporterFactory(2, expected)
+
+	exp, err := tr.createTracesExporter()
+	require.NoError(t, err)
+	assert.Same(t, expected, exp)
+	assert.Equal(t, 3, *attempts)
+}
+
+func TestCreateTracesExporter_MaxAttempts(t *testing.T) {
+	tr := newTracesOTELReceiver(context.Background(), TracesConfig{
+		ExporterInitMaxAttempts:   2,
+		ExporterInitRetryInterval: time.Millisecond,
+	}, nil, nil)
+	var attempts *int
+	tr.newExporter, attempts = failingExporterFactory(2, &countingExporter{})
+
+	_, err := tr.createTracesExporter()
+	require.Error(t, err)
+	assert.Equal(t, 2, *attempts)
+}
+
+func TestCreateTracesExporter_NoRetryByDefault(t *testing.T) {
+	tr := newTracesOTELReceiver(context.Background(), TracesConfig{}, nil, nil)
+	var attempts *int
+	tr.newExporter, attempts = failingExporterFactory(1, &countingExporter{})
+
+	_, err := tr.createTracesExporter()
+	require.Error(t, err)
+	assert.Equal(t, 1, *attempts)
+}
+
+func TestCreateTracesExporter_Cancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	tr := newTracesOTELReceiver(ctx, TracesConfig{
+		ExporterInitMaxAttempts:   5,
+		ExporterInitRetryInterval: time.Hour,
+	}, nil, nil)
+	var attempts *int
+	tr.newExporter, attempts = failingExporterFactory(2, &countingExporter{})
+
+	_, err := tr.createTracesExporter()
+	require.Error(t, err)
+	assert.Equal(t, 1, *attempts)
+}
+
+func TestProvideLoop_FailOnExporterError(t *testing.T) {
+	cfg := TracesConfig{CommonEndpoint: "http://localhost:4318", FailOnExporterError: true}
+	tr := newTracesOTELReceiver(context.Background(), cfg, nil, nil)
+	var attempts *int
+	tr.newExporter, attempts = failingExporterFactory(1, &countingExporter{})
+
+	_, err := tr.provideLoop()
+	require.Error(t, err)
+	assert.Equal(t, 1, *attempts)
+}
+
+func TestProvideLoop_LenientExporterError(t *testing.T) {
+	cfg := TracesConfig{CommonEndpoint: "http://localhost:4318"}
+	tr := newTracesOTELReceiver(context.Background(), cfg, nil, nil)
+	var attempts *int
+	tr.newExporter, attempts = failingExporterFactory(1, &countingExporter{})
+
+	loop, err := tr.provideLoop()
+	require.NoError(t, err)
+	// the exporter is created, and its error logged, once the loop runs
+	assert.Zero(t, *attempts)
+	in := make(chan []request.Span)
+	close(in)
+	loop(in)
+	assert.Equal(t, 1, *attempts)
+}
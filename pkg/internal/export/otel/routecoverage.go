@@ -0,0 +1,76 @@
+package otel
+
+import (
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.19.0"
+	trace2 "go.opentelemetry.io/otel/trace"
+)
+
+const (
+	samplerRouteCoverage         = "route_coverage"
+	defaultRouteCoverageInterval = time.Minute
+)
+
+// routeCoverageSampler keeps the first span of each HTTP route (http.route attribute) in each
+// time interval, so all the routes are represented in the traces even with low sampling ratios.
+// The rest of spans, or the spans without route, are sampled by the fallback sampler.
+type routeCoverageSampler struct {
+	fallback trace.Sampler
+	interval time.Duration
+	now      func() time.Time
+
+	mt sync.Mutex
+	// keptBuckets stores, for each route, the start of the last interval where a span was kept
+	keptBuckets map[string]time.Time
+}
+
+func newRouteCoverageSampler(fallback trace.Sampler, interval time.Duration) *routeCoverageSampler {
+	if interval <= 0 {
+		interval = defaultRouteCoverageInterval
+	}
+	return &routeCoverageSampler{
+		fallback:    fallback,
+		interval:    interval,
+		now:         time.Now,
+		keptBuckets: map[string]time.Time{},
+	}
+}
+
+func (rc *routeCoverageSampler) ShouldSample(p trace.SamplingParameters) trace.SamplingResult {
+	if route := routeAttribute(&p); route != "" && rc.firstInInterval(route) {
+		return withProbability(trace.SamplingResult{
+			Decision:   trace.RecordAndSample,
+			Tracestate: trace2.SpanContextFromContext(p.ParentContext).TraceState(),
+		}, 1)
+	}
+	return rc.fallback.ShouldSample(p)
+}
+
+// firstInInterval returns whether the route did not have any kept span in the current interval,
+// and marks it as kept
+func (rc *routeCoverageSampler) firstInInterval(route string) bool {
+	bucket := rc.now().Truncate(rc.interval)
+	rc.mt.Lock()
+	defer rc.mt.Unlock()
+	if last, ok := rc.keptBuckets[route]; ok && !last.Before(bucket) {
+		return false
+	}
+	rc.keptBuckets[route] = bucket
+	return true
+}
+
+func (rc *routeCoverageSampler) Description() string {
+	return "RouteCoverage{" + rc.interval.String() + "," + rc.fallback.Description() + "}"
+}
+
+func routeAttribute(p *trace.SamplingParameters) string {
+	for _, a := range p.Attributes {
+		if a.Key == semconv.HTTPRouteKey {
+			return a.Value.AsString()
+		}
+	}
+	return ""
+}
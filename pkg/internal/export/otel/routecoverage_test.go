@@ -0,0 +1,59 @@
+package otel
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/beyla/pkg/internal/request"
+)
+
+func TestRouteCoverageSampler(t *testing.T) {
+	s := Sampler{Name: samplerRouteCoverage, Arg: "0", Interval: time.Minute}
+	sampler, ok := s.Implementation().(*routeCoverageSampler)
+	require.True(t, ok)
+	now := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	sampler.now = func() time.Time { return now }
+
+	keeps := func(route string) int {
+		kept := 0
+		for i := 0; i < 100; i++ {
+			if shouldSample(sampler, &request.Span{Type: request.EventTypeHTTP, Method: "GET", Route: route}, nil) {
+				kept++
+			}
+		}
+		return kept
+	}
+
+	// each route keeps one span per interval, and the ratio sampler drops the rest
+	assert.Equal(t, 1, keeps("/users"))
+	assert.Equal(t, 1, keeps("/orders"))
+	now = now.Add(30 * time.Second)
+	assert.Equal(t, 0, keeps("/users"))
+	assert.Equal(t, 1, keeps("/products"))
+
+	// next interval
+	now = now.Add(30 * time.Second)
+	assert.Equal(t, 1, keeps("/users"))
+	assert.Equal(t, 1, keeps("/orders"))
+	assert.Equal(t, 1, keeps("/products"))
+
+	// spans without route only follow the ratio sampler
+	assert.Equal(t, 0, keeps(""))
+}
+
+func TestRouteCoverageSampler_Fallback(t *testing.T) {
+	s := Sampler{Name: samplerRouteCoverage, Arg: "1"}
+	sampler, ok := s.Implementation().(*routeCoverageSampler)
+	require.True(t, ok)
+	assert.Equal(t, defaultRouteCoverageInterval, sampler.interval)
+	for i := 0; i < 10; i++ {
+		assert.True(t, shouldSample(sampler, &request.Span{Type: request.EventTypeHTTP, Route: "/users"}, nil))
+	}
+	// the first span of the route in the interval is always kept
+	sampler.keptBuckets = map[string]time.Time{}
+	assert.Equal(t, samplingDecision{keep: true, probability: 1},
+		sampleSpan(sampler, &request.Span{Type: request.EventTypeHTTP, Route: "/users"}, nil))
+}
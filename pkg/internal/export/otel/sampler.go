@@ -1,28 +1,128 @@
 package otel
 
 import (
+	"context"
 	"log/slog"
+	"math"
 	"strconv"
+	"sync"
+	"time"
 
+	lru "github.com/hashicorp/golang-lru/v2"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
 	"go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.19.0"
+	trace2 "go.opentelemetry.io/otel/trace"
+
+	attr "github.com/grafana/beyla/pkg/internal/export/attributes/names"
+	"github.com/grafana/beyla/pkg/internal/request"
+)
+
+// names of the samplers, as reported in the internal metrics
+const (
+	samplerActive = "active"
+	samplerShadow = "shadow"
 )
 
 // Sampler standard configuration
 // https://opentelemetry.io/docs/concepts/sdk-configuration/general-sdk-configuration/#otel_traces_sampler
 // We don't support, yet, the jaeger and xray samplers.
+// Custom samplers can be registered with the RegisterSampler function, and selected by their name.
 type Sampler struct {
 	Name string `yaml:"name" env:"OTEL_TRACES_SAMPLER"`
 	Arg  string `yaml:"arg" env:"OTEL_TRACES_SAMPLER_ARG"`
+	// Args are passed to the factory of a registered custom sampler. If Arg is set, it
+	// is passed as the "arg" entry, unless Args already contain it.
+	Args map[string]any `yaml:"args"`
+	// Interval of the route_coverage sampler, which keeps at least one span of each route
+	// in each interval. Defaults to 1 minute.
+	Interval time.Duration `yaml:"interval" env:"BEYLA_OTLP_TRACES_SAMPLER_INTERVAL"`
+	// Score configures the weights of the score sampler, whose threshold is provided in the Arg.
+	Score ScoreSamplerConfig `yaml:"score"`
+	// AlwaysKeep maps attribute names to values (e.g. k8s.deployment.name: checkout-canary). The spans
+	// with any of these resource or span attribute values are always kept, regardless of the sampler.
+	AlwaysKeep map[string]string `yaml:"always_keep"`
+}
+
+// SamplerFactory creates a custom sampler from the arguments of the Sampler configuration.
+type SamplerFactory func(args map[string]any) trace.Sampler
+
+var (
+	samplersMt sync.RWMutex
+	samplers   = map[string]SamplerFactory{}
+)
+
+var builtinSamplers = map[string]struct{}{
+	"always_on": {}, "always_off": {}, "traceidratio": {},
+	"parentbased_always_on": {}, "parentbased_always_off": {}, "parentbased_traceidratio": {},
+	samplerRouteCoverage: {}, samplerSlowTail: {}, samplerScore: {},
+}
+
+// RegisterSampler makes a custom sampler available by the provided name, which can be then
+// selected in the Sampler configuration. It is expected to be invoked before Beyla starts
+// (e.g. from an init function), and panics if the name is empty, is already registered, or
+// clashes with the name of a standard sampler.
+// The sampler can report the probability with which it kept a span in the beyla.sampling.probability
+// attribute of its result. Otherwise, the probability of the span is unknown.
+func RegisterSampler(name string, factory SamplerFactory) {
+	if factory == nil {
+		panic("otel: RegisterSampler factory is nil")
+	}
+	if name == "" {
+		panic("otel: RegisterSampler name is empty")
+	}
+	if _, ok := builtinSamplers[name]; ok {
+		panic("otel: RegisterSampler called for standard sampler " + name)
+	}
+	samplersMt.Lock()
+	defer samplersMt.Unlock()
+	if _, dup := samplers[name]; dup {
+		panic("otel: RegisterSampler called twice for sampler " + name)
+	}
+	samplers[name] = factory
+}
+
+func registeredSampler(name string) (SamplerFactory, bool) {
+	samplersMt.RLock()
+	defer samplersMt.RUnlock()
+	factory, ok := samplers[name]
+	return factory, ok
+}
+
+func (s *Sampler) customArgs() map[string]any {
+	args := make(map[string]any, len(s.Args)+1)
+	for k, v := range s.Args {
+		args[k] = v
+	}
+	if _, ok := args["arg"]; !ok && s.Arg != "" {
+		args["arg"] = s.Arg
+	}
+	return args
+}
+
+// configured returns whether a sampler has been explicitly selected. Otherwise, the
+// spans are not sampled by Beyla.
+func (s *Sampler) configured() bool {
+	return s.Name != ""
 }
 
 func (s *Sampler) Implementation() trace.Sampler {
+	sampler := s.baseImplementation()
+	if len(s.AlwaysKeep) > 0 {
+		sampler = newAlwaysKeepSampler(s.AlwaysKeep, sampler)
+	}
+	return sampler
+}
+
+func (s *Sampler) baseImplementation() trace.Sampler {
 	var defaultSampler = func() trace.Sampler {
-		return trace.ParentBased(trace.AlwaysSample())
+		return trace.ParentBased(newRatioSampler(1))
 	}
 	log := slog.With("component", "otel.Sampler", "name", s.Name, "arg", s.Arg)
 	switch s.Name {
 	case "always_on":
-		return trace.AlwaysSample()
+		return newRatioSampler(1)
 	case "always_off":
 		return trace.NeverSample()
 	case "traceidratio":
@@ -31,7 +131,7 @@ func (s *Sampler) Implementation() trace.Sampler {
 			log.Warn("can't parse sampler argument. Defaulting to parentbased_always_on", "error", err)
 			return defaultSampler()
 		}
-		return trace.TraceIDRatioBased(ratio)
+		return newRatioSampler(ratio)
 	case "parentbased_always_off":
 		return trace.ParentBased(trace.NeverSample())
 	case "parentbased_traceidratio":
@@ -40,11 +140,210 @@ func (s *Sampler) Implementation() trace.Sampler {
 			log.Warn("can't parse sampler argument. Defaulting to parentbased_always_on", "error", err)
 			return defaultSampler()
 		}
-		return trace.ParentBased(trace.TraceIDRatioBased(ratio))
+		return trace.ParentBased(newRatioSampler(ratio))
 	case "parentbased_always_on", "":
 		return defaultSampler()
+	case samplerRouteCoverage:
+		ratio, err := strconv.ParseFloat(s.Arg, 64)
+		if err != nil {
+			log.Warn("can't parse sampler argument. Defaulting to parentbased_always_on", "error", err)
+			return defaultSampler()
+		}
+		return newRouteCoverageSampler(newRatioSampler(ratio), s.Interval)
+	case samplerSlowTail:
+		percentile, err := strconv.ParseFloat(s.Arg, 64)
+		if err != nil {
+			log.Warn("can't parse sampler argument. Defaulting to parentbased_always_on", "error", err)
+			return defaultSampler()
+		}
+		return newSlowTailSampler(percentile)
+	case samplerScore:
+		threshold, err := strconv.ParseFloat(s.Arg, 64)
+		if err != nil {
+			log.Warn("can't parse sampler argument. Defaulting to parentbased_always_on", "error", err)
+			return defaultSampler()
+		}
+		return newScoreSampler(threshold, &s.Score)
 	default:
+		if factory, ok := registeredSampler(s.Name); ok {
+			if sampler := factory(s.customArgs()); sampler != nil {
+				return sampler
+			}
+			log.Warn("custom sampler factory returned nil. Defaulting to parentbased_always_on")
+			return defaultSampler()
+		}
 		log.Warn("unsupported sampler name. Defaulting to parentbased_always_on")
 		return defaultSampler()
 	}
 }
+
+// samplingDecision is the decision of a sampler for a span, and the probability with which it kept
+// the span. The probability is zero if it is unknown (e.g. the parent span took the decision).
+type samplingDecision struct {
+	keep        bool
+	probability float64
+}
+
+// withProbability annotates the result of a sampler with the probability with which it kept the
+// span. Custom samplers can also report it in the beyla.sampling.probability attribute of their results.
+func withProbability(res trace.SamplingResult, probability float64) trace.SamplingResult {
+	if res.Decision != trace.Drop {
+		res.Attributes = append(res.Attributes, attr.BeylaSamplingProbability.OTEL().Float64(probability))
+	}
+	return res
+}
+
+// ratioSampler is a TraceIDRatioBased sampler that reports its ratio as the probability of the kept spans
+type ratioSampler struct {
+	trace.Sampler
+	ratio float64
+}
+
+func newRatioSampler(ratio float64) *ratioSampler {
+	// same boundaries as the TraceIDRatioBased sampler
+	return &ratioSampler{Sampler: trace.TraceIDRatioBased(ratio), ratio: math.Max(0, math.Min(1, ratio))}
+}
+
+func (rs *ratioSampler) ShouldSample(p trace.SamplingParameters) trace.SamplingResult {
+	return withProbability(rs.Sampler.ShouldSample(p), rs.ratio)
+}
+
+// shouldSample evaluates the sampler against the trace context of the provided span,
+// and returns whether it should be exported.
+func shouldSample(sampler trace.Sampler, span *request.Span, knownMethods request.HTTPMethods) bool {
+	return sampleSpan(sampler, span, knownMethods).keep
+}
+
+// sampleSpan evaluates the sampler against the trace context of the provided span, and
+// returns its decision. The sampler receives the same span name that is exported.
+func sampleSpan(sampler trace.Sampler, span *request.Span, knownMethods request.HTTPMethods) samplingDecision {
+	parentCtx := context.Background()
+	if span.ParentSpanID.IsValid() {
+		parentCtx = trace2.ContextWithRemoteSpanContext(parentCtx, trace2.NewSpanContext(trace2.SpanContextConfig{
+			TraceID:    span.TraceID,
+			SpanID:     span.ParentSpanID,
+			TraceFlags: trace2.TraceFlags(span.Flags),
+			Remote:     true,
+		}))
+	}
+	traceID := span.TraceID
+	if !traceID.IsValid() {
+		// the trace ID will be randomly generated at export time, so
+		// we use a random value to make a consistent sampling decision
+		traceID = randomTraceID()
+	}
+	params := trace.SamplingParameters{
+		ParentContext: parentCtx,
+		TraceID:       traceID,
+		Name:          TraceName(span, knownMethods),
+		Kind:          spanKind(span),
+		Attributes:    samplingAttributes(span),
+	}
+	res := sampler.ShouldSample(params)
+	decision := samplingDecision{keep: res.Decision != trace.Drop}
+	if decision.keep {
+		for _, a := range res.Attributes {
+			if a.Key == attr.BeylaSamplingProbability.OTEL() {
+				decision.probability = a.Value.AsFloat64()
+			}
+		}
+	}
+	return decision
+}
+
+// samplingAttributes returns the attributes of the span that are provided to the samplers
+func samplingAttributes(span *request.Span) []attribute.KeyValue {
+	var attrs []attribute.KeyValue
+	if span.Route != "" {
+		attrs = append(attrs, semconv.HTTPRoute(span.Route))
+	}
+	if span.ServiceID.Name != "" {
+		attrs = append(attrs, semconv.ServiceName(span.ServiceID.Name))
+	}
+	if span.ServiceID.Namespace != "" {
+		attrs = append(attrs, semconv.ServiceNamespace(span.ServiceID.Namespace))
+	}
+	for k, v := range span.ServiceID.Metadata {
+		if v != "" {
+			attrs = append(attrs, k.OTEL().String(v))
+		}
+	}
+	if span.End >= span.RequestStart {
+		attrs = append(attrs, attrSamplingDuration.Int64(span.End-span.RequestStart))
+	}
+	if SpanStatusCode(span) == codes.Error {
+		attrs = append(attrs, attrSamplingError.Bool(true))
+	}
+	return attrs
+}
+
+// decisionsCache remembers the sampling decisions of the most recent traces, so all the spans
+// of a trace share the decision that was taken for the first of them, even if the sampler
+// is not deterministic. It is safe for concurrent use.
+type decisionsCache struct {
+	decisions *lru.Cache[trace2.TraceID, samplingDecision]
+}
+
+func newDecisionsCache(size int) *decisionsCache {
+	decisions, _ := lru.New[trace2.TraceID, samplingDecision](size)
+	return &decisionsCache{decisions: decisions}
+}
+
+// sample returns the cached decision for the trace of the span, or evaluates the sampler
+// and caches its decision if the trace hasn't been seen before.
+func (dc *decisionsCache) sample(sampler trace.Sampler, span *request.Span, knownMethods request.HTTPMethods) samplingDecision {
+	if !span.TraceID.IsValid() {
+		// the span will get a random trace ID, so it can't share the decision with other spans
+		return sampleSpan(sampler, span, knownMethods)
+	}
+	if decision, ok := dc.decisions.Get(span.TraceID); ok {
+		return decision
+	}
+	decision := sampleSpan(sampler, span, knownMethods)
+	// if another span of the same trace took a decision meanwhile, we keep the first one
+	if previous, ok, _ := dc.decisions.PeekOrAdd(span.TraceID, decision); ok {
+		return previous
+	}
+	return decision
+}
+
+// force stores the decision for the trace of the span, overriding any previous decision,
+// so the rest of spans of the trace share it
+func (dc *decisionsCache) force(span *request.Span, decision samplingDecision) {
+	if span.TraceID.IsValid() {
+		dc.decisions.Add(span.TraceID, decision)
+	}
+}
+
+// parentsFirst returns the order in which the spans of a batch need to be sampled so the
+// spans whose parent was generated locally, in the same batch, are sampled after their parent.
+// This way, the decisions cache propagates the decision taken for the parent, and the children
+// of a dropped parent do not become orphans (e.g. Beyla usually reports the client spans
+// of a request before the server span that contains them).
+func parentsFirst(spans []request.Span) []int {
+	indices := make(map[trace2.SpanID]int, len(spans))
+	for i := range spans {
+		if spans[i].SpanID.IsValid() {
+			indices[spans[i].SpanID] = i
+		}
+	}
+	order := make([]int, 0, len(spans))
+	visited := make([]bool, len(spans))
+	var visit func(i int)
+	visit = func(i int) {
+		if visited[i] {
+			return
+		}
+		visited[i] = true
+		span := &spans[i]
+		if parent, ok := indices[span.ParentSpanID]; ok && span.ParentSpanID.IsValid() &&
+			spans[parent].TraceID == span.TraceID {
+			visit(parent)
+		}
+		order = append(order, i)
+	}
+	for i := range spans {
+		visit(i)
+	}
+	return order
+}
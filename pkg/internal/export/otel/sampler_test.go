@@ -1,10 +1,24 @@
 package otel
 
 import (
+	"context"
+	"encoding/binary"
+	"math"
+	"slices"
+	"sync"
+	"sync/atomic"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"go.opentelemetry.io/otel/sdk/trace"
+	trace2 "go.opentelemetry.io/otel/trace"
+
+	"github.com/grafana/beyla/pkg/internal/export/attributes"
+	attr "github.com/grafana/beyla/pkg/internal/export/attributes/names"
+	"github.com/grafana/beyla/pkg/internal/imetrics"
+	"github.com/grafana/beyla/pkg/internal/pipe/global"
+	"github.com/grafana/beyla/pkg/internal/request"
 )
 
 func TestSamplerImplementation(t *testing.T) {
@@ -15,38 +29,337 @@ func TestSamplerImplementation(t *testing.T) {
 
 	for _, tc := range []testCase{{
 		// default sampler
-		out: trace.ParentBased(trace.AlwaysSample()),
+		out: trace.ParentBased(newRatioSampler(1)),
 	}, {
 		in:  Sampler{Name: "invalid_sampler", Arg: "0.33"},
-		out: trace.ParentBased(trace.AlwaysSample()),
+		out: trace.ParentBased(newRatioSampler(1)),
 	}, {
 		in:  Sampler{Name: "always_on"},
-		out: trace.AlwaysSample(),
+		out: newRatioSampler(1),
 	}, {
 		in:  Sampler{Name: "always_off"},
 		out: trace.NeverSample(),
 	}, {
 		in:  Sampler{Name: "traceidratio", Arg: "0.33"},
-		out: trace.TraceIDRatioBased(0.33),
+		out: newRatioSampler(0.33),
 	}, {
 		// wrong argument: using default sampler
 		in:  Sampler{Name: "traceidratio", Arg: "fofofofoof"},
-		out: trace.ParentBased(trace.AlwaysSample()),
+		out: trace.ParentBased(newRatioSampler(1)),
 	}, {
 		in:  Sampler{Name: "parentbased_always_off", Arg: "0.33"},
 		out: trace.ParentBased(trace.NeverSample()),
 	}, {
 		in:  Sampler{Name: "parentbased_always_on", Arg: "0.33"},
-		out: trace.ParentBased(trace.AlwaysSample()),
+		out: trace.ParentBased(newRatioSampler(1)),
 	}, {
 		in:  Sampler{Name: "parentbased_traceidratio", Arg: "0.3"},
-		out: trace.ParentBased(trace.TraceIDRatioBased(0.3)),
+		out: trace.ParentBased(newRatioSampler(0.3)),
 	}, {
 		in:  Sampler{Name: "parentbased_traceidratio", Arg: "wrong argument"},
-		out: trace.ParentBased(trace.AlwaysSample()),
+		out: trace.ParentBased(newRatioSampler(1)),
 	}} {
 		t.Run(tc.in.Name+"/"+tc.in.Arg, func(t *testing.T) {
 			assert.Equal(t, tc.out, tc.in.Implementation())
 		})
 	}
 }
+
+func TestShadowSampler(t *testing.T) {
+	decisions := &fakeSamplingDecisions{}
+	tr := newTracesOTELReceiver(context.Background(), TracesConfig{
+		Sampler:       Sampler{Name: "always_on"},
+		ShadowSampler: &Sampler{Name: "traceidratio", Arg: "0.5"},
+	}, &global.ContextInfo{Metrics: decisions}, attributes.Selection{})
+
+	const total = 1000
+	for i := 1; i <= total; i++ {
+		traceID, _ := NewIDs(i)
+		// the ratio sampler only looks at the lower 8 bytes of the trace ID
+		binary.BigEndian.PutUint64(traceID[8:], uint64(i)*(math.MaxUint64/total))
+		// the active sampler decision must not be affected by the shadow sampler
+		assert.True(t, tr.sample(&request.Span{Type: request.EventTypeHTTP, TraceID: traceID}))
+	}
+
+	assert.Equal(t, total, decisions.kept[samplerActive])
+	assert.Zero(t, decisions.dropped[samplerActive])
+	assert.InDelta(t, total/2, decisions.kept[samplerShadow], total/20)
+	assert.Equal(t, total, decisions.kept[samplerShadow]+decisions.dropped[samplerShadow])
+}
+
+func TestShadowSampler_Disabled(t *testing.T) {
+	decisions := &fakeSamplingDecisions{}
+	tr := newTracesOTELReceiver(context.Background(), TracesConfig{
+		Sampler: Sampler{Name: "always_off"},
+	}, &global.ContextInfo{Metrics: decisions}, attributes.Selection{})
+
+	assert.False(t, tr.sample(&request.Span{Type: request.EventTypeHTTP}))
+	assert.Equal(t, 1, decisions.dropped[samplerActive])
+	assert.Zero(t, decisions.kept[samplerShadow]+decisions.dropped[samplerShadow])
+}
+
+func TestTracesReceiver_UnsetSampler(t *testing.T) {
+	// a server span whose upstream service decided not to sample the trace
+	unsampledParent := request.Span{
+		Type: request.EventTypeHTTP, Method: "GET", Path: "/foo", Status: 200,
+		TraceID: trace2.TraceID{1}, ParentSpanID: trace2.SpanID{1}, Flags: 0,
+	}
+	export := func(cfg TracesConfig) (*batchesExporter, *fakeSamplingDecisions) {
+		decisions := &fakeSamplingDecisions{}
+		tr, exp := batchingReceiver(t, cfg)
+		tr.ctxInfo.Metrics = decisions
+		loop, err := tr.provideLoop()
+		require.NoError(t, err)
+		in := make(chan []request.Span, 1)
+		in <- []request.Span{unsampledParent}
+		close(in)
+		loop(in)
+		return exp, decisions
+	}
+
+	t.Run("spans are not sampled if no sampler is configured", func(t *testing.T) {
+		exp, decisions := export(TracesConfig{ShadowSampler: &Sampler{Name: "always_off"}})
+		assert.Equal(t, []int{1}, exp.SpanCounts())
+		// the shadow sampler decision is only recorded
+		assert.Equal(t, 1, decisions.kept[samplerActive])
+		assert.Equal(t, 1, decisions.dropped[samplerShadow])
+	})
+	t.Run("explicitly configured sampler", func(t *testing.T) {
+		exp, decisions := export(TracesConfig{Sampler: Sampler{Name: "parentbased_always_on"}})
+		assert.Empty(t, exp.SpanCounts())
+		assert.Equal(t, 1, decisions.dropped[samplerActive])
+	})
+}
+
+func TestTracesReceiver_SamplerDecidesOnExportedTraceID(t *testing.T) {
+	tr, exp := batchingReceiver(t, TracesConfig{Sampler: Sampler{Name: "traceidratio", Arg: "0.5"}})
+	loop, err := tr.provideLoop()
+	require.NoError(t, err)
+	const total = 200
+	spans := make([]request.Span, total)
+	for i := range spans {
+		// the trace IDs are not provided by the tracer, so they are generated by Beyla
+		spans[i] = request.Span{Type: request.EventTypeHTTP, Method: "GET", Path: "/foo", Status: 200}
+	}
+	original := slices.Clone(spans)
+	in := make(chan []request.Span, 1)
+	in <- spans
+	close(in)
+	loop(in)
+	// the trace IDs are only generated in the copy of the batch that is exported as traces
+	assert.Equal(t, original, spans)
+
+	ratio := trace.TraceIDRatioBased(0.5)
+	exported := 0
+	for _, b := range exp.batches {
+		span := b.ResourceSpans().At(0).ScopeSpans().At(0).Spans().At(0)
+		// the exported trace ID is the one that the sampler decided to keep
+		res := ratio.ShouldSample(trace.SamplingParameters{ParentContext: context.Background(), TraceID: trace2.TraceID(span.TraceID())})
+		assert.Equal(t, trace.RecordAndSample, res.Decision)
+		exported++
+	}
+	assert.InDelta(t, total/2, exported, total/5)
+}
+
+func TestTracesReceiver_SamplingProbabilityNotShared(t *testing.T) {
+	tr, exp := batchingReceiver(t, TracesConfig{Sampler: Sampler{Name: "traceidratio", Arg: "1"}})
+	tr.attributes = attributes.Selection{
+		attributes.Traces.Section: attributes.InclusionLists{Include: []string{string(attr.BeylaSamplingProbability)}},
+	}
+	loop, err := tr.provideLoop()
+	require.NoError(t, err)
+	spans := []request.Span{{Type: request.EventTypeHTTP, Method: "GET", Path: "/foo", Status: 200}}
+	in := make(chan []request.Span, 1)
+	in <- spans
+	close(in)
+	loop(in)
+
+	require.Len(t, exp.batches, 1)
+	p, ok := exp.batches[0].ResourceSpans().At(0).ScopeSpans().At(0).Spans().At(0).Attributes().
+		Get(string(attr.BeylaSamplingProbability))
+	require.True(t, ok)
+	assert.Equal(t, 1.0, p.Double())
+	// the probability is only set in the copy of the batch that is exported as traces
+	assert.Zero(t, spans[0].SamplingProbability)
+}
+
+type fakeSamplingDecisions struct {
+	imetrics.NoopReporter
+	kept    map[string]int
+	dropped map[string]int
+}
+
+func (f *fakeSamplingDecisions) OTELTraceSamplingDecision(sampler string, keep bool) {
+	if f.kept == nil {
+		f.kept, f.dropped = map[string]int{}, map[string]int{}
+	}
+	if keep {
+		f.kept[sampler]++
+	} else {
+		f.dropped[sampler]++
+	}
+}
+
+// alternateSampler is a non-deterministic sampler that alternates its keep/drop decisions
+type alternateSampler struct {
+	calls atomic.Int64
+}
+
+func (as *alternateSampler) ShouldSample(_ trace.SamplingParameters) trace.SamplingResult {
+	if as.calls.Add(1)%2 == 0 {
+		return trace.SamplingResult{Decision: trace.Drop}
+	}
+	return trace.SamplingResult{Decision: trace.RecordAndSample}
+}
+
+func (as *alternateSampler) Description() string { return "alternate" }
+
+func TestDecisionsCache_ConsistentPerTrace(t *testing.T) {
+	sampler := &alternateSampler{}
+	tr := newTracesOTELReceiver(context.Background(),
+		TracesConfig{SamplingDecisionsCacheLen: 10}, nil, attributes.Selection{})
+	tr.sampler = sampler
+
+	traceA, traceB := trace2.TraceID{1}, trace2.TraceID{2}
+	assert.True(t, tr.sample(&request.Span{TraceID: traceA, SpanID: trace2.SpanID{1}}))
+	assert.False(t, tr.sample(&request.Span{TraceID: traceB, SpanID: trace2.SpanID{2}}))
+	for i := byte(3); i < 10; i++ {
+		assert.True(t, tr.sample(&request.Span{TraceID: traceA, SpanID: trace2.SpanID{i}}))
+		assert.False(t, tr.sample(&request.Span{TraceID: traceB, SpanID: trace2.SpanID{i}, ParentSpanID: trace2.SpanID{2}}))
+	}
+	// the sampler is only evaluated for the first span of each trace
+	assert.EqualValues(t, 2, sampler.calls.Load())
+}
+
+func TestDecisionsCache_Bounded(t *testing.T) {
+	sampler := &alternateSampler{}
+	dc := newDecisionsCache(1)
+
+	assert.True(t, dc.sample(sampler, &request.Span{TraceID: trace2.TraceID{1}}, nil).keep)
+	assert.False(t, dc.sample(sampler, &request.Span{TraceID: trace2.TraceID{2}}, nil).keep)
+	// the decision for the first trace was evicted, so the sampler is evaluated again
+	assert.True(t, dc.sample(sampler, &request.Span{TraceID: trace2.TraceID{1}}, nil).keep)
+	assert.EqualValues(t, 3, sampler.calls.Load())
+}
+
+func TestDecisionsCache_Concurrent(t *testing.T) {
+	dc := newDecisionsCache(100)
+	sampler := &alternateSampler{}
+	traceID := trace2.TraceID{1}
+
+	const goroutines = 20
+	decisions := make(chan bool, goroutines)
+	wg := sync.WaitGroup{}
+	wg.Add(goroutines)
+	for i := 0; i < goroutines; i++ {
+		go func() {
+			defer wg.Done()
+			decisions <- dc.sample(sampler, &request.Span{TraceID: traceID}, nil).keep
+		}()
+	}
+	wg.Wait()
+	close(decisions)
+
+	first := <-decisions
+	for keep := range decisions {
+		assert.Equal(t, first, keep)
+	}
+}
+
+func TestDecisionsCache_DroppedParentDropsChildren(t *testing.T) {
+	tr := newTracesOTELReceiver(context.Background(), TracesConfig{
+		Sampler:                   Sampler{Name: "parentbased_traceidratio", Arg: "0"},
+		SamplingDecisionsCacheLen: 10,
+	}, nil, attributes.Selection{})
+
+	traceID, rootID := trace2.TraceID{1}, trace2.SpanID{1}
+	in := make(chan []request.Span, 1)
+	// the children are reported before the root, and they are marked as sampled
+	// by the context propagated from the locally generated root span
+	in <- []request.Span{
+		{Path: "/child1", TraceID: traceID, SpanID: trace2.SpanID{2}, ParentSpanID: rootID, Flags: 1},
+		{Path: "/grandchild", TraceID: traceID, SpanID: trace2.SpanID{3}, ParentSpanID: trace2.SpanID{2}, Flags: 1},
+		{Path: "/root", TraceID: traceID, SpanID: rootID},
+		{Path: "/child2", TraceID: traceID, SpanID: trace2.SpanID{4}, ParentSpanID: rootID, Flags: 1},
+		{Path: "/remote", TraceID: trace2.TraceID{2}, SpanID: trace2.SpanID{5}, ParentSpanID: trace2.SpanID{6}, Flags: 1},
+	}
+	close(in)
+
+	var exported []string
+	tr.consume(in, func(s *request.Span) {
+		if tr.sample(s) {
+			exported = append(exported, s.Path)
+		}
+	})
+	// only the span whose parent is remote and sampled is kept
+	assert.Equal(t, []string{"/remote"}, exported)
+}
+
+func TestParentsFirst(t *testing.T) {
+	traceID := trace2.TraceID{1}
+	spans := []request.Span{
+		{TraceID: traceID, SpanID: trace2.SpanID{3}, ParentSpanID: trace2.SpanID{2}},
+		{TraceID: traceID, SpanID: trace2.SpanID{2}, ParentSpanID: trace2.SpanID{1}},
+		{TraceID: traceID, SpanID: trace2.SpanID{1}},
+		// same span ID as the root, but from another trace
+		{TraceID: trace2.TraceID{2}, SpanID: trace2.SpanID{4}, ParentSpanID: trace2.SpanID{1}},
+		// invalid span IDs
+		{},
+		{},
+	}
+	assert.Equal(t, []int{2, 1, 0, 3, 4, 5}, parentsFirst(spans))
+}
+
+type canarySampler struct {
+	args map[string]any
+}
+
+func (cs *canarySampler) ShouldSample(_ trace.SamplingParameters) trace.SamplingResult {
+	return trace.SamplingResult{Decision: trace.RecordAndSample}
+}
+
+func (cs *canarySampler) Description() string { return "canary" }
+
+func TestRegisterSampler(t *testing.T) {
+	RegisterSampler("test_canary", func(args map[string]any) trace.Sampler {
+		return &canarySampler{args: args}
+	})
+
+	cfg := Sampler{Name: "test_canary", Arg: "0.5", Args: map[string]any{"deployment": "canary"}}
+	sampler := cfg.Implementation()
+	require.IsType(t, &canarySampler{}, sampler)
+	assert.Equal(t, map[string]any{"deployment": "canary", "arg": "0.5"}, sampler.(*canarySampler).args)
+	// the configuration arguments are not modified
+	assert.Equal(t, map[string]any{"deployment": "canary"}, cfg.Args)
+
+	// the probability of custom samplers is unknown unless they report it
+	assert.Zero(t, sampleSpan(sampler, &request.Span{}, nil).probability)
+
+	assert.Panics(t, func() {
+		RegisterSampler("test_canary", func(_ map[string]any) trace.Sampler { return trace.AlwaysSample() })
+	})
+	assert.Panics(t, func() {
+		RegisterSampler("always_on", func(_ map[string]any) trace.Sampler { return trace.AlwaysSample() })
+	})
+	// unregistered samplers still default to parentbased_always_on
+	assert.Equal(t, trace.ParentBased(newRatioSampler(1)), (&Sampler{Name: "test_unregistered"}).Implementation())
+}
+
+// sampledProbability samples the span with the traces receiver and returns the sampling probability
+// that is exported for it, verifying that the adjusted count is consistent with it
+func sampledProbability(t *testing.T, tr *tracesOTELReceiver, span *request.Span) (float64, bool) {
+	t.Helper()
+	require.True(t, tr.sample(span), "span should be kept")
+	traces := GenerateTraces(&tr.cfg, span, map[attr.Name]struct{}{
+		attr.BeylaSamplingProbability: {}, attr.BeylaAdjustedCount: {},
+	})
+	attrs := traces.ResourceSpans().At(0).ScopeSpans().At(0).Spans().At(0).Attributes()
+	p, ok := attrs.Get(string(attr.BeylaSamplingProbability))
+	count, countOK := attrs.Get(string(attr.BeylaAdjustedCount))
+	require.Equal(t, ok, countOK)
+	if !ok {
+		return 0, false
+	}
+	assert.InDelta(t, 1/p.Double(), count.Double(), 1e-9)
+	return p.Double(), true
+}
@@ -0,0 +1,118 @@
+package otel
+
+import (
+	"encoding/binary"
+	"fmt"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/trace"
+	trace2 "go.opentelemetry.io/otel/trace"
+)
+
+const samplerScore = "score"
+
+// attrSamplingError is set in the sampling parameters of the spans with error status.
+// It is only used for the sampling decisions and it is not exported as a span attribute.
+const attrSamplingError = attribute.Key("beyla.sampling.error")
+
+const defaultScoreMaxLatency = time.Second
+
+// ScoreSamplerConfig configures how the score sampler weights the errors and the latency of the spans
+type ScoreSamplerConfig struct {
+	// ErrorWeight is added to the score of the spans with error status
+	ErrorWeight float64 `yaml:"error_weight" env:"BEYLA_OTLP_TRACES_SAMPLER_SCORE_ERROR_WEIGHT"`
+	// LatencyWeight is multiplied by the normalized latency of the span, and added to its score
+	LatencyWeight float64 `yaml:"latency_weight" env:"BEYLA_OTLP_TRACES_SAMPLER_SCORE_LATENCY_WEIGHT"`
+	// MaxLatency normalizes the latency of the spans between 0 and 1. The spans with longer
+	// duration have a normalized latency of 1. Defaults to 1 second.
+	MaxLatency time.Duration `yaml:"max_latency" env:"BEYLA_OTLP_TRACES_SAMPLER_SCORE_MAX_LATENCY"`
+	// Probabilistic, if true, keeps the spans whose score is below the threshold with a probability
+	// proportional to their score. Otherwise, they are dropped.
+	Probabilistic bool `yaml:"probabilistic" env:"BEYLA_OTLP_TRACES_SAMPLER_SCORE_PROBABILISTIC"`
+}
+
+// scoreSampler keeps the most interesting spans according to a score that combines their error status
+// and their latency: score = error_weight * is_error + latency_weight * normalized_latency.
+// The spans whose score reaches the threshold are kept. The rest are dropped or, in probabilistic mode,
+// kept with a probability of score/threshold, consistently for all the spans of the same trace.
+type scoreSampler struct {
+	threshold     float64
+	errorWeight   float64
+	latencyWeight float64
+	maxLatency    time.Duration
+	probabilistic bool
+}
+
+func newScoreSampler(threshold float64, cfg *ScoreSamplerConfig) *scoreSampler {
+	ss := &scoreSampler{
+		threshold:     threshold,
+		errorWeight:   cfg.ErrorWeight,
+		latencyWeight: cfg.LatencyWeight,
+		maxLatency:    cfg.MaxLatency,
+		probabilistic: cfg.Probabilistic,
+	}
+	if ss.errorWeight == 0 && ss.latencyWeight == 0 {
+		ss.errorWeight, ss.latencyWeight = 1, 1
+	}
+	if ss.maxLatency <= 0 {
+		ss.maxLatency = defaultScoreMaxLatency
+	}
+	return ss
+}
+
+func (ss *scoreSampler) ShouldSample(p trace.SamplingParameters) trace.SamplingResult {
+	probability := ss.probability(ss.score(&p))
+	decision := trace.Drop
+	if ss.keep(probability, p.TraceID) {
+		decision = trace.RecordAndSample
+	}
+	return withProbability(trace.SamplingResult{
+		Decision:   decision,
+		Tracestate: trace2.SpanContextFromContext(p.ParentContext).TraceState(),
+	}, probability)
+}
+
+// probability of keeping a span with the provided score
+func (ss *scoreSampler) probability(score float64) float64 {
+	if score >= ss.threshold {
+		return 1
+	}
+	if !ss.probabilistic || score <= 0 {
+		return 0
+	}
+	return score / ss.threshold
+}
+
+func (ss *scoreSampler) score(p *trace.SamplingParameters) float64 {
+	var score float64
+	for _, a := range p.Attributes {
+		switch a.Key {
+		case attrSamplingError:
+			if a.Value.AsBool() {
+				score += ss.errorWeight
+			}
+		case attrSamplingDuration:
+			latency := min(1, float64(a.Value.AsInt64())/float64(ss.maxLatency))
+			score += ss.latencyWeight * latency
+		}
+	}
+	return score
+}
+
+func (ss *scoreSampler) keep(probability float64, traceID trace2.TraceID) bool {
+	switch {
+	case probability >= 1:
+		return true
+	case probability <= 0:
+		return false
+	}
+	// same decision for all the spans of the trace, as in the TraceIDRatioBased sampler
+	bound := uint64(probability * (1 << 63))
+	return binary.BigEndian.Uint64(traceID[8:16])>>1 < bound
+}
+
+func (ss *scoreSampler) Description() string {
+	return fmt.Sprintf("Score{threshold=%g,errorWeight=%g,latencyWeight=%g,maxLatency=%s,probabilistic=%t}",
+		ss.threshold, ss.errorWeight, ss.latencyWeight, ss.maxLatency, ss.probabilistic)
+}
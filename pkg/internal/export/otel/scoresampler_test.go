@@ -0,0 +1,91 @@
+package otel
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/grafana/beyla/pkg/internal/request"
+)
+
+func scoredSpan(status int, duration time.Duration) *request.Span {
+	return &request.Span{Type: request.EventTypeHTTP, Method: "GET", Route: "/users", Status: status,
+		RequestStart: 1000, End: 1000 + int64(duration)}
+}
+
+func TestScoreSampler(t *testing.T) {
+	s := Sampler{Name: samplerScore, Arg: "1", Score: ScoreSamplerConfig{
+		ErrorWeight:   1,
+		LatencyWeight: 0.5,
+		MaxLatency:    2 * time.Second,
+	}}
+	sampler, ok := s.Implementation().(*scoreSampler)
+	require.True(t, ok)
+
+	// high score
+	assert.True(t, shouldSample(sampler, scoredSpan(500, time.Millisecond), nil))
+	assert.True(t, shouldSample(sampler, scoredSpan(500, 5*time.Second), nil))
+	// low score
+	assert.False(t, shouldSample(sampler, scoredSpan(200, time.Millisecond), nil))
+	// latency alone can't reach the threshold, as its weight is 0.5
+	assert.False(t, shouldSample(sampler, scoredSpan(200, 5*time.Second), nil))
+
+	// latency alone reaches the threshold
+	latencyOnly := newScoreSampler(1, &ScoreSamplerConfig{LatencyWeight: 1, MaxLatency: time.Second})
+	assert.True(t, shouldSample(latencyOnly, scoredSpan(200, time.Second), nil))
+	assert.False(t, shouldSample(latencyOnly, scoredSpan(200, 900*time.Millisecond), nil))
+	assert.False(t, shouldSample(latencyOnly, scoredSpan(500, time.Millisecond), nil))
+
+	// the spans reaching the threshold are always kept
+	assert.Equal(t, samplingDecision{keep: true, probability: 1}, sampleSpan(sampler, scoredSpan(500, time.Millisecond), nil))
+}
+
+func TestScoreSampler_Defaults(t *testing.T) {
+	sampler := newScoreSampler(1, &ScoreSamplerConfig{})
+	assert.Equal(t, 1.0, sampler.errorWeight)
+	assert.Equal(t, 1.0, sampler.latencyWeight)
+	assert.Equal(t, time.Second, sampler.maxLatency)
+
+	// wrong threshold
+	s := Sampler{Name: samplerScore, Arg: "foo"}
+	_, ok := s.Implementation().(*scoreSampler)
+	assert.False(t, ok)
+}
+
+func TestScoreSampler_Probabilistic(t *testing.T) {
+	sampler := newScoreSampler(1, &ScoreSamplerConfig{
+		LatencyWeight: 1, MaxLatency: time.Second, Probabilistic: true,
+	})
+	rnd := rand.New(rand.NewSource(1234))
+	const spans = 20000
+	kept := map[time.Duration]int{}
+	for i := 0; i < spans; i++ {
+		for _, duration := range []time.Duration{0, 100 * time.Millisecond, 500 * time.Millisecond, 2 * time.Second} {
+			span := scoredSpan(200, duration)
+			_, _ = rnd.Read(span.TraceID[:])
+			if shouldSample(sampler, span, nil) {
+				kept[duration]++
+			}
+		}
+	}
+	// spans below the threshold are kept proportionally to their score
+	assert.Zero(t, kept[0])
+	assert.InDelta(t, 0.1, float64(kept[100*time.Millisecond])/spans, 0.01)
+	assert.InDelta(t, 0.5, float64(kept[500*time.Millisecond])/spans, 0.01)
+	assert.Equal(t, spans, kept[2*time.Second])
+
+	// the decision is consistent for all the spans of a trace
+	span := scoredSpan(200, 500*time.Millisecond)
+	span.TraceID = trace.TraceID{8: 0x10}
+	assert.True(t, shouldSample(sampler, span, nil))
+	span.TraceID = trace.TraceID{8: 0xf0}
+	assert.False(t, shouldSample(sampler, span, nil))
+
+	// the kept spans report the probability of their score
+	span.TraceID = trace.TraceID{8: 0x10}
+	assert.Equal(t, samplingDecision{keep: true, probability: 0.5}, sampleSpan(sampler, span, nil))
+}
@@ -0,0 +1,102 @@
+package otel
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"sync/atomic"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/sdk/resource"
+	"go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.19.0"
+	trace2 "go.opentelemetry.io/otel/trace"
+
+	"github.com/grafana/beyla/pkg/buildinfo"
+)
+
+// attributes of the self-traces
+const (
+	attrSelfTraceEndpoint   = attribute.Key("beyla.export.endpoint")
+	attrSelfTraceRetryCount = attribute.Key("beyla.export.retry_count")
+)
+
+// selfTracer reports significant internal events of the traces exporter (e.g. export failures)
+// as spans that are submitted to a diagnostic endpoint. It uses its own OTLP/HTTP exporter, whose
+// errors are just logged by the OpenTelemetry SDK, so a failing self-trace never generates other
+// self-traces.
+type selfTracer struct {
+	provider *trace.TracerProvider
+	tracer   trace2.Tracer
+	// endpoint of the traces exporter whose events are reported, without credentials
+	endpoint string
+	// consecutiveFailures is the number of exports that failed since the last successful export
+	consecutiveFailures atomic.Int64
+}
+
+func newSelfTracer(ctx context.Context, cfg *TracesConfig) (*selfTracer, error) {
+	murl, err := url.Parse(cfg.SelfTraceEndpoint)
+	if err != nil {
+		return nil, fmt.Errorf("parsing self-trace endpoint: %w", err)
+	}
+	if murl.Scheme == "" || murl.Host == "" {
+		return nil, fmt.Errorf("self-trace endpoint %q must be an absolute URL", cfg.SelfTraceEndpoint)
+	}
+	opts := otlpOptions{Endpoint: murl.Host, URLPath: murl.Path, Insecure: murl.Scheme == "http"}
+	if opts.URLPath == "" {
+		opts.URLPath = "/v1/traces"
+	}
+	exp, err := otlptracehttp.New(ctx, opts.AsTraceHTTP()...)
+	if err != nil {
+		return nil, fmt.Errorf("creating self-trace exporter: %w", err)
+	}
+	return newSelfTracerWithProcessor(trace.NewBatchSpanProcessor(exp), cfg), nil
+}
+
+func newSelfTracerWithProcessor(processor trace.SpanProcessor, cfg *TracesConfig) *selfTracer {
+	endpoint, _ := cfg.effectiveEndpoint()
+	provider := trace.NewTracerProvider(
+		trace.WithSpanProcessor(processor),
+		trace.WithResource(resource.NewWithAttributes(semconv.SchemaURL,
+			semconv.ServiceName("beyla"),
+			semconv.ServiceVersion(buildinfo.Version),
+		)),
+	)
+	return &selfTracer{
+		provider: provider,
+		tracer:   provider.Tracer(reporterName),
+		endpoint: redactEndpoint(endpoint),
+	}
+}
+
+// exporterInitFailure reports a failed attempt to create the traces exporter
+func (st *selfTracer) exporterInitFailure(err error, attempt int) {
+	st.report("beyla.exporter.init_failure", err, attempt-1)
+}
+
+// exportResult reports the failed exports, along with the number of previous consecutive failures
+func (st *selfTracer) exportResult(err error) {
+	if err == nil {
+		st.consecutiveFailures.Store(0)
+		return
+	}
+	st.report("beyla.export.failure", err, int(st.consecutiveFailures.Add(1)-1))
+}
+
+func (st *selfTracer) report(name string, err error, retries int) {
+	_, span := st.tracer.Start(context.Background(), name, trace2.WithAttributes(
+		attrSelfTraceEndpoint.String(st.endpoint),
+		attrSelfTraceRetryCount.Int(retries),
+	))
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+	span.End()
+}
+
+func (st *selfTracer) shutdown(ctx context.Context) {
+	if err := st.provider.Shutdown(ctx); err != nil {
+		tlog().Warn("error shutting down self-trace exporter", "error", err)
+	}
+}
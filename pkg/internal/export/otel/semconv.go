@@ -0,0 +1,75 @@
+package otel
+
+import (
+	"go.opentelemetry.io/otel/attribute"
+	trace2 "go.opentelemetry.io/otel/trace"
+
+	attr "github.com/grafana/beyla/pkg/internal/export/attributes/names"
+	"github.com/grafana/beyla/pkg/internal/request"
+)
+
+// Accepted values for the TracesConfig.SemconvCompatMode option
+const (
+	// SemconvStable emits the attribute names of the stable HTTP semantic conventions (default)
+	SemconvStable = "stable"
+	// SemconvLegacy emits the attribute names previous to the stable HTTP semantic conventions
+	SemconvLegacy = "legacy"
+	// SemconvDup emits both the stable and the legacy attribute names
+	SemconvDup = "dup"
+)
+
+// legacyAttrNames maps the stable attribute names to their name in the
+// semantic conventions previous to the HTTP stabilization (v1.19)
+var legacyAttrNames = map[attribute.Key]attribute.Key{
+	attr.HTTPRequestMethod.OTEL():      "http.method",
+	attr.HTTPResponseStatusCode.OTEL(): "http.status_code",
+	attr.HTTPUrlPath.OTEL():            "http.target",
+	attr.HTTPUrlFull.OTEL():            "http.url",
+	attr.ClientAddr.OTEL():             "net.sock.peer.addr",
+	attr.HTTPRequestBodySize.OTEL():    "http.request_content_length",
+	attr.HTTPResponseBodySize.OTEL():   "http.response_content_length",
+	attr.NetworkProtocolVersion.OTEL(): "net.protocol.version",
+	attr.NetworkTransport.OTEL():       "net.transport",
+}
+
+// legacy names of the server address and port, which depend on whether the span is
+// reported by the server (host) or the client (peer)
+var (
+	legacyServerAddrNames = map[bool]attribute.Key{true: "net.host.name", false: "net.peer.name"}
+	legacyServerPortNames = map[bool]attribute.Key{true: "net.host.port", false: "net.peer.port"}
+)
+
+func legacyAttrName(key attribute.Key, server bool) (attribute.Key, bool) {
+	switch key {
+	case attr.ServerAddr.OTEL():
+		return legacyServerAddrNames[server], true
+	case attr.ServerPort.OTEL():
+		return legacyServerPortNames[server], true
+	}
+	legacy, ok := legacyAttrNames[key]
+	return legacy, ok
+}
+
+// applySemconvCompat renames the span attributes that changed with the stabilization of the
+// HTTP semantic conventions, according to the provided SemconvCompatMode. In "dup" mode,
+// the legacy attributes are appended after the stable ones.
+func applySemconvCompat(mode string, span *request.Span, attrs []attribute.KeyValue) []attribute.KeyValue {
+	if mode != SemconvLegacy && mode != SemconvDup {
+		return attrs
+	}
+	server := spanKind(span) == trace2.SpanKindServer
+	if mode == SemconvLegacy {
+		for i := range attrs {
+			if legacy, ok := legacyAttrName(attrs[i].Key, server); ok {
+				attrs[i].Key = legacy
+			}
+		}
+		return attrs
+	}
+	for i, stableLen := 0, len(attrs); i < stableLen; i++ {
+		if legacy, ok := legacyAttrName(attrs[i].Key, server); ok {
+			attrs = append(attrs, attribute.KeyValue{Key: legacy, Value: attrs[i].Value})
+		}
+	}
+	return attrs
+}
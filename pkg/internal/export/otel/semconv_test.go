@@ -0,0 +1,77 @@
+package otel
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/attribute"
+
+	"github.com/grafana/beyla/pkg/internal/request"
+)
+
+func TestSemconvCompatMode(t *testing.T) {
+	server := request.Span{Type: request.EventTypeHTTP, Method: "GET", Path: "/foo", Status: 200, HostPort: 8080, ContentLength: 12}
+	client := request.Span{Type: request.EventTypeHTTPClient, Method: "POST", Path: "http://bar/baz", Status: 201, HostPort: 80}
+
+	keys := func(attrs []attribute.KeyValue) []string {
+		var res []string
+		for _, a := range attrs {
+			res = append(res, string(a.Key))
+		}
+		return res
+	}
+
+	for _, tc := range []struct {
+		mode        string
+		span        *request.Span
+		contains    []string
+		notContains []string
+	}{{
+		mode:        "",
+		span:        &server,
+		contains:    []string{"http.request.method", "http.response.status_code", "url.path", "server.port", "http.request.body.size"},
+		notContains: []string{"http.method", "http.status_code", "http.target", "net.host.port"},
+	}, {
+		mode:        SemconvStable,
+		span:        &client,
+		contains:    []string{"http.request.method", "url.full", "server.address"},
+		notContains: []string{"http.method", "http.url", "net.peer.name"},
+	}, {
+		mode:        SemconvLegacy,
+		span:        &server,
+		contains:    []string{"http.method", "http.status_code", "http.target", "net.host.name", "net.host.port", "http.request_content_length"},
+		notContains: []string{"http.request.method", "http.response.status_code", "url.path", "server.port", "http.request.body.size"},
+	}, {
+		mode:        SemconvLegacy,
+		span:        &client,
+		contains:    []string{"http.method", "http.url", "net.peer.name", "net.peer.port"},
+		notContains: []string{"http.request.method", "url.full", "server.address", "net.host.name"},
+	}, {
+		mode:     SemconvDup,
+		span:     &server,
+		contains: []string{"http.request.method", "http.method", "url.path", "http.target", "server.port", "net.host.port"},
+	}} {
+		t.Run(tc.mode+"/"+TraceName(tc.span, nil), func(t *testing.T) {
+			cfg := TracesConfig{SemconvCompatMode: tc.mode}
+			attrs := traceAttributes(&cfg, tc.span, nil)
+			names := keys(attrs)
+			for _, k := range tc.contains {
+				assert.Contains(t, names, k)
+			}
+			for _, k := range tc.notContains {
+				assert.NotContains(t, names, k)
+			}
+		})
+	}
+
+	t.Run("dup mode keeps the same values", func(t *testing.T) {
+		cfg := TracesConfig{SemconvCompatMode: SemconvDup}
+		values := map[attribute.Key]attribute.Value{}
+		for _, a := range traceAttributes(&cfg, &server, nil) {
+			values[a.Key] = a.Value
+		}
+		assert.Equal(t, values["http.request.method"], values["http.method"])
+		assert.Equal(t, values["http.response.status_code"], values["http.status_code"])
+		assert.Equal(t, "GET", values["http.method"].AsString())
+	})
+}
@@ -0,0 +1,56 @@
+package otel
+
+import (
+	"strconv"
+
+	"go.opentelemetry.io/otel/attribute"
+
+	attr "github.com/grafana/beyla/pkg/internal/export/attributes/names"
+)
+
+// bucketedSizeAttrs maps the attributes with exact body sizes to the attributes that replace them
+// when the RequestSizeBuckets are defined
+var bucketedSizeAttrs = map[attribute.Key]attribute.Key{
+	attr.HTTPRequestBodySize.OTEL():  attr.BeylaRequestSizeBucket.OTEL(),
+	attr.HTTPResponseBodySize.OTEL(): attr.BeylaResponseSizeBucket.OTEL(),
+}
+
+// bucketSizes replaces the exact request and response body sizes by the label of their size bucket
+func bucketSizes(bounds []int, attrs []attribute.KeyValue) []attribute.KeyValue {
+	if len(bounds) == 0 {
+		return attrs
+	}
+	for i := range attrs {
+		if key, ok := bucketedSizeAttrs[attrs[i].Key]; ok {
+			attrs[i] = key.String(sizeBucket(bounds, attrs[i].Value.AsInt64()))
+		}
+	}
+	return attrs
+}
+
+// sizeBucket returns the label of the bucket of the given size, where bounds are the
+// ascending bucket limits, e.g. <1k, 1k-10k or >=10k for the 1000 and 10000 bounds.
+func sizeBucket(bounds []int, size int64) string {
+	for i, bound := range bounds {
+		if size < int64(bound) {
+			if i == 0 {
+				return "<" + formatSize(bound)
+			}
+			return formatSize(bounds[i-1]) + "-" + formatSize(bound)
+		}
+	}
+	return ">=" + formatSize(bounds[len(bounds)-1])
+}
+
+// formatSize abbreviates the multiples of 1000 with the k, M and G suffixes
+func formatSize(size int) string {
+	for _, unit := range []struct {
+		factor int
+		suffix string
+	}{{1_000_000_000, "G"}, {1_000_000, "M"}, {1_000, "k"}} {
+		if size >= unit.factor && size%unit.factor == 0 {
+			return strconv.Itoa(size/unit.factor) + unit.suffix
+		}
+	}
+	return strconv.Itoa(size)
+}
@@ -0,0 +1,50 @@
+package otel
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	attr "github.com/grafana/beyla/pkg/internal/export/attributes/names"
+	"github.com/grafana/beyla/pkg/internal/request"
+)
+
+func TestSizeBucket(t *testing.T) {
+	bounds := []int{1000, 10_000, 1_500_000}
+	for _, tc := range []struct {
+		size     int64
+		expected string
+	}{
+		{size: 0, expected: "<1k"},
+		{size: 999, expected: "<1k"},
+		{size: 1000, expected: "1k-10k"},
+		{size: 9999, expected: "1k-10k"},
+		{size: 10_000, expected: "10k-1500k"},
+		{size: 1_499_999, expected: "10k-1500k"},
+		{size: 1_500_000, expected: ">=1500k"},
+		{size: 50_000_000, expected: ">=1500k"},
+	} {
+		assert.Equal(t, tc.expected, sizeBucket(bounds, tc.size), tc.size)
+	}
+	assert.Equal(t, "<512", sizeBucket([]int{512, 2_000_000}, 100))
+	assert.Equal(t, ">=2M", sizeBucket([]int{512, 2_000_000}, 2_000_000))
+}
+
+func TestGenerateTraces_RequestSizeBuckets(t *testing.T) {
+	span := request.Span{Type: request.EventTypeHTTPClient, Method: "POST", ContentLength: 2048, ResponseLength: 20}
+	selection := map[attr.Name]struct{}{attr.HTTPResponseBodySize: {}}
+
+	traces := GenerateTraces(&TracesConfig{RequestSizeBuckets: []int{1000, 10_000}}, &span, selection)
+	attrs := traces.ResourceSpans().At(0).ScopeSpans().At(0).Spans().At(0).Attributes()
+	ensureTraceStrAttr(t, attrs, attr.BeylaRequestSizeBucket.OTEL(), "1k-10k")
+	ensureTraceStrAttr(t, attrs, attr.BeylaResponseSizeBucket.OTEL(), "<1k")
+	ensureTraceAttrNotExists(t, attrs, attr.HTTPRequestBodySize.OTEL())
+	ensureTraceAttrNotExists(t, attrs, attr.HTTPResponseBodySize.OTEL())
+
+	// without buckets, the exact sizes are reported
+	traces = GenerateTraces(&TracesConfig{}, &span, selection)
+	attrs = traces.ResourceSpans().At(0).ScopeSpans().At(0).Spans().At(0).Attributes()
+	ensureTraceStrAttr(t, attrs, attr.HTTPRequestBodySize.OTEL(), "2048")
+	ensureTraceStrAttr(t, attrs, attr.HTTPResponseBodySize.OTEL(), "20")
+	ensureTraceAttrNotExists(t, attrs, attr.BeylaRequestSizeBucket.OTEL())
+}
@@ -0,0 +1,187 @@
+package otel
+
+import (
+	"slices"
+	"strconv"
+	"sync"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.19.0"
+	trace2 "go.opentelemetry.io/otel/trace"
+)
+
+const samplerSlowTail = "slow_tail"
+
+// attrSamplingDuration carries the span duration, in nanoseconds, in the sampling parameters.
+// It is only used for the sampling decisions and it is not exported as a span attribute.
+const attrSamplingDuration = attribute.Key("beyla.sampling.duration_ns")
+
+// minimum number of observations before the percentile estimation of an operation can be used
+const p2Markers = 5
+
+// slowTailSampler keeps the spans whose duration is above the configured percentile of the
+// durations observed for the same operation (service and span name, which includes the HTTP route). The
+// percentile is estimated adaptively from the stream of observed durations, so it keeps
+// roughly the slowest (1 - percentile) fraction of each operation without a fixed threshold.
+// Until an operation has enough observations to estimate the percentile, its spans are kept.
+type slowTailSampler struct {
+	percentile float64
+
+	mt         sync.Mutex
+	operations map[operation]*p2Quantile
+}
+
+// operation identifies the spans that share a latency distribution. The same span names from
+// different services are different operations.
+type operation struct {
+	namespace string
+	service   string
+	name      string
+}
+
+func newSlowTailSampler(percentile float64) *slowTailSampler {
+	return &slowTailSampler{
+		percentile: min(1, max(0, percentile)),
+		operations: map[operation]*p2Quantile{},
+	}
+}
+
+func (st *slowTailSampler) ShouldSample(p trace.SamplingParameters) trace.SamplingResult {
+	decision := trace.Drop
+	if duration, ok := samplingDuration(&p); ok && st.slow(samplingOperation(&p), float64(duration)) {
+		decision = trace.RecordAndSample
+	}
+	// all the slow spans are kept
+	return withProbability(trace.SamplingResult{
+		Decision:   decision,
+		Tracestate: trace2.SpanContextFromContext(p.ParentContext).TraceState(),
+	}, 1)
+}
+
+// slow returns whether the duration is above the estimated percentile of the operation,
+// and adds it to the estimation
+func (st *slowTailSampler) slow(op operation, duration float64) bool {
+	st.mt.Lock()
+	defer st.mt.Unlock()
+	estimator, ok := st.operations[op]
+	if !ok {
+		estimator = newP2Quantile(st.percentile)
+		st.operations[op] = estimator
+	}
+	slow := estimator.count < p2Markers || duration > estimator.quantile()
+	estimator.add(duration)
+	return slow
+}
+
+func (st *slowTailSampler) Description() string {
+	return "SlowTail{" + strconv.FormatFloat(st.percentile, 'f', -1, 64) + "}"
+}
+
+func samplingOperation(p *trace.SamplingParameters) operation {
+	op := operation{name: p.Name}
+	for _, a := range p.Attributes {
+		switch a.Key {
+		case semconv.ServiceNamespaceKey:
+			op.namespace = a.Value.AsString()
+		case semconv.ServiceNameKey:
+			op.service = a.Value.AsString()
+		}
+	}
+	return op
+}
+
+func samplingDuration(p *trace.SamplingParameters) (int64, bool) {
+	for _, a := range p.Attributes {
+		if a.Key == attrSamplingDuration {
+			return a.Value.AsInt64(), true
+		}
+	}
+	return 0, false
+}
+
+// p2Quantile estimates a quantile of a stream of values in constant memory, following the
+// P-square algorithm from R. Jain and I. Chlamtac (1985): it keeps five markers whose heights
+// approximate the minimum, the p/2, p and (1+p)/2 quantiles, and the maximum of the stream.
+// It is not safe for concurrent use.
+type p2Quantile struct {
+	count int
+	// heights of the markers
+	q [p2Markers]float64
+	// actual positions of the markers
+	n [p2Markers]float64
+	// desired positions of the markers, and their increments on each observation
+	np  [p2Markers]float64
+	dnp [p2Markers]float64
+}
+
+func newP2Quantile(p float64) *p2Quantile {
+	return &p2Quantile{
+		n:   [p2Markers]float64{1, 2, 3, 4, 5},
+		np:  [p2Markers]float64{1, 1 + 2*p, 1 + 4*p, 3 + 2*p, 5},
+		dnp: [p2Markers]float64{0, p / 2, p, (1 + p) / 2, 1},
+	}
+}
+
+func (e *p2Quantile) add(x float64) {
+	if e.count < p2Markers {
+		e.q[e.count] = x
+		e.count++
+		if e.count == p2Markers {
+			slices.Sort(e.q[:])
+		}
+		return
+	}
+	e.count++
+	// find the cell k where q[k] <= x < q[k+1], extending the extremes if needed
+	var k int
+	switch {
+	case x < e.q[0]:
+		e.q[0] = x
+	case x >= e.q[p2Markers-1]:
+		e.q[p2Markers-1] = x
+		k = p2Markers - 2
+	default:
+		for x >= e.q[k+1] {
+			k++
+		}
+	}
+	for i := k + 1; i < p2Markers; i++ {
+		e.n[i]++
+	}
+	for i := range e.np {
+		e.np[i] += e.dnp[i]
+	}
+	// adjust the heights of the middle markers if they are off their desired positions
+	for i := 1; i < p2Markers-1; i++ {
+		d := e.np[i] - e.n[i]
+		if (d >= 1 && e.n[i+1]-e.n[i] > 1) || (d <= -1 && e.n[i-1]-e.n[i] < -1) {
+			sign := 1.0
+			if d < 0 {
+				sign = -1
+			}
+			if q := e.parabolic(i, sign); e.q[i-1] < q && q < e.q[i+1] {
+				e.q[i] = q
+			} else {
+				e.q[i] = e.linear(i, sign)
+			}
+			e.n[i] += sign
+		}
+	}
+}
+
+func (e *p2Quantile) parabolic(i int, d float64) float64 {
+	return e.q[i] + d/(e.n[i+1]-e.n[i-1])*
+		((e.n[i]-e.n[i-1]+d)*(e.q[i+1]-e.q[i])/(e.n[i+1]-e.n[i])+
+			(e.n[i+1]-e.n[i]-d)*(e.q[i]-e.q[i-1])/(e.n[i]-e.n[i-1]))
+}
+
+func (e *p2Quantile) linear(i int, d float64) float64 {
+	j := i + int(d)
+	return e.q[i] + d*(e.q[j]-e.q[i])/(e.n[j]-e.n[i])
+}
+
+// quantile returns the current estimation. It requires at least p2Markers observations.
+func (e *p2Quantile) quantile() float64 {
+	return e.q[2]
+}
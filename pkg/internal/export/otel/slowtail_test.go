@@ -0,0 +1,117 @@
+package otel
+
+import (
+	"math/rand"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/beyla/pkg/internal/request"
+	"github.com/grafana/beyla/pkg/internal/svc"
+)
+
+func TestSlowTailSampler(t *testing.T) {
+	s := Sampler{Name: samplerSlowTail, Arg: "0.95"}
+	sampler, ok := s.Implementation().(*slowTailSampler)
+	require.True(t, ok)
+
+	rnd := rand.New(rand.NewSource(1234))
+	kept := map[string]int{}
+	const spansPerRoute = 20000
+	for i := 0; i < spansPerRoute; i++ {
+		// each route has a different latency distribution, so the percentiles are estimated separately
+		for route, scale := range map[string]time.Duration{"/fast": time.Millisecond, "/slow": time.Second} {
+			duration := int64(rnd.ExpFloat64() * float64(scale))
+			span := request.Span{Type: request.EventTypeHTTP, Method: "GET", Route: route, RequestStart: 1000, End: 1000 + duration}
+			if shouldSample(sampler, &span, nil) {
+				kept[route]++
+			}
+		}
+	}
+	for _, route := range []string{"/fast", "/slow"} {
+		assert.InDelta(t, 0.05, float64(kept[route])/spansPerRoute, 0.01, route)
+	}
+
+	// all the slow spans are kept
+	assert.Equal(t, samplingDecision{keep: true, probability: 1},
+		sampleSpan(sampler, &request.Span{Type: request.EventTypeHTTP, Route: "/fast", End: int64(time.Hour)}, nil))
+}
+
+func TestSlowTailSampler_Services(t *testing.T) {
+	sampler := newSlowTailSampler(0.95)
+	rnd := rand.New(rand.NewSource(1234))
+	kept := map[string]int{}
+	const spansPerService = 20000
+	for i := 0; i < spansPerService; i++ {
+		// the same route has a different latency distribution in each service
+		for service, scale := range map[string]time.Duration{"fast-svc": time.Millisecond, "slow-svc": time.Second} {
+			duration := int64(rnd.ExpFloat64() * float64(scale))
+			span := request.Span{Type: request.EventTypeHTTP, Method: "GET", Route: "/users",
+				RequestStart: 1000, End: 1000 + duration, ServiceID: svc.ID{Name: service}}
+			if shouldSample(sampler, &span, nil) {
+				kept[service]++
+			}
+		}
+	}
+	for _, service := range []string{"fast-svc", "slow-svc"} {
+		assert.InDelta(t, 0.05, float64(kept[service])/spansPerService, 0.01, service)
+	}
+}
+
+func TestSlowTailSampler_Concurrent(t *testing.T) {
+	sampler := newSlowTailSampler(0.9)
+	const goroutines, spans = 8, 5000
+	var kept sync.Map
+	wg := sync.WaitGroup{}
+	for g := 0; g < goroutines; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			rnd := rand.New(rand.NewSource(int64(g)))
+			count := 0
+			for i := 0; i < spans; i++ {
+				span := request.Span{Type: request.EventTypeHTTP, Method: "GET", Route: "/users",
+					End: int64(rnd.NormFloat64()*float64(10*time.Millisecond)) + int64(100*time.Millisecond)}
+				if shouldSample(sampler, &span, nil) {
+					count++
+				}
+			}
+			kept.Store(g, count)
+		}(g)
+	}
+	wg.Wait()
+	total := 0
+	kept.Range(func(_, v any) bool {
+		total += v.(int)
+		return true
+	})
+	assert.InDelta(t, 0.1, float64(total)/(goroutines*spans), 0.02)
+}
+
+func TestSlowTailSampler_Warmup(t *testing.T) {
+	sampler := newSlowTailSampler(0.99)
+	for i := 0; i < p2Markers; i++ {
+		assert.True(t, shouldSample(sampler, &request.Span{Type: request.EventTypeHTTP, Route: "/users", End: 10}, nil))
+	}
+	// the spans without duration are not kept
+	assert.False(t, shouldSample(sampler, &request.Span{Type: request.EventTypeHTTP, Route: "/users", RequestStart: 10}, nil))
+}
+
+func TestP2Quantile(t *testing.T) {
+	rnd := rand.New(rand.NewSource(42))
+	for _, p := range []float64{0.5, 0.9, 0.99} {
+		estimator := newP2Quantile(p)
+		values := make([]float64, 0, 50000)
+		for i := 0; i < cap(values); i++ {
+			v := rnd.Float64() * 1000
+			values = append(values, v)
+			estimator.add(v)
+		}
+		sort.Float64s(values)
+		assert.InDelta(t, values[int(p*float64(len(values)))], estimator.quantile(), 10, p)
+	}
+}
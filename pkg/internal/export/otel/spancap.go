@@ -0,0 +1,77 @@
+package otel
+
+import (
+	"sync/atomic"
+
+	lru "github.com/hashicorp/golang-lru/v2"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+	trace2 "go.opentelemetry.io/otel/trace"
+
+	attr "github.com/grafana/beyla/pkg/internal/export/attributes/names"
+	"github.com/grafana/beyla/pkg/internal/request"
+)
+
+// spansPerTraceCacheLen is the number of traces whose exported spans are accounted
+const spansPerTraceCacheLen = 1024
+
+// spansCap limits the number of spans that are exported for each trace. The local root spans
+// (the server spans, which are the entry point to the instrumented services) are always
+// exported, and they report the number of spans of their trace that were dropped before them.
+// The spans dropped after a root span is exported can't be reported by it. This happens when the
+// SamplingDecisionsCacheLen is set, because the spans of a batch are then exported parents first.
+// The accounting can be safely read from multiple goroutines, but it must be updated from a single one.
+type spansCap struct {
+	max    int
+	traces *lru.Cache[trace2.TraceID, *traceSpans]
+}
+
+type traceSpans struct {
+	exported int
+	dropped  atomic.Int64
+}
+
+func newSpansCap(maxSpans int) *spansCap {
+	traces, _ := lru.New[trace2.TraceID, *traceSpans](spansPerTraceCacheLen)
+	return &spansCap{max: maxSpans, traces: traces}
+}
+
+func isLocalRoot(span *request.Span) bool {
+	return !span.IsClientSpan()
+}
+
+// admit returns whether the span can be exported without exceeding the maximum spans for its trace
+func (sc *spansCap) admit(span *request.Span) bool {
+	if !span.TraceID.IsValid() {
+		// the span will get a random trace ID, so it will be the only span of its trace
+		return true
+	}
+	ts, ok := sc.traces.Get(span.TraceID)
+	if !ok {
+		ts = &traceSpans{}
+		sc.traces.Add(span.TraceID, ts)
+	}
+	ts.exported++
+	if ts.exported <= sc.max || isLocalRoot(span) {
+		return true
+	}
+	ts.exported--
+	ts.dropped.Add(1)
+	return false
+}
+
+// markDropped sets the beyla.spans_dropped attribute to the traces of a local root span, if
+// any span from its trace has been dropped. The attribute is set in the span of the request,
+// which is generated after its sub-spans.
+func (sc *spansCap) markDropped(span *request.Span, traces ptrace.Traces) {
+	if !isLocalRoot(span) || !span.TraceID.IsValid() {
+		return
+	}
+	ts, ok := sc.traces.Peek(span.TraceID)
+	if !ok {
+		return
+	}
+	if dropped := ts.dropped.Load(); dropped > 0 {
+		spans := traces.ResourceSpans().At(0).ScopeSpans().At(0).Spans()
+		spans.At(spans.Len()-1).Attributes().PutInt(string(attr.BeylaSpansDropped), dropped)
+	}
+}
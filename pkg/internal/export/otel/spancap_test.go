@@ -0,0 +1,80 @@
+package otel
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+	trace2 "go.opentelemetry.io/otel/trace"
+
+	attr "github.com/grafana/beyla/pkg/internal/export/attributes/names"
+	"github.com/grafana/beyla/pkg/internal/request"
+)
+
+func TestSpansCap(t *testing.T) {
+	sc := newSpansCap(3)
+	traceID, rootID := trace2.TraceID{1}, trace2.SpanID{1}
+
+	admitted := 0
+	for i := byte(2); i < 7; i++ {
+		if sc.admit(&request.Span{Type: request.EventTypeSQLClient, TraceID: traceID, SpanID: trace2.SpanID{i}, ParentSpanID: rootID}) {
+			admitted++
+		}
+	}
+	assert.Equal(t, 3, admitted)
+
+	// spans from other traces are not affected
+	assert.True(t, sc.admit(&request.Span{Type: request.EventTypeHTTPClient, TraceID: trace2.TraceID{2}}))
+	// spans without trace ID are not accounted
+	for i := 0; i < 5; i++ {
+		assert.True(t, sc.admit(&request.Span{Type: request.EventTypeHTTPClient}))
+	}
+
+	// the root span is exported, reporting the dropped spans
+	root := request.Span{Type: request.EventTypeHTTP, Method: "GET", TraceID: traceID, SpanID: rootID}
+	require.True(t, sc.admit(&root))
+	traces := GenerateTraces(&TracesConfig{}, &root, map[attr.Name]struct{}{})
+	sc.markDropped(&root, traces)
+	dropped, ok := traces.ResourceSpans().At(0).ScopeSpans().At(0).Spans().At(0).Attributes().Get(string(attr.BeylaSpansDropped))
+	require.True(t, ok)
+	assert.EqualValues(t, 2, dropped.Int())
+
+	// roots of traces without dropped spans are not marked
+	other := request.Span{Type: request.EventTypeHTTP, Method: "GET", TraceID: trace2.TraceID{2}}
+	traces = GenerateTraces(&TracesConfig{}, &other, map[attr.Name]struct{}{})
+	sc.markDropped(&other, traces)
+	ensureTraceAttrNotExists(t, traces.ResourceSpans().At(0).ScopeSpans().At(0).Spans().At(0).Attributes(),
+		attr.BeylaSpansDropped.OTEL())
+}
+
+func TestTracesReceiver_MaxSpansPerTrace_SubSpans(t *testing.T) {
+	tr, exp := batchingReceiver(t, TracesConfig{MaxSpansPerTrace: 2})
+	loop, err := tr.provideLoop()
+	require.NoError(t, err)
+
+	traceID, rootID := trace2.TraceID{1}, trace2.SpanID{1}
+	in := make(chan []request.Span, 1)
+	var spans []request.Span
+	for i := byte(2); i < 5; i++ {
+		spans = append(spans, request.Span{Type: request.EventTypeSQLClient, Method: "SELECT",
+			TraceID: traceID, SpanID: trace2.SpanID{i}, ParentSpanID: rootID, RequestStart: 100, Start: 100, End: 200})
+	}
+	// the root span is reported after its children, and it has "in queue" and "processing" sub-spans
+	spans = append(spans, request.Span{Type: request.EventTypeHTTP, Method: "GET", Path: "/", Status: 200,
+		TraceID: traceID, SpanID: rootID, RequestStart: 10, Start: 50, End: 300})
+	in <- spans
+	close(in)
+	loop(in)
+
+	require.Equal(t, []int{1, 1, 3}, exp.SpanCounts())
+	rootSpans := exp.batches[2].ResourceSpans().At(0).ScopeSpans().At(0).Spans()
+	for i := 0; i < rootSpans.Len()-1; i++ {
+		ensureTraceAttrNotExists(t, rootSpans.At(i).Attributes(), attr.BeylaSpansDropped.OTEL())
+	}
+	main := rootSpans.At(rootSpans.Len() - 1)
+	assert.Equal(t, ptrace.SpanKindServer, main.Kind())
+	dropped, ok := main.Attributes().Get(string(attr.BeylaSpansDropped))
+	require.True(t, ok)
+	assert.EqualValues(t, 1, dropped.Int())
+}
@@ -0,0 +1,65 @@
+package otel
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/grafana/beyla/pkg/internal/request"
+)
+
+// StatusRange defines an inclusive range of HTTP response status codes. When unmarshalled from text,
+// it accepts a single status code (e.g. 204) or a range of status codes (e.g. 200-299).
+type StatusRange struct {
+	Start int
+	End   int
+}
+
+var validStatusRange = regexp.MustCompile(`^\s*(\d{3})\s*(-\s*(\d{3})\s*)?$`)
+
+func (r *StatusRange) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind != yaml.ScalarNode {
+		return fmt.Errorf("StatusRange: unexpected YAML node kind %d", value.Kind)
+	}
+	return r.UnmarshalText([]byte(value.Value))
+}
+
+func (r *StatusRange) UnmarshalText(text []byte) error {
+	val := string(text)
+	groups := validStatusRange.FindStringSubmatch(val)
+	if groups == nil {
+		return fmt.Errorf("invalid status range %q. Must be a status code or a range of status codes (e.g. 200-299)", val)
+	}
+	// don't need to check integer parsing, as we already did it via regular expression
+	r.Start, _ = strconv.Atoi(groups[1])
+	r.End = r.Start
+	if groups[3] != "" {
+		r.End, _ = strconv.Atoi(groups[3])
+	}
+	if r.End < r.Start {
+		return fmt.Errorf("invalid status range %q: the end is lower than the start", strings.TrimSpace(val))
+	}
+	return nil
+}
+
+func (r *StatusRange) matches(status int) bool {
+	return r.Start <= status && status <= r.End
+}
+
+// droppedByStatus returns whether the HTTP span has to be dropped because its status code
+// is in any of the DropStatusRanges.
+func droppedByStatus(ranges []StatusRange, span *request.Span) bool {
+	if len(ranges) == 0 ||
+		(span.Type != request.EventTypeHTTP && span.Type != request.EventTypeHTTPClient) {
+		return false
+	}
+	for i := range ranges {
+		if ranges[i].matches(span.Status) {
+			return true
+		}
+	}
+	return false
+}
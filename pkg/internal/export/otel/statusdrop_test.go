@@ -0,0 +1,59 @@
+package otel
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+
+	"github.com/grafana/beyla/pkg/internal/export/attributes"
+	"github.com/grafana/beyla/pkg/internal/request"
+)
+
+func TestDropStatusRanges(t *testing.T) {
+	cfg := TracesConfig{}
+	require.NoError(t, yaml.Unmarshal([]byte(`
+drop_status_ranges: ["200-299", "304", "500 - 599"]
+always_sample_errors: true
+`), &cfg))
+	require.Equal(t, []StatusRange{{Start: 200, End: 299}, {Start: 304, End: 304}, {Start: 500, End: 599}},
+		cfg.DropStatusRanges)
+
+	tr := newTracesOTELReceiver(context.Background(), cfg, nil, attributes.Selection{})
+	in := make(chan []request.Span, 1)
+	in <- []request.Span{
+		{Type: request.EventTypeHTTP, Path: "/ok", Status: 200},
+		{Type: request.EventTypeHTTP, Path: "/not-found", Status: 404},
+		{Type: request.EventTypeHTTP, Path: "/not-modified", Status: 304},
+		{Type: request.EventTypeHTTP, Path: "/error", Status: 503},
+		{Type: request.EventTypeHTTPClient, Path: "/client-ok", Status: 204},
+		{Type: request.EventTypeGRPC, Path: "/grpc", Status: 200},
+	}
+	close(in)
+
+	var exported []string
+	tr.consume(in, func(s *request.Span) { exported = append(exported, s.Path) })
+	// error spans are kept, and the ranges don't apply to gRPC status codes
+	assert.Equal(t, []string{"/not-found", "/error", "/grpc"}, exported)
+
+	cfg.AlwaysSampleErrors = false
+	tr = newTracesOTELReceiver(context.Background(), cfg, nil, attributes.Selection{})
+	in = make(chan []request.Span, 1)
+	in <- []request.Span{
+		{Type: request.EventTypeHTTP, Path: "/not-found", Status: 404},
+		{Type: request.EventTypeHTTP, Path: "/error", Status: 503},
+	}
+	close(in)
+	exported = nil
+	tr.consume(in, func(s *request.Span) { exported = append(exported, s.Path) })
+	assert.Equal(t, []string{"/not-found"}, exported)
+}
+
+func TestStatusRange_Invalid(t *testing.T) {
+	for _, val := range []string{"", "2xx", "200-", "299-200", "20-29", "200,299"} {
+		sr := StatusRange{}
+		assert.Errorf(t, sr.UnmarshalText([]byte(val)), "expected error for %q", val)
+	}
+}
@@ -0,0 +1,42 @@
+package otel
+
+import (
+	"go.opentelemetry.io/collector/pdata/ptrace"
+)
+
+// names of the internal sub-spans of a request
+const (
+	subSpanInQueue    = "in queue"
+	subSpanProcessing = "processing"
+)
+
+// subSpansByImportance sorts the internal sub-spans from the most to the least important.
+// The processing sub-span goes first because it might carry the span ID that is propagated
+// to the downstream services.
+var subSpansByImportance = []string{subSpanProcessing, subSpanInQueue}
+
+// capSubSpans removes the least important sub-spans of the scope, until there are no
+// more than maxSubSpans. It must be invoked before the parent span is added to the scope.
+func capSubSpans(ss *ptrace.ScopeSpans, maxSubSpans int) {
+	spans := ss.Spans()
+	if spans.Len() <= maxSubSpans {
+		return
+	}
+	present := make(map[string]struct{}, spans.Len())
+	for i := 0; i < spans.Len(); i++ {
+		present[spans.At(i).Name()] = struct{}{}
+	}
+	keep := make(map[string]struct{}, maxSubSpans)
+	for _, name := range subSpansByImportance {
+		if len(keep) == maxSubSpans {
+			break
+		}
+		if _, ok := present[name]; ok {
+			keep[name] = struct{}{}
+		}
+	}
+	spans.RemoveIf(func(sp ptrace.Span) bool {
+		_, ok := keep[sp.Name()]
+		return !ok
+	})
+}
@@ -0,0 +1,98 @@
+package otel
+
+import (
+	"fmt"
+
+	lru "github.com/hashicorp/golang-lru/v2"
+
+	"github.com/grafana/beyla/pkg/internal/request"
+	"github.com/grafana/beyla/pkg/internal/svc"
+)
+
+// Accepted values for the TracesConfig.ServiceIDConflicts option
+const (
+	ServiceIDConflictsKeepFirst = "keep_first"
+	ServiceIDConflictsKeepLast  = "keep_last"
+)
+
+func validateServiceIDConflicts(policy string) error {
+	switch policy {
+	case "", ServiceIDConflictsKeepFirst, ServiceIDConflictsKeepLast:
+		return nil
+	}
+	return fmt.Errorf("invalid value for service_id_conflicts %q. Accepted values: %s, %s",
+		policy, ServiceIDConflictsKeepFirst, ServiceIDConflictsKeepLast)
+}
+
+// connIdentitiesCacheLen is the number of recent connections whose service identity is remembered
+const connIdentitiesCacheLen = 4096
+
+// connection identifies the spans of a long-lived connection by the process and the addresses
+// and ports of both ends
+type connection struct {
+	pid      uint32
+	peer     string
+	peerPort int
+	host     string
+	hostPort int
+}
+
+// connIdentities remembers the service identity of the recent connections, to detect the spans whose
+// ServiceID was re-resolved while the connection was alive (e.g. between the start and the end
+// of a request). It must be invoked from a single goroutine.
+type connIdentities struct {
+	keepFirst  bool
+	identities *lru.Cache[connection, svc.ID]
+}
+
+func newConnIdentities(policy string) *connIdentities {
+	identities, _ := lru.New[connection, svc.ID](connIdentitiesCacheLen)
+	return &connIdentities{keepFirst: policy == ServiceIDConflictsKeepFirst, identities: identities}
+}
+
+func sameServiceIdentity(a, b *svc.ID) bool {
+	return a.UID == b.UID && a.Name == b.Name && a.Namespace == b.Namespace
+}
+
+// decorated returns whether the service identity has been completed with the metadata
+// of the orchestrator (e.g. by the Kubernetes decorator)
+func decorated(id *svc.ID) bool {
+	return len(id.Metadata) > 0
+}
+
+// resolve returns whether the ServiceID of the span conflicts with the identity previously seen for its
+// connection. On conflict, the span gets the first-seen identity with the keep_first policy,
+// while the keep_last policy keeps the span identity and remembers it for the next spans.
+// A decorated identity replaces an undecorated one without being considered a conflict, as
+// the first spans of a connection might have been resolved before the decoration was available.
+func (ci *connIdentities) resolve(span *request.Span) bool {
+	if span.PeerPort == 0 || span.HostPort == 0 {
+		// the span can't be correlated to a connection
+		return false
+	}
+	conn := connection{pid: span.Pid.HostPID,
+		peer: span.Peer, peerPort: span.PeerPort,
+		host: span.Host, hostPort: span.HostPort}
+	known, ok := ci.identities.Get(conn)
+	if !ok {
+		ci.identities.Add(conn, span.ServiceID)
+		return false
+	}
+	if sameServiceIdentity(&known, &span.ServiceID) {
+		return false
+	}
+	if !decorated(&known) && decorated(&span.ServiceID) {
+		ci.identities.Add(conn, span.ServiceID)
+		return false
+	}
+	tlog().Debug("service identity changed for the same connection",
+		"pid", conn.pid, "peer", conn.peer, "peerPort", conn.peerPort,
+		"host", conn.host, "hostPort", conn.hostPort,
+		"first", known.String(), "current", span.ServiceID.String(), "keepFirst", ci.keepFirst)
+	if ci.keepFirst {
+		span.ServiceID = known
+	} else {
+		ci.identities.Add(conn, span.ServiceID)
+	}
+	return true
+}
@@ -0,0 +1,107 @@
+package otel
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	attr "github.com/grafana/beyla/pkg/internal/export/attributes/names"
+	"github.com/grafana/beyla/pkg/internal/imetrics"
+	"github.com/grafana/beyla/pkg/internal/pipe/global"
+	"github.com/grafana/beyla/pkg/internal/request"
+	"github.com/grafana/beyla/pkg/internal/svc"
+)
+
+type fakeServiceIDConflicts struct {
+	imetrics.NoopReporter
+	conflicts atomic.Int32
+}
+
+func (f *fakeServiceIDConflicts) OTELTraceServiceIDConflict() {
+	f.conflicts.Add(1)
+}
+
+func TestTracesReceiver_ServiceIDConflicts(t *testing.T) {
+	connSpan := func(path, service string) request.Span {
+		return request.Span{
+			Type: request.EventTypeHTTP, Path: path, Pid: request.PidInfo{HostPID: 123},
+			Peer: "10.0.0.1", PeerPort: 34567, Host: "10.0.0.2", HostPort: 8080,
+			ServiceID: svc.ID{UID: svc.UID("uid-" + service), Name: service},
+		}
+	}
+	// the identity of the connection is re-resolved after the first request
+	spans := []request.Span{
+		connSpan("/first", "old-name"),
+		connSpan("/second", "new-name"),
+		connSpan("/third", "new-name"),
+		{Type: request.EventTypeHTTP, Path: "/other-conn", Pid: request.PidInfo{HostPID: 123},
+			Peer: "10.0.0.3", PeerPort: 34567, Host: "10.0.0.2", HostPort: 8080, ServiceID: svc.ID{Name: "new-name"}},
+		// another client from the same peer address uses a different connection
+		{Type: request.EventTypeHTTP, Path: "/other-port", Pid: request.PidInfo{HostPID: 123},
+			Peer: "10.0.0.1", PeerPort: 45678, Host: "10.0.0.2", HostPort: 8080, ServiceID: svc.ID{Name: "new-name"}},
+	}
+	consume := func(policy string) ([]string, int32) {
+		metrics := &fakeServiceIDConflicts{}
+		tr := newTracesOTELReceiver(context.Background(), TracesConfig{ServiceIDConflicts: policy},
+			&global.ContextInfo{Metrics: metrics}, nil)
+		in := make(chan []request.Span, 1)
+		batch := make([]request.Span, len(spans))
+		copy(batch, spans)
+		in <- batch
+		close(in)
+		var services []string
+		tr.consume(in, func(s *request.Span) { services = append(services, s.ServiceID.Name) })
+		return services, metrics.conflicts.Load()
+	}
+
+	services, conflicts := consume(ServiceIDConflictsKeepFirst)
+	assert.Equal(t, []string{"old-name", "old-name", "old-name", "new-name", "new-name"}, services)
+	assert.EqualValues(t, 2, conflicts)
+
+	services, conflicts = consume(ServiceIDConflictsKeepLast)
+	assert.Equal(t, []string{"old-name", "new-name", "new-name", "new-name", "new-name"}, services)
+	assert.EqualValues(t, 1, conflicts)
+
+	// without conflicts handling, the spans keep their identity
+	services, conflicts = consume("")
+	assert.Equal(t, []string{"old-name", "new-name", "new-name", "new-name", "new-name"}, services)
+	assert.Zero(t, conflicts)
+}
+
+func TestTracesReceiver_ServiceIDConflicts_Decoration(t *testing.T) {
+	connSpan := func(path string, id svc.ID) request.Span {
+		return request.Span{
+			Type: request.EventTypeHTTP, Path: path, Pid: request.PidInfo{HostPID: 123},
+			Peer: "10.0.0.1", PeerPort: 34567, Host: "10.0.0.2", HostPort: 8080, ServiceID: id,
+		}
+	}
+	undecorated := svc.ID{UID: "uid-1", Name: "server", AutoName: true}
+	decorated := svc.ID{UID: "uid-1", Name: "frontend", Namespace: "shop",
+		Metadata: map[attr.Name]string{attr.K8sPodName: "frontend-1234"}}
+	metrics := &fakeServiceIDConflicts{}
+	tr := newTracesOTELReceiver(context.Background(),
+		TracesConfig{ServiceIDConflicts: ServiceIDConflictsKeepFirst},
+		&global.ContextInfo{Metrics: metrics}, nil)
+	in := make(chan []request.Span, 1)
+	// the first span is seen before the Kubernetes metadata is available
+	in <- []request.Span{
+		connSpan("/first", undecorated),
+		connSpan("/second", decorated),
+		connSpan("/third", undecorated),
+	}
+	close(in)
+	var services []string
+	tr.consume(in, func(s *request.Span) { services = append(services, s.ServiceID.Name) })
+
+	assert.Equal(t, []string{"server", "frontend", "frontend"}, services)
+	assert.EqualValues(t, 1, metrics.conflicts.Load())
+}
+
+func TestServiceIDConflicts_Validate(t *testing.T) {
+	for _, policy := range []string{"", ServiceIDConflictsKeepFirst, ServiceIDConflictsKeepLast} {
+		assert.NoError(t, (&TracesConfig{ServiceIDConflicts: policy}).Validate(), policy)
+	}
+	assert.Error(t, (&TracesConfig{ServiceIDConflicts: "keep_all"}).Validate())
+}
@@ -0,0 +1,47 @@
+package otel
+
+import (
+	"strings"
+
+	"github.com/grafana/beyla/pkg/internal/request"
+	"github.com/grafana/beyla/pkg/services"
+)
+
+const defaultSyntheticHeader = "user-agent"
+
+// SyntheticMatcher identifies the synthetic requests by the value of one of their headers
+// (or gRPC metadata keys)
+type SyntheticMatcher struct {
+	// Header name, case-insensitive. Defaults to User-Agent.
+	Header string `yaml:"header"`
+	// Value is a regular expression that needs to match any of the values of the header.
+	// If unset, it matches any request carrying the header.
+	Value services.RegexpAttr `yaml:"value"`
+}
+
+func (m *SyntheticMatcher) matches(span *request.Span) bool {
+	header := defaultSyntheticHeader
+	if m.Header != "" {
+		header = strings.ToLower(m.Header)
+	}
+	for _, value := range span.RequestHeaders[header] {
+		if m.Value.MatchString(value) {
+			return true
+		}
+	}
+	return false
+}
+
+// isSynthetic returns whether a server span has been generated by a request that
+// matches any of the provided matchers
+func isSynthetic(matchers []SyntheticMatcher, span *request.Span) bool {
+	if span.Type != request.EventTypeHTTP && span.Type != request.EventTypeGRPC {
+		return false
+	}
+	for i := range matchers {
+		if matchers[i].matches(span) {
+			return true
+		}
+	}
+	return false
+}
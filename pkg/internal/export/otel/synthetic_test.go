@@ -0,0 +1,56 @@
+package otel
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+
+	attr "github.com/grafana/beyla/pkg/internal/export/attributes/names"
+	"github.com/grafana/beyla/pkg/internal/request"
+)
+
+func TestSyntheticMatchers(t *testing.T) {
+	cfg := TracesConfig{}
+	require.NoError(t, yaml.Unmarshal([]byte(`
+synthetic_matchers:
+  - value: "^SyntheticMonitor/"
+  - header: X-Probe
+`), &cfg))
+
+	for _, tc := range []struct {
+		name      string
+		span      request.Span
+		synthetic bool
+	}{{
+		name:      "matching user agent",
+		span:      request.Span{Type: request.EventTypeHTTP, RequestHeaders: map[string][]string{"user-agent": {"SyntheticMonitor/1.2"}}},
+		synthetic: true,
+	}, {
+		name:      "matching header presence",
+		span:      request.Span{Type: request.EventTypeGRPC, RequestHeaders: map[string][]string{"x-probe": {"anything"}}},
+		synthetic: true,
+	}, {
+		name: "non-matching user agent",
+		span: request.Span{Type: request.EventTypeHTTP, RequestHeaders: map[string][]string{"user-agent": {"curl/8.0 SyntheticMonitor/1.2"}}},
+	}, {
+		name: "no headers",
+		span: request.Span{Type: request.EventTypeHTTP},
+	}, {
+		name: "client spans are not marked",
+		span: request.Span{Type: request.EventTypeHTTPClient, RequestHeaders: map[string][]string{"x-probe": {"true"}}},
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			traces := GenerateTraces(&cfg, &tc.span, map[attr.Name]struct{}{})
+			attrs := traces.ResourceSpans().At(0).ScopeSpans().At(0).Spans().At(0).Attributes()
+			if tc.synthetic {
+				synthetic, ok := attrs.Get(string(attr.BeylaSynthetic))
+				require.True(t, ok)
+				assert.True(t, synthetic.Bool())
+			} else {
+				ensureTraceAttrNotExists(t, attrs, attr.BeylaSynthetic.OTEL())
+			}
+		})
+	}
+}
@@ -0,0 +1,79 @@
+package otel
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/grafana/beyla/pkg/internal/request"
+)
+
+// Accepted values for the TracesConfig.InvalidTimestamps option
+const (
+	InvalidTimestampsDrop  = "drop"
+	InvalidTimestampsClamp = "clamp"
+)
+
+func validateInvalidTimestamps(action string) error {
+	switch action {
+	case "", InvalidTimestampsDrop, InvalidTimestampsClamp:
+		return nil
+	}
+	return fmt.Errorf("invalid value for invalid_timestamps %q. Accepted values: %s, %s",
+		action, InvalidTimestampsDrop, InvalidTimestampsClamp)
+}
+
+// minSpanDuration is the duration given to the spans whose end timestamp is clamped
+const minSpanDuration = time.Microsecond
+
+// invalidTimestamps returns the reason why the timestamps of a span are invalid, or nil if they are valid
+func invalidTimestamps(span *request.Span) error {
+	switch {
+	case span.Start <= 0:
+		return errors.New("zero start timestamp")
+	case span.End <= 0:
+		return errors.New("zero end timestamp")
+	case span.End < span.Start:
+		return errors.New("end timestamp is before start timestamp")
+	}
+	return nil
+}
+
+// clampTimestamps returns a copy of the span with repaired timestamps, or false if they can't be repaired.
+// A missing start is taken from the request start, and a missing or wrong end is clamped to the start,
+// plus a minimal duration.
+func clampTimestamps(span *request.Span) (request.Span, bool) {
+	fixed := *span
+	if fixed.Start <= 0 {
+		fixed.Start = fixed.RequestStart
+	}
+	if fixed.Start <= 0 {
+		return fixed, false
+	}
+	if fixed.RequestStart <= 0 || fixed.RequestStart > fixed.Start {
+		fixed.RequestStart = fixed.Start
+	}
+	if fixed.End < fixed.Start {
+		fixed.End = fixed.Start + int64(minSpanDuration)
+	}
+	return fixed, true
+}
+
+// checkTimestamps returns the provided span if its timestamps are valid, a repaired copy of it,
+// or nil if the span needs to be dropped.
+func (tr *tracesOTELReceiver) checkTimestamps(span *request.Span) *request.Span {
+	issue := invalidTimestamps(span)
+	if issue == nil {
+		return span
+	}
+	if tr.cfg.InvalidTimestamps == InvalidTimestampsClamp {
+		if fixed, ok := clampTimestamps(span); ok {
+			tr.warnLog.Warn("clamping timestamps of invalid span", issue)
+			tr.internalMetrics().OTELTraceInvalidTimestamps(InvalidTimestampsClamp)
+			return &fixed
+		}
+	}
+	tr.warnLog.Warn("dropping span with invalid timestamps", issue)
+	tr.internalMetrics().OTELTraceInvalidTimestamps(InvalidTimestampsDrop)
+	return nil
+}
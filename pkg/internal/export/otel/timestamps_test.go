@@ -0,0 +1,89 @@
+package otel
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/beyla/pkg/internal/imetrics"
+	"github.com/grafana/beyla/pkg/internal/pipe/global"
+	"github.com/grafana/beyla/pkg/internal/request"
+)
+
+type fakeInvalidTimestamps struct {
+	imetrics.NoopReporter
+	actions map[string]int
+}
+
+func (f *fakeInvalidTimestamps) OTELTraceInvalidTimestamps(action string) {
+	if f.actions == nil {
+		f.actions = map[string]int{}
+	}
+	f.actions[action]++
+}
+
+func consumeWithTimestampsCheck(t *testing.T, action string, spans ...request.Span) ([]request.Span, map[string]int) {
+	metrics := &fakeInvalidTimestamps{}
+	tr := newTracesOTELReceiver(context.Background(), TracesConfig{InvalidTimestamps: action},
+		&global.ContextInfo{Metrics: metrics}, nil)
+	in := make(chan []request.Span, 1)
+	in <- spans
+	close(in)
+	var exported []request.Span
+	tr.consume(in, func(s *request.Span) { exported = append(exported, *s) })
+	return exported, metrics.actions
+}
+
+func TestInvalidTimestamps_Drop(t *testing.T) {
+	exported, actions := consumeWithTimestampsCheck(t, InvalidTimestampsDrop,
+		request.Span{Path: "/valid", RequestStart: 100, Start: 100, End: 200},
+		request.Span{Path: "/zero-end", RequestStart: 100, Start: 100},
+		request.Span{Path: "/zero-start", End: 200},
+		request.Span{Path: "/end-before-start", RequestStart: 100, Start: 300, End: 200},
+	)
+	require.Len(t, exported, 1)
+	assert.Equal(t, "/valid", exported[0].Path)
+	assert.Equal(t, map[string]int{InvalidTimestampsDrop: 3}, actions)
+}
+
+func TestInvalidTimestamps_Clamp(t *testing.T) {
+	zeroEnd := request.Span{Path: "/zero-end", RequestStart: 100, Start: 150}
+	exported, actions := consumeWithTimestampsCheck(t, InvalidTimestampsClamp,
+		zeroEnd,
+		request.Span{Path: "/zero-start", RequestStart: 100, End: 200},
+		// can't be repaired
+		request.Span{Path: "/zero-start-unrepairable", End: 200},
+	)
+	require.Len(t, exported, 2)
+
+	assert.Equal(t, "/zero-end", exported[0].Path)
+	assert.EqualValues(t, 100, exported[0].RequestStart)
+	assert.EqualValues(t, 150, exported[0].Start)
+	assert.EqualValues(t, 150+int64(minSpanDuration), exported[0].End)
+	// the original span is not modified
+	assert.Zero(t, zeroEnd.End)
+
+	assert.Equal(t, "/zero-start", exported[1].Path)
+	assert.EqualValues(t, 100, exported[1].RequestStart)
+	assert.EqualValues(t, 100, exported[1].Start)
+	assert.EqualValues(t, 200, exported[1].End)
+
+	assert.Equal(t, map[string]int{InvalidTimestampsClamp: 2, InvalidTimestampsDrop: 1}, actions)
+}
+
+func TestInvalidTimestamps_Disabled(t *testing.T) {
+	exported, actions := consumeWithTimestampsCheck(t, "",
+		request.Span{Path: "/zero-end", RequestStart: 100, Start: 100},
+	)
+	assert.Len(t, exported, 1)
+	assert.Empty(t, actions)
+}
+
+func TestInvalidTimestamps_Validate(t *testing.T) {
+	for _, action := range []string{"", InvalidTimestampsDrop, InvalidTimestampsClamp} {
+		assert.NoError(t, (&TracesConfig{InvalidTimestamps: action}).Validate(), action)
+	}
+	assert.Error(t, (&TracesConfig{InvalidTimestamps: "repair"}).Validate())
+}
@@ -2,12 +2,18 @@ package otel
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"log/slog"
+	"net"
 	"net/url"
 	"os"
+	"slices"
 	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/mariomac/pipes/pipe"
 	"go.opentelemetry.io/collector/component"
@@ -37,6 +43,8 @@ import (
 	"github.com/grafana/beyla/pkg/internal/imetrics"
 	"github.com/grafana/beyla/pkg/internal/pipe/global"
 	"github.com/grafana/beyla/pkg/internal/request"
+	"github.com/grafana/beyla/pkg/internal/sqlprune"
+	"github.com/grafana/beyla/pkg/internal/svc"
 )
 
 func tlog() *slog.Logger {
@@ -52,11 +60,236 @@ type TracesConfig struct {
 	Protocol       Protocol `yaml:"protocol" env:"OTEL_EXPORTER_OTLP_PROTOCOL"`
 	TracesProtocol Protocol `yaml:"-" env:"OTEL_EXPORTER_OTLP_TRACES_PROTOCOL"`
 
+	// DefaultProtocol is used when no protocol is set and it can't be guessed from the usual
+	// OTLP ports of the endpoint. Defaults to http/protobuf, as the OTEL specification defines.
+	DefaultProtocol Protocol `yaml:"default_protocol" env:"BEYLA_OTLP_TRACES_DEFAULT_PROTOCOL"`
+
+	// EndpointPriority sets which endpoint is used when it is configured from multiple sources: "traces"
+	// (TracesEndpoint), "common" (CommonEndpoint) and "grafana" (Grafana Cloud OTLP gateway). The omitted
+	// sources keep their default order, which is traces, common, grafana.
+	EndpointPriority []EndpointSource `yaml:"endpoint_priority" env:"BEYLA_OTLP_TRACES_ENDPOINT_PRIORITY" envSeparator:","`
+
+	// IPFamily constrains the addresses that the gRPC exporter connects to, when the endpoint host
+	// resolves to both IPv4 and IPv6 addresses. Accepted values: auto (default), ipv4 and ipv6.
+	// It is ignored by the HTTP exporters.
+	IPFamily string `yaml:"ip_family" env:"BEYLA_OTLP_TRACES_IP_FAMILY"`
+
+	// Protocols, if set, overrides the Protocol properties and sends the traces to the same
+	// endpoint host through each of the listed protocols (e.g. grpc and http/protobuf). The endpoint
+	// port is adjusted to each protocol when it is one of the standard 4317 or 4318 ports.
+	Protocols []Protocol `yaml:"protocols" env:"BEYLA_OTLP_TRACES_PROTOCOLS" envSeparator:","`
+
+	// FilePath is the path of the file where the traces are written when the "file" protocol is used,
+	// for environments that can't reach a collector. The file is rotated when it would exceed FileMaxBytes
+	// (100MB by default), keeping up to FileMaxBackups previous files.
+	FilePath       string `yaml:"file_path" env:"BEYLA_OTLP_TRACES_FILE_PATH"`
+	FileMaxBytes   int64  `yaml:"file_max_bytes" env:"BEYLA_OTLP_TRACES_FILE_MAX_BYTES"`
+	FileMaxBackups int    `yaml:"file_max_backups" env:"BEYLA_OTLP_TRACES_FILE_MAX_BACKUPS"`
+
+	// TracesURLPath overrides the default /v1/traces path that is appended to the CommonEndpoint
+	// in HTTP protocol, for receivers that expose OTLP in a non-standard path. It must start with /.
+	TracesURLPath string `yaml:"traces_url_path" env:"BEYLA_OTLP_TRACES_URL_PATH"`
+
 	// InsecureSkipVerify is not standard, so we don't follow the same naming convention
 	InsecureSkipVerify bool `yaml:"insecure_skip_verify" env:"BEYLA_OTEL_INSECURE_SKIP_VERIFY"`
 
+	// Sampler decides which spans are exported. If no sampler name is explicitly configured,
+	// all the spans are exported, regardless of the sampling flags of their parent.
 	Sampler Sampler `yaml:"sampler"`
 
+	// MaxSubSpans, if set, limits the number of internal sub-spans that are created for each request.
+	// Beyond the limit, the least important sub-spans are dropped, starting from the "in queue" sub-span.
+	// Zero (default) means unlimited.
+	MaxSubSpans int `yaml:"max_sub_spans" env:"BEYLA_OTLP_TRACES_MAX_SUB_SPANS"`
+
+	// HashEndUserID replaces the value of the enduser.id attribute by its SHA-256 hash, so traces
+	// from the same user can be correlated without disclosing their identity.
+	HashEndUserID bool `yaml:"hash_enduser_id" env:"BEYLA_OTLP_TRACES_HASH_ENDUSER_ID"`
+
+	// ResourceSchemaURL overrides the schema URL of the traces resource, which defaults to the
+	// URL of the semantic conventions version used by Beyla.
+	ResourceSchemaURL string `yaml:"resource_schema_url" env:"BEYLA_OTLP_TRACES_RESOURCE_SCHEMA_URL"`
+
+	// EmitDistroAttributes adds the telemetry.distro.name and telemetry.distro.version
+	// attributes to the traces resource, so the Beyla version that produced a trace is known.
+	EmitDistroAttributes bool `yaml:"emit_distro_attributes" env:"BEYLA_OTLP_TRACES_EMIT_DISTRO_ATTRIBUTES"`
+
+	// EmitContainerImageAttrs adds the container.image.name and container.image.tag attributes to the
+	// traces resource, when the image of the container running the service is known. The images pinned
+	// by digest report the digest as tag.
+	EmitContainerImageAttrs bool `yaml:"emit_container_image_attributes" env:"BEYLA_OTLP_TRACES_EMIT_CONTAINER_IMAGE_ATTRIBUTES"`
+
+	// EmitNodeName adds the k8s.node.name attribute to the traces resource, with the name of the node where
+	// Beyla runs. It is taken from the K8S_NODE_NAME environment variable (e.g. populated from the Kubernetes
+	// downward API), falling back to the hostname.
+	EmitNodeName bool `yaml:"emit_node_name" env:"BEYLA_OTLP_TRACES_EMIT_NODE_NAME"`
+
+	// EmitConfigFingerprint adds the beyla.config.fingerprint attribute to the traces resource, so it can
+	// be verified that all the Beyla instances run the intended configuration.
+	EmitConfigFingerprint bool `yaml:"emit_config_fingerprint" env:"BEYLA_OTLP_TRACES_EMIT_CONFIG_FINGERPRINT"`
+	// ConfigFingerprint is a hash of the effective Beyla configuration. It is computed at startup,
+	// when EmitConfigFingerprint is enabled.
+	ConfigFingerprint string `yaml:"-" env:"-"`
+
+	// EmitProcessAttributes adds the process.command_line and process.executable.name attributes
+	// to the traces resource, when the information of the instrumented process is known.
+	// The values of the command line flags listed in RedactCommandLineFlags are redacted.
+	EmitProcessAttributes bool `yaml:"emit_process_attributes" env:"BEYLA_OTLP_TRACES_EMIT_PROCESS_ATTRIBUTES"`
+	// RedactCommandLineFlags lists the case-insensitive substrings of the command line flag names
+	// whose values are redacted. Defaults to password, passwd, secret, token, key and credential.
+	RedactCommandLineFlags []string `yaml:"redact_command_line_flags" env:"BEYLA_OTLP_TRACES_REDACT_COMMAND_LINE_FLAGS" envSeparator:","`
+
+	// CaptureGRPCMetadata lists the request metadata keys that are added to the gRPC spans,
+	// when captured, as rpc.grpc.request.metadata.<key> attributes.
+	CaptureGRPCMetadata []string `yaml:"capture_grpc_metadata" env:"BEYLA_OTLP_TRACES_CAPTURE_GRPC_METADATA" envSeparator:","`
+
+	// RequireAttribute, if set, restricts the exported spans to those whose request carries all the
+	// provided header (or gRPC metadata) values, e.g. X-Debug: true. Header names are case-insensitive.
+	RequireAttribute map[string]string `yaml:"require_attribute"`
+
+	// DerivedAttributes adds span attributes whose values are rendered from Go templates, evaluated against
+	// the captured headers (e.g. {{.Header "x-org"}}-{{.Header "x-env"}}) or the span fields (e.g. {{.Span.Route}}).
+	DerivedAttributes map[string]string `yaml:"derived_attributes"`
+	// DerivedAttributesAllowMissing renders the missing headers of the DerivedAttributes as empty strings.
+	// Otherwise, the attributes referencing any header that wasn't captured are omitted.
+	DerivedAttributesAllowMissing bool `yaml:"derived_attributes_allow_missing" env:"BEYLA_OTLP_TRACES_DERIVED_ATTRIBUTES_ALLOW_MISSING"`
+
+	// SyntheticMatchers identify the synthetic (e.g. monitoring probes) requests by their headers.
+	// The server spans of the matching requests are marked with the beyla.synthetic=true attribute.
+	SyntheticMatchers []SyntheticMatcher `yaml:"synthetic_matchers"`
+
+	// MaxSpansPerTrace, if set, limits the number of spans that are exported for each trace. The spans
+	// exceeding it are dropped, and the server spans of the trace report the number of dropped spans
+	// in the beyla.spans_dropped attribute. Only the spans that were dropped before the server span
+	// was exported are reported. If SamplingDecisionsCacheLen is set, the server spans are exported
+	// before the rest of spans of the same batch, so their dropped children are not reported.
+	MaxSpansPerTrace int `yaml:"max_spans_per_trace" env:"BEYLA_OTLP_TRACES_MAX_SPANS_PER_TRACE"`
+
+	// KnownHTTPMethods extends the standard HTTP methods (e.g. with WebDAV methods such as PROPFIND) that are
+	// reported as they are in the http.request.method attribute of both the traces and the metrics. The rest of
+	// methods are reported as _OTHER, and the original method is reported in the http.request.method_original
+	// attribute of the spans. The span names replace the unknown methods by HTTP.
+	KnownHTTPMethods request.HTTPMethods `yaml:"known_http_methods" env:"BEYLA_OTLP_TRACES_KNOWN_HTTP_METHODS" envSeparator:","`
+
+	// RequestLineMaxLength truncates the beyla.http.request_line attribute, when selected, to the given
+	// length in bytes. Defaults to 1024.
+	RequestLineMaxLength int `yaml:"request_line_max_length" env:"BEYLA_OTLP_TRACES_REQUEST_LINE_MAX_LENGTH"`
+	// RedactQueryParams lists the case-insensitive substrings of the query parameter names whose values
+	// are redacted from the beyla.http.request_line attribute. Defaults to password, passwd, secret,
+	// token, key and credential.
+	RedactQueryParams []string `yaml:"redact_query_params" env:"BEYLA_OTLP_TRACES_REDACT_QUERY_PARAMS" envSeparator:","`
+
+	// MaxSpanNameLength, if set, truncates the span names (e.g. long SQL queries or URLs) that exceed the
+	// given length in bytes, ending them with an ellipsis.
+	MaxSpanNameLength int `yaml:"max_span_name_length" env:"BEYLA_OTLP_TRACES_MAX_SPAN_NAME_LENGTH"`
+
+	// MaxSpanBytes, if set, removes the largest optional attributes (e.g. db.statement or the captured gRPC
+	// metadata) of the spans whose estimated serialized size exceeds it, marking them with beyla.span_trimmed.
+	MaxSpanBytes int `yaml:"max_span_bytes" env:"BEYLA_OTLP_TRACES_MAX_SPAN_BYTES"`
+
+	// MaxSpanAttributes, if set, limits the number of attributes of each span, as many backends reject the
+	// spans exceeding a given count. Zero (default) means unlimited. It can be set with the standard
+	// OTEL_SPAN_ATTRIBUTE_COUNT_LIMIT variable, but the default limit of 128 attributes of the OpenTelemetry
+	// SDK doesn't apply when it is unset.
+	MaxSpanAttributes int `yaml:"max_span_attributes" env:"OTEL_SPAN_ATTRIBUTE_COUNT_LIMIT"`
+	// AttributeOverflowPolicy specifies what to do with the spans exceeding MaxSpanAttributes: "drop_extra"
+	// (default) drops the Beyla-specific attributes before the rest, "truncate_to_limit" drops the last
+	// attributes, and "reject_span" does not export the span.
+	AttributeOverflowPolicy string `yaml:"attribute_overflow_policy" env:"BEYLA_OTLP_TRACES_ATTRIBUTE_OVERFLOW_POLICY"`
+
+	// RequestSizeBuckets, if set, are the ascending limits, in bytes, of the buckets that replace the exact
+	// HTTP request and response body sizes by the beyla.request_size_bucket and beyla.response_size_bucket
+	// attributes (e.g. <1k, 1k-10k or >=10k for the 1000 and 10000 limits), to keep a low cardinality.
+	RequestSizeBuckets []int `yaml:"request_size_buckets" env:"BEYLA_OTLP_TRACES_REQUEST_SIZE_BUCKETS" envSeparator:","`
+
+	// AttributeRenames maps the names of the span attributes to the names expected by the tracing
+	// backend (e.g. url.path: http.target). If several attributes end up with the same name, only
+	// the attribute with the lowest original name is kept.
+	AttributeRenames map[string]string `yaml:"attribute_renames"`
+
+	// EnvironmentFromLabel names the Kubernetes Pod label whose value is reported as the
+	// deployment.environment resource attribute. If the Pod doesn't have the label, or it is not
+	// running in Kubernetes, the DeploymentEnvironment value is reported, if set.
+	EnvironmentFromLabel  string `yaml:"environment_from_label" env:"BEYLA_OTLP_TRACES_ENVIRONMENT_FROM_LABEL"`
+	DeploymentEnvironment string `yaml:"deployment_environment" env:"BEYLA_OTLP_TRACES_DEPLOYMENT_ENVIRONMENT"`
+
+	// NormalizeDBStatement replaces the literal values of the db.statement attribute (strings, numbers
+	// and IN lists) by ? placeholders, so the SQL queries do not leak sensitive data.
+	NormalizeDBStatement bool `yaml:"normalize_db_statement" env:"BEYLA_OTLP_TRACES_NORMALIZE_DB_STATEMENT"`
+
+	// CaptureSessionsOnly, if true, only exports the spans received during a time-boxed capture
+	// session. The rest of spans are dropped. A capture session is started each time the Beyla
+	// process receives the SIGUSR1 signal (e.g. kill -USR1 <beyla pid>).
+	CaptureSessionsOnly bool `yaml:"capture_sessions_only" env:"BEYLA_OTLP_TRACES_CAPTURE_SESSIONS_ONLY"`
+	// CaptureSessionDuration is the duration of the capture sessions started by the SIGUSR1 signal.
+	// Defaults to 5 minutes.
+	CaptureSessionDuration time.Duration `yaml:"capture_session_duration" env:"BEYLA_OTLP_TRACES_CAPTURE_SESSION_DURATION"`
+
+	// LatencyBuckets, if set, classify the spans by their duration in the beyla.latency_bucket
+	// attribute (e.g. fast, normal or slow). They must be sorted by ascending upper bound.
+	LatencyBuckets []LatencyBucket `yaml:"latency_buckets"`
+
+	// EmitObservedTimestamps adds the observed request start and end times as RFC3339 strings
+	// in the beyla.observed.request_start and beyla.observed.end attributes, to debug clock skews.
+	EmitObservedTimestamps bool `yaml:"emit_observed_timestamps" env:"BEYLA_OTLP_TRACES_EMIT_OBSERVED_TIMESTAMPS"`
+
+	// AnnotateContinuedTraces adds the beyla.trace.continued=true attribute to the server spans that continue
+	// a trace propagated from an upstream service, instead of starting a new trace.
+	AnnotateContinuedTraces bool `yaml:"annotate_continued_traces" env:"BEYLA_OTLP_TRACES_ANNOTATE_CONTINUED_TRACES"`
+
+	// OmitDefaultPorts, if true, does not report the server.port attribute of the HTTP and gRPC spans
+	// when it is the default port of their scheme: 443 for encrypted connections, 80 otherwise.
+	OmitDefaultPorts bool `yaml:"omit_default_ports" env:"BEYLA_OTLP_TRACES_OMIT_DEFAULT_PORTS"`
+
+	// KeepIPv6Zone, if true, reports the zone identifier of the IPv6 addresses (e.g. fe80::1%eth0) in the
+	// server.address and client.address attributes. By default, it is removed, as it leaks the names of the
+	// network interfaces and some backends do not accept it.
+	KeepIPv6Zone bool `yaml:"keep_ipv6_zone" env:"BEYLA_OTLP_TRACES_KEEP_IPV6_ZONE"`
+
+	// ScopeAttributes are added to the instrumentation scope of the exported spans
+	// (e.g. beyla.config.hash, to correlate the spans with the configuration that produced them).
+	ScopeAttributes map[string]string `yaml:"scope_attributes"`
+
+	// CorrelateRequests, if true, makes the client spans (e.g. SQL queries) whose trace context couldn't be
+	// propagated share the trace ID of the inbound request that was being served by the same process,
+	// when both are reported in the same batch. The SQL spans without parent are also re-parented to the
+	// HTTP request that was being served.
+	CorrelateRequests bool `yaml:"correlate_requests" env:"BEYLA_OTLP_TRACES_CORRELATE_REQUESTS"`
+
+	// EnableSpanLinks, if true, adds to the client spans a link to the server span of the same
+	// process that was being served when they were invoked, if both are reported in the same batch.
+	EnableSpanLinks bool `yaml:"enable_span_links" env:"BEYLA_OTLP_TRACES_ENABLE_SPAN_LINKS"`
+
+	// CountResends, if true, sets the http.request.resend_count of the HTTP client spans that retry a failed
+	// request of the same parent span to the same URL, when all the attempts are reported in the same batch.
+	CountResends bool `yaml:"count_resends" env:"BEYLA_OTLP_TRACES_COUNT_RESENDS"`
+
+	// RootSpansOnly, if true, only exports the server spans, which are the entry point to the
+	// instrumented services, and drops all the client spans.
+	RootSpansOnly bool `yaml:"root_spans_only" env:"BEYLA_OTLP_TRACES_ROOT_SPANS_ONLY"`
+
+	// ChildSpansOnly, if true, only exports the spans with a parent, and drops the root spans. It is useful
+	// when another agent already reports the root spans. It can't be enabled along with RootSpansOnly.
+	ChildSpansOnly bool `yaml:"child_spans_only" env:"BEYLA_OTLP_TRACES_CHILD_SPANS_ONLY"`
+
+	// DropUnroutedSpans, if true, drops the HTTP server spans whose route is unknown, as their names
+	// would be derived from high-cardinality raw paths. Error spans are exported anyway if AlwaysSampleErrors is set.
+	DropUnroutedSpans bool `yaml:"drop_unrouted_spans" env:"BEYLA_OTLP_TRACES_DROP_UNROUTED_SPANS"`
+
+	// ShadowSampler is evaluated along with the Sampler, but its decisions are only accounted in the internal
+	// metrics, without affecting the exported spans. It allows evaluating the keep rate of a candidate sampler.
+	ShadowSampler *Sampler `yaml:"shadow_sampler"`
+
+	// SamplingPriority, if its header is set, forces the sampling decision of the requests carrying
+	// a sampling priority (e.g. the x-datadog-sampling-priority header) before the Sampler is evaluated.
+	SamplingPriority SamplingPriority `yaml:"sampling_priority"`
+
+	// RemoteSamplingURL, if set, is periodically polled (every RemoteSamplingInterval, 1 minute by default)
+	// to fetch the per-service sampling strategies, in the Jaeger sampling strategies file format.
+	// The Sampler is used for the services without strategy, or while the strategies can't be fetched.
+	RemoteSamplingURL      string        `yaml:"remote_sampling_url" env:"BEYLA_OTLP_TRACES_REMOTE_SAMPLING_URL"`
+	RemoteSamplingInterval time.Duration `yaml:"remote_sampling_interval" env:"BEYLA_OTLP_TRACES_REMOTE_SAMPLING_INTERVAL"`
+
 	// Configuration options below this line will remain undocumented at the moment,
 	// but can be useful for performance-tuning of some customers.
 	MaxExportBatchSize int           `yaml:"max_export_batch_size" env:"BEYLA_OTLP_TRACES_MAX_EXPORT_BATCH_SIZE"`
@@ -64,21 +297,320 @@ type TracesConfig struct {
 	BatchTimeout       time.Duration `yaml:"batch_timeout" env:"BEYLA_OTLP_TRACES_BATCH_TIMEOUT"`
 	ExportTimeout      time.Duration `yaml:"export_timeout" env:"BEYLA_OTLP_TRACES_EXPORT_TIMEOUT"`
 
+	// QueueOverflowPolicy, if set, queues the spans waiting to be exported (up to MaxQueueSize, or 2048 by default),
+	// and specifies what to do with the spans when the queue is full: "drop_new" discards the new spans,
+	// "drop_oldest" discards the oldest queued spans, and "block" waits for room in the queue up to
+	// QueueBlockTimeout (1s by default) before discarding the new span.
+	QueueOverflowPolicy string        `yaml:"queue_overflow_policy" env:"BEYLA_OTLP_TRACES_QUEUE_OVERFLOW_POLICY"`
+	QueueBlockTimeout   time.Duration `yaml:"queue_block_timeout" env:"BEYLA_OTLP_TRACES_QUEUE_BLOCK_TIMEOUT"`
+
+	// MaxExportBatchBytes, if set, queues the exported spans and submits them in batches whose serialized
+	// size does not exceed the given amount of bytes. A span bigger than the limit is submitted alone.
+	// The queued spans are submitted after the BatchTimeout (5s by default) at most.
+	MaxExportBatchBytes int `yaml:"max_export_batch_bytes" env:"BEYLA_OTLP_TRACES_MAX_EXPORT_BATCH_BYTES"`
+
+	// MinExportInterval, if set, queues the exported spans and ensures that they are not submitted more
+	// often than the given interval, by coalescing them. The queued spans are checked for submission
+	// every BatchTimeout (5s by default), so they might wait up to MinExportInterval + BatchTimeout.
+	MinExportInterval time.Duration `yaml:"min_export_interval" env:"BEYLA_OTLP_TRACES_MIN_EXPORT_INTERVAL"`
+
+	// ExportCallTimeout, if set, limits the time that each span submission to the traces exporter can
+	// take. Submissions exceeding it are cancelled, so a slow collector does not stall the pipeline.
+	ExportCallTimeout time.Duration `yaml:"export_call_timeout" env:"BEYLA_OTLP_TRACES_EXPORT_CALL_TIMEOUT"`
+
+	// MeasurePayloadSize, if true, measures the serialized OTLP size of each submission to the traces exporter,
+	// to help tuning the MaxExportBatchSize. The size is logged at debug level and reported in the
+	// otel_trace_export_bytes internal metric.
+	MeasurePayloadSize bool `yaml:"measure_payload_size" env:"BEYLA_OTLP_TRACES_MEASURE_PAYLOAD_SIZE"`
+
+	// ExporterInitMaxAttempts, if greater than 1, retries the creation of the traces exporter when it fails
+	// (e.g. because the collector address is not resolvable yet). The interval between attempts starts
+	// at ExporterInitRetryInterval (1s by default) and is doubled after each failed attempt.
+	ExporterInitMaxAttempts   int           `yaml:"exporter_init_max_attempts" env:"BEYLA_OTLP_TRACES_EXPORTER_INIT_MAX_ATTEMPTS"`
+	ExporterInitRetryInterval time.Duration `yaml:"exporter_init_retry_interval" env:"BEYLA_OTLP_TRACES_EXPORTER_INIT_RETRY_INTERVAL"`
+
+	// FailOnExporterError, if true, stops Beyla with an error when the traces exporter can't be created
+	// (after the configured retries), so it can be restarted by the orchestrator. Otherwise, the error is
+	// logged and Beyla keeps running without exporting traces.
+	FailOnExporterError bool `yaml:"fail_on_exporter_error" env:"BEYLA_OTLP_TRACES_FAIL_ON_EXPORTER_ERROR"`
+
+	// GRPCConnPoolSize specifies the number of connections that are open towards the gRPC
+	// endpoint. The exported traces are distributed across them in round-robin. Defaults to 1.
+	GRPCConnPoolSize int `yaml:"grpc_conn_pool_size" env:"BEYLA_OTLP_TRACES_GRPC_CONN_POOL_SIZE"`
+
+	// ExportConcurrency, if greater than 1, specifies the number of goroutines that concurrently
+	// submit the sampled spans to the traces exporter.
+	ExportConcurrency int `yaml:"export_concurrency" env:"BEYLA_OTLP_TRACES_EXPORT_CONCURRENCY"`
+
 	ReportersCacheLen int `yaml:"reporters_cache_len" env:"BEYLA_TRACES_REPORT_CACHE_LEN"`
 
+	// SamplingDecisionsCacheLen, if set, specifies how many traces remember their sampling decision, so all
+	// the spans of a trace are consistently kept or dropped, even if the sampler is not deterministic.
+	// The children of a locally generated span inherit its decision, so they are not exported as orphans.
+	SamplingDecisionsCacheLen int `yaml:"sampling_decisions_cache_len" env:"BEYLA_OTLP_TRACES_SAMPLING_DECISIONS_CACHE_LEN"`
+
+	// ChildSampleRatio, if set, is the ratio of child spans that are kept from the traces whose local root was
+	// sampled. The local roots are the server spans, even if they continue a trace from an upstream service.
+	// The children of a dropped root are always dropped. It requires the SamplingDecisionsCacheLen to be set,
+	// so the children can be correlated with the decision taken for their root.
+	ChildSampleRatio float64 `yaml:"child_sample_ratio" env:"BEYLA_OTLP_TRACES_CHILD_SAMPLE_RATIO"`
+
+	// ServiceIDGracePeriod, if set, specifies how long the spans of services whose name is not yet
+	// resolved are buffered, waiting for the service discovery to provide it.
+	ServiceIDGracePeriod time.Duration `yaml:"service_id_grace_period" env:"BEYLA_OTLP_TRACES_SERVICE_ID_GRACE_PERIOD"`
+	// InvalidTimestamps, if set, validates the timestamps of the spans before exporting them. Spans with zero or
+	// inconsistent timestamps are either discarded ("drop") or repaired when possible ("clamp").
+	InvalidTimestamps string `yaml:"invalid_timestamps" env:"BEYLA_OTLP_TRACES_INVALID_TIMESTAMPS"`
+
+	// DedupWindow, if set, drops the spans whose identity (trace ID, span ID and start time) is the same
+	// as a span that was received within the given window, e.g. because of retries or probe re-entries.
+	DedupWindow time.Duration `yaml:"dedup_window" env:"BEYLA_OTLP_TRACES_DEDUP_WINDOW"`
+
+	// ServiceIDConflicts, if set, detects the spans of a connection whose service identity differs from
+	// the identity previously seen for the same connection (e.g. because it was re-resolved while the
+	// connection was alive). Accepted values are "keep_first", which reports the first-seen identity, and
+	// "keep_last", which reports the latest identity of the connection. In both cases, an identity decorated
+	// with the Kubernetes metadata replaces an undecorated one. Spans whose connection ports are unknown are
+	// not checked.
+	ServiceIDConflicts string `yaml:"service_id_conflicts" env:"BEYLA_OTLP_TRACES_SERVICE_ID_CONFLICTS"`
+
+	// SelfTraceEndpoint, if set, is the URL of an OTLP/HTTP endpoint that receives the spans describing
+	// the significant internal events of the traces exporter (e.g. export failures), for diagnostic purposes.
+	SelfTraceEndpoint string `yaml:"self_trace_endpoint" env:"BEYLA_OTLP_TRACES_SELF_TRACE_ENDPOINT"`
+
+	// ClockSource specifies how the monotonic timestamps of the spans are converted to wall-clock time:
+	// "realtime" (default) uses the current difference between both clocks for each span, while "boottime"
+	// uses the boot time captured at startup, so the wall-clock adjustments do not shift the spans.
+	ClockSource string `yaml:"clock_source" env:"BEYLA_OTLP_TRACES_CLOCK_SOURCE"`
+
+	// TimestampPrecision rounds down the timestamps of the spans to the given precision, for the backends
+	// that do not accept nanoseconds: "ns" (default), "us" or "ms".
+	TimestampPrecision string `yaml:"timestamp_precision" env:"BEYLA_OTLP_TRACES_TIMESTAMP_PRECISION"`
+
+	// UnresolvedServiceFallback specifies what to do with the buffered spans whose service name wasn't resolved
+	// after the ServiceIDGracePeriod: "emit" (default) exports them anyway, "drop" discards them.
+	UnresolvedServiceFallback string `yaml:"unresolved_service_fallback" env:"BEYLA_OTLP_TRACES_UNRESOLVED_SERVICE_FALLBACK"`
+
+	// AlwaysSampleErrors, if true, exports the spans whose status is an error even if they would be dropped
+	// by the DropStatusRanges, IgnorePeerCIDRs or DropUnroutedSpans options. It is enabled by default.
+	AlwaysSampleErrors bool `yaml:"always_sample_errors" env:"BEYLA_OTLP_TRACES_ALWAYS_SAMPLE_ERRORS"`
+
+	// DropStatusRanges specifies the HTTP response status codes (e.g. 204) or ranges (e.g. 200-299) whose
+	// spans are not exported.
+	DropStatusRanges []StatusRange `yaml:"drop_status_ranges" env:"BEYLA_OTLP_TRACES_DROP_STATUS_RANGES" envSeparator:","`
+
+	// IgnorePeerCIDRs specifies the CIDRs (e.g. 10.0.0.0/8) or single IPs whose server spans are not exported,
+	// according to the peer IP of the span (e.g. the health checkers or scanners). Client spans are never
+	// dropped, as their peer IP is the local address of the instrumented process.
+	IgnorePeerCIDRs []string `yaml:"ignore_peer_cidrs" env:"BEYLA_OTLP_TRACES_IGNORE_PEER_CIDRS" envSeparator:","`
+
+	// SemconvCompatMode specifies the naming of the span attributes that changed with the stabilization of
+	// the HTTP semantic conventions: "stable" (default, e.g. http.request.method), "legacy" (e.g. http.method),
+	// or "dup" to emit both, easing the migration of the dashboards that rely on the legacy names.
+	SemconvCompatMode string `yaml:"semconv_compat_mode" env:"BEYLA_OTLP_TRACES_SEMCONV_COMPAT_MODE"`
+
 	// SDKLogLevel works independently from the global LogLevel because it prints GBs of logs in Debug mode
 	// and the Info messages leak internal details that are not usually valuable for the final user.
 	SDKLogLevel string `yaml:"otel_sdk_log_level" env:"BEYLA_OTEL_SDK_LOG_LEVEL"`
 
+	// Destinations specifies additional endpoints where the traces are sent to, besides the
+	// endpoint that is configured from the above properties.
+	Destinations []TracesDestination `yaml:"destinations"`
+
 	// Grafana configuration needs to be explicitly set up before building the graph
 	Grafana *GrafanaOTLP `yaml:"-"`
+
+	// PeerEnricher, if set, is invoked with the peer IP of each server span, and the returned key/values
+	// are added as span attributes (e.g. the ASN or geolocation of the client). Beyla does not perform
+	// any lookup by itself. The function is invoked synchronously from the traces export loop, for every
+	// span, so it must return quickly (e.g. from an in-memory database or cache) and never block on I/O.
+	// It can be invoked concurrently from multiple exporters.
+	PeerEnricher func(ip string) map[string]string `yaml:"-" env:"-"`
+
+	// headers to be sent to the endpoint. Only set for the configuration of the Destinations
+	headers map[string]string
+}
+
+// TracesDestination specifies an additional endpoint for the traces export. Each destination
+// uses its own headers and TLS configuration, which are never shared with other destinations.
+type TracesDestination struct {
+	Endpoint           string            `yaml:"endpoint"`
+	Protocol           Protocol          `yaml:"protocol"`
+	Headers            map[string]string `yaml:"headers"`
+	InsecureSkipVerify bool              `yaml:"insecure_skip_verify"`
+}
+
+// tracesConfig returns a copy of the base traces configuration whose endpoint
+// properties are replaced by the properties of the destination
+func (d *TracesDestination) tracesConfig(base *TracesConfig) TracesConfig {
+	cfg := *base
+	cfg.CommonEndpoint = ""
+	cfg.TracesEndpoint = d.Endpoint
+	cfg.Protocol = d.Protocol
+	cfg.TracesProtocol = ""
+	cfg.Protocols = nil
+	cfg.InsecureSkipVerify = d.InsecureSkipVerify
+	cfg.Destinations = nil
+	cfg.Grafana = nil
+	cfg.headers = d.Headers
+	return cfg
+}
+
+// protocolsConfigs returns a copy of the traces configuration for each of the configured
+// Protocols, whose endpoint port is adjusted to the usual port of each protocol.
+func (m *TracesConfig) protocolsConfigs() ([]TracesConfig, error) {
+	configs := make([]TracesConfig, 0, len(m.Protocols))
+	for _, proto := range m.Protocols {
+		cfg := *m
+		cfg.Protocols = nil
+		cfg.Protocol = proto
+		cfg.TracesProtocol = ""
+		var err error
+		// any of both endpoints could be used, according to the EndpointPriority
+		if cfg.TracesEndpoint != "" {
+			if cfg.TracesEndpoint, err = endpointForProtocol(cfg.TracesEndpoint, proto); err != nil {
+				return nil, err
+			}
+		}
+		if cfg.CommonEndpoint != "" {
+			if cfg.CommonEndpoint, err = endpointForProtocol(cfg.CommonEndpoint, proto); err != nil {
+				return nil, err
+			}
+		}
+		configs = append(configs, cfg)
+	}
+	return configs, nil
+}
+
+// endpointForProtocol replaces the port of the endpoint by the usual port of the provided
+// protocol, if the endpoint uses the usual port of the other protocol. E.g. for the gRPC protocol,
+// 4318 becomes 4317. Otherwise, the endpoint is returned unchanged.
+func endpointForProtocol(endpoint string, proto Protocol) (string, error) {
+	epURL, err := url.Parse(endpoint)
+	if err != nil {
+		return "", fmt.Errorf("parsing endpoint URL %s: %w", endpoint, err)
+	}
+	var from, to string
+	switch proto {
+	case ProtocolGRPC:
+		from, to = UsualPortHTTP, UsualPortGRPC
+	case ProtocolHTTPProtobuf, ProtocolHTTPJSON:
+		from, to = UsualPortGRPC, UsualPortHTTP
+	default:
+		return endpoint, nil
+	}
+	if epURL.Port() != from {
+		return endpoint, nil
+	}
+	epURL.Host = net.JoinHostPort(epURL.Hostname(), to)
+	return epURL.String(), nil
 }
 
 // Enabled specifies that the OTEL traces node is enabled if and only if
-// either the OTEL endpoint and OTEL traces endpoint is defined.
+// either the OTEL endpoint, OTEL traces endpoint or any extra destination is defined.
 // If not enabled, this node won't be instantiated
 func (m TracesConfig) Enabled() bool { //nolint:gocritic
-	return m.CommonEndpoint != "" || m.TracesEndpoint != "" || m.Grafana.TracesEnabled()
+	return m.endpointEnabled() || len(m.Destinations) > 0
+}
+
+func (m *TracesConfig) resourceSchemaURL() string {
+	if m.ResourceSchemaURL != "" {
+		return m.ResourceSchemaURL
+	}
+	return semconv.SchemaURL
+}
+
+func (m *TracesConfig) resourceExtraAttrs(service *svc.ID) []attribute.KeyValue {
+	var attrs []attribute.KeyValue
+	if m.EmitDistroAttributes {
+		attrs = append(attrs, distroAttrs()...)
+	}
+	if m.EmitProcessAttributes {
+		attrs = append(attrs, processAttrs(service, m.RedactCommandLineFlags)...)
+	}
+	if m.EmitContainerImageAttrs {
+		attrs = append(attrs, containerImageAttrs(service)...)
+	}
+	if m.EmitNodeName {
+		attrs = append(attrs, nodeNameAttrs(service, nodeName())...)
+	}
+	if m.EmitConfigFingerprint && m.ConfigFingerprint != "" {
+		attrs = append(attrs, configFingerprintKey.String(m.ConfigFingerprint))
+	}
+	if env := m.deploymentEnvironment(service); env != "" {
+		attrs = append(attrs, semconv.DeploymentEnvironment(env))
+	}
+	return attrs
+}
+
+func (m *TracesConfig) deploymentEnvironment(service *svc.ID) string {
+	if m.EnvironmentFromLabel != "" {
+		if env, ok := service.PodLabels[m.EnvironmentFromLabel]; ok && env != "" {
+			return env
+		}
+	}
+	return m.DeploymentEnvironment
+}
+
+// Validate returns an error if any of the traces options has an invalid value
+func (m *TracesConfig) Validate() error {
+	if m.RootSpansOnly && m.ChildSpansOnly {
+		return errors.New("root_spans_only and child_spans_only can't be enabled at the same time")
+	}
+	if err := validateClockSource(m.ClockSource); err != nil {
+		return err
+	}
+	if err := validateTimestampPrecision(m.TimestampPrecision); err != nil {
+		return err
+	}
+	if err := validateInvalidTimestamps(m.InvalidTimestamps); err != nil {
+		return err
+	}
+	if err := validateServiceIDConflicts(m.ServiceIDConflicts); err != nil {
+		return err
+	}
+	if err := validateUnresolvedServiceFallback(m.UnresolvedServiceFallback); err != nil {
+		return err
+	}
+	if err := validateAttributeOverflowPolicy(m.AttributeOverflowPolicy); err != nil {
+		return err
+	}
+	if err := validateDerivedAttributes(m.DerivedAttributes); err != nil {
+		return err
+	}
+	if err := validateChildSampleRatio(m.ChildSampleRatio, m.SamplingDecisionsCacheLen); err != nil {
+		return err
+	}
+	if err := validateEndpointPriority(m.EndpointPriority); err != nil {
+		return err
+	}
+	if _, err := parsePeerCIDRs(m.IgnorePeerCIDRs); err != nil {
+		return fmt.Errorf("ignore_peer_cidrs: %w", err)
+	}
+	if err := validateTracesURLPath(m.TracesURLPath); err != nil {
+		return err
+	}
+	return validateIPFamily(m.IPFamily)
+}
+
+// validateTracesURLPath checks that the traces URL path is an absolute path, without scheme nor host,
+// as it replaces the path of the common endpoint
+func validateTracesURLPath(path string) error {
+	if path == "" {
+		return nil
+	}
+	if !strings.HasPrefix(path, "/") {
+		return fmt.Errorf("traces URL path %q must start with /", path)
+	}
+	if u, err := url.Parse(path); err != nil || u.Scheme != "" || u.Host != "" {
+		return fmt.Errorf("traces URL path %q must be a path, without scheme nor host", path)
+	}
+	return nil
+}
+
+func (m *TracesConfig) endpointEnabled() bool {
+	return m.CommonEndpoint != "" || m.TracesEndpoint != "" || m.Grafana.TracesEnabled() ||
+		(m.FilePath != "" && m.getProtocol() == ProtocolFile)
 }
 
 func (m *TracesConfig) getProtocol() Protocol {
@@ -92,24 +624,13 @@ func (m *TracesConfig) getProtocol() Protocol {
 }
 
 func (m *TracesConfig) guessProtocol() Protocol {
-	// If no explicit protocol is set, we guess it it from the metrics enpdoint port
-	// (assuming it uses a standard port or a development-like form like 14317, 24317, 14318...)
-	ep, _, err := parseTracesEndpoint(m)
-	if err == nil {
-		if strings.HasSuffix(ep.Port(), UsualPortGRPC) {
-			return ProtocolGRPC
-		} else if strings.HasSuffix(ep.Port(), UsualPortHTTP) {
-			return ProtocolHTTPProtobuf
-		}
-	}
-	// Otherwise we return default protocol according to the latest specification:
-	// https://github.com/open-telemetry/opentelemetry-specification/blob/main/specification/protocol/exporter.md?plain=1#L53
-	return ProtocolHTTPProtobuf
+	signal, common := m.signalEndpoints()
+	return guessProtocol(signal, common, nil, m.DefaultProtocol)
 }
 
 // TracesReceiver creates a terminal node that consumes request.Spans and sends OpenTelemetry metrics to the configured consumers.
 func TracesReceiver(ctx context.Context, cfg TracesConfig, ctxInfo *global.ContextInfo, userAttribSelection attributes.Selection) pipe.FinalProvider[[]request.Span] {
-	return (&tracesOTELReceiver{ctx: ctx, cfg: cfg, ctxInfo: ctxInfo, attributes: userAttribSelection}).provideLoop
+	return newTracesOTELReceiver(ctx, cfg, ctxInfo, userAttribSelection).provideLoop
 }
 
 type tracesOTELReceiver struct {
@@ -117,6 +638,147 @@ type tracesOTELReceiver struct {
 	cfg        TracesConfig
 	ctxInfo    *global.ContextInfo
 	attributes attributes.Selection
+
+	sampler       trace.Sampler
+	shadowSampler trace.Sampler
+	// decisions is only set when the SamplingDecisionsCacheLen is defined
+	decisions *decisionsCache
+
+	// spansCap is only set when the MaxSpansPerTrace is defined
+	spansCap *spansCap
+
+	// pendingServices is only set when the ServiceIDGracePeriod is defined
+	pendingServices *pendingServices
+
+	// dedup is only set when the DedupWindow is defined
+	dedup *spansDedup
+
+	// connIdentities is only set when the ServiceIDConflicts handling is defined
+	connIdentities *connIdentities
+	clientConns    *clientConns
+
+	// selfTracer is only set when the SelfTraceEndpoint is defined
+	selfTracer *selfTracer
+
+	// remoteSampler is only set when the RemoteSamplingURL is defined. It replaces the sampler
+	remoteSampler *remoteSampler
+
+	errLog  *rateLimitedLogger
+	warnLog *rateLimitedLogger
+
+	// ignoredPeers is only set when the IgnorePeerCIDRs are defined
+	ignoredPeers *peerCIDRs
+
+	// requiredHeaders contains the RequireAttribute configuration with lowercase keys
+	requiredHeaders map[string]string
+
+	// newExporter creates the traces exporter. It can be overridden for testing purposes
+	newExporter func(ctx context.Context, cfg TracesConfig, ctxInfo *global.ContextInfo) (exporter.Traces, error)
+}
+
+func newTracesOTELReceiver(ctx context.Context, cfg TracesConfig, ctxInfo *global.ContextInfo, userAttribSelection attributes.Selection) *tracesOTELReceiver {
+	tr := &tracesOTELReceiver{
+		ctx:        ctx,
+		cfg:        cfg,
+		ctxInfo:    ctxInfo,
+		attributes: userAttribSelection,
+
+		newExporter: getTracesExporter,
+	}
+	tr.errLog = newRateLimitedLogger(tlog(), exportErrorLogInterval, tr.internalMetrics())
+	tr.warnLog = newRateLimitedLogger(tlog(), exportErrorLogInterval, tr.internalMetrics())
+	if cfg.Sampler.configured() {
+		tr.sampler = cfg.Sampler.Implementation()
+	}
+	if cfg.RemoteSamplingURL != "" {
+		tr.remoteSampler = newRemoteSampler(cfg.RemoteSamplingURL, cfg.RemoteSamplingInterval, cfg.Sampler.Implementation())
+		tr.sampler = tr.remoteSampler
+	}
+	warnRenameCollisions(tlog(), cfg.AttributeRenames)
+	cfg.warnMultipleEndpoints(tlog())
+	if cfg.ShadowSampler != nil {
+		tr.shadowSampler = cfg.ShadowSampler.Implementation()
+	}
+	if len(cfg.RequireAttribute) > 0 {
+		tr.requiredHeaders = make(map[string]string, len(cfg.RequireAttribute))
+		for k, v := range cfg.RequireAttribute {
+			tr.requiredHeaders[strings.ToLower(k)] = v
+		}
+	}
+	if len(cfg.IgnorePeerCIDRs) > 0 {
+		var err error
+		if tr.ignoredPeers, err = parsePeerCIDRs(cfg.IgnorePeerCIDRs); err != nil {
+			tlog().Warn("invalid ignored peer CIDRs. The spans won't be filtered by peer IP", "error", err)
+		}
+	}
+	if cfg.SamplingDecisionsCacheLen > 0 {
+		tr.decisions = newDecisionsCache(cfg.SamplingDecisionsCacheLen)
+	}
+	if cfg.MaxSpansPerTrace > 0 {
+		tr.spansCap = newSpansCap(cfg.MaxSpansPerTrace)
+	}
+	if cfg.ServiceIDGracePeriod > 0 {
+		tr.pendingServices = newPendingServices(cfg.ServiceIDGracePeriod, cfg.UnresolvedServiceFallback)
+	}
+	if cfg.DedupWindow > 0 {
+		tr.dedup = newSpansDedup(cfg.DedupWindow)
+	}
+	if cfg.SelfTraceEndpoint != "" {
+		var err error
+		if tr.selfTracer, err = newSelfTracer(ctx, &cfg); err != nil {
+			tlog().Warn("can't create self-tracer. Internal events won't be reported", "error", err)
+		}
+	}
+	tr.clientConns = newClientConns()
+	if cfg.ServiceIDConflicts != "" {
+		tr.connIdentities = newConnIdentities(cfg.ServiceIDConflicts)
+	}
+	return tr
+}
+
+func (tr *tracesOTELReceiver) internalMetrics() imetrics.Reporter {
+	if tr.ctxInfo == nil || tr.ctxInfo.Metrics == nil {
+		return imetrics.NoopReporter{}
+	}
+	return tr.ctxInfo.Metrics
+}
+
+// sample returns whether the span has to be exported, according to the configured sampler.
+// The sampling priority carried by the request, if any, overrides the sampler decision.
+// If a shadow sampler is defined, its decision is only recorded in the internal metrics.
+// It also sets the SamplingProbability of the span, as reported by the path that took the decision.
+func (tr *tracesOTELReceiver) sample(span *request.Span) bool {
+	if keep, forced := tr.cfg.SamplingPriority.decision(span); forced {
+		decision := samplingDecision{keep: keep}
+		if keep {
+			decision.probability = 1
+		}
+		if tr.decisions != nil {
+			tr.decisions.force(span, decision)
+		}
+		tr.internalMetrics().OTELTraceSamplingDecision(samplerPriority, keep)
+		span.SamplingProbability = decision.probability
+		return keep
+	}
+	// the spans are only dropped if a sampler is explicitly configured
+	decision := samplingDecision{keep: true, probability: 1}
+	switch {
+	case tr.sampler == nil:
+	case tr.decisions != nil:
+		decision = tr.decisions.sample(tr.sampler, span, tr.cfg.KnownHTTPMethods)
+	default:
+		decision = sampleSpan(tr.sampler, span, tr.cfg.KnownHTTPMethods)
+	}
+	if tr.cfg.ChildSampleRatio > 0 {
+		decision = sampleChildDecision(tr.cfg.ChildSampleRatio, span, decision)
+	}
+	metrics := tr.internalMetrics()
+	metrics.OTELTraceSamplingDecision(samplerActive, decision.keep)
+	if tr.shadowSampler != nil {
+		metrics.OTELTraceSamplingDecision(samplerShadow, shouldSample(tr.shadowSampler, span, tr.cfg.KnownHTTPMethods))
+	}
+	span.SamplingProbability = decision.probability
+	return decision.keep
 }
 
 func GetUserSelectedAttributes(attrs attributes.Selection) (map[attr.Name]struct{}, error) {
@@ -138,11 +800,31 @@ func (tr *tracesOTELReceiver) provideLoop() (pipe.FinalFunc[[]request.Span], err
 	if !tr.cfg.Enabled() {
 		return pipe.IgnoreFinal[[]request.Span](), nil
 	}
+	var exp exporter.Traces
+	if tr.cfg.FailOnExporterError {
+		// the exporter is created before the pipeline starts, so the error stops Beyla
+		var err error
+		if exp, err = tr.createTracesExporter(); err != nil {
+			if tr.selfTracer != nil {
+				tr.selfTracer.shutdown(context.Background())
+			}
+			return nil, fmt.Errorf("creating traces exporter: %w", err)
+		}
+	}
+	if tr.cfg.CaptureSessionsOnly {
+		// the signal is handled before the pipeline starts, so it never terminates Beyla
+		listenCaptureSignal(tr.ctx, tr.cfg.CaptureSessionDuration)
+	}
 	return func(in <-chan []request.Span) {
-		exp, err := getTracesExporter(tr.ctx, tr.cfg, tr.ctxInfo)
-		if err != nil {
-			slog.Error("error creating traces exporter", "error", err)
-			return
+		if tr.selfTracer != nil {
+			defer tr.selfTracer.shutdown(context.Background())
+		}
+		var err error
+		if exp == nil {
+			if exp, err = tr.createTracesExporter(); err != nil {
+				slog.Error("error creating traces exporter", "error", err)
+				return
+			}
 		}
 		defer func() {
 			err := exp.Shutdown(tr.ctx)
@@ -161,24 +843,200 @@ func (tr *tracesOTELReceiver) provideLoop() (pipe.FinalFunc[[]request.Span], err
 			slog.Error("error selecting user trace attributes", "error", err)
 			return
 		}
+		if tr.remoteSampler != nil {
+			go tr.remoteSampler.poll(tr.ctx)
+		}
 
-		for spans := range in {
-			for i := range spans {
-				span := &spans[i]
-				if span.IgnoreSpan == request.IgnoreTraces {
-					continue
-				}
-				traces := GenerateTraces(span, traceAttrs)
-				err := exp.ConsumeTraces(tr.ctx, traces)
-				if err != nil {
-					slog.Error("error sending trace to consumer", "error", err)
-				}
+		submit := func(traces ptrace.Traces) {
+			err := tr.consumeTraces(exp, traces)
+			if err != nil {
+				tr.errLog.Error("error sending trace to consumer", err)
+			}
+			if tr.selfTracer != nil {
+				tr.selfTracer.exportResult(err)
+			}
+		}
+		if tr.cfg.MaxExportBatchBytes > 0 || tr.cfg.MinExportInterval > 0 {
+			batcher := newTracesBatcher(&tr.cfg, submit)
+			// submits the queued traces before the exporter is shut down
+			defer batcher.close()
+			submit = batcher.add
+		}
+		send := func(span *request.Span) {
+			traces := GenerateTraces(&tr.cfg, span, traceAttrs)
+			if traces.SpanCount() == 0 {
+				tr.internalMetrics().OTELTraceRejectedSpan()
+				return
+			}
+			if tr.spansCap != nil {
+				tr.spansCap.markDropped(span, traces)
 			}
+			submit(traces)
 		}
+		var pool *exportPool
+		switch {
+		case tr.cfg.QueueOverflowPolicy != "":
+			pool = newOverflowExportPool(max(tr.cfg.ExportConcurrency, 1), &tr.cfg, tr.internalMetrics(), send)
+		case tr.cfg.ExportConcurrency > 1:
+			pool = newExportPool(tr.cfg.ExportConcurrency, send)
+		}
+		if pool != nil {
+			// drains the pool before the exporter is shut down
+			defer pool.close()
+			send = pool.submit
+		}
+		// the sampling decisions are taken serially, so the parents are sampled before their children.
+		// The span belongs to the copy of the batch that is owned by the traces exporter.
+		export := func(span *request.Span) {
+			if !span.TraceID.IsValid() {
+				// the trace ID is assigned before sampling, so the samplers decide on the exported trace ID
+				span.TraceID = randomTraceID()
+			}
+			if tr.sample(span) && (tr.spansCap == nil || tr.spansCap.admit(span)) {
+				send(span)
+			}
+		}
+		tr.consume(in, export)
 	}, nil
 }
 
+func (tr *tracesOTELReceiver) consume(in <-chan []request.Span, export func(*request.Span)) {
+	// the pending spans are periodically expired, even if no new spans are received
+	var expireTicks <-chan time.Time
+	if tr.pendingServices != nil {
+		defer tr.pendingServices.flush(export)
+		ticker := time.NewTicker(tr.pendingServices.expirePeriod())
+		defer ticker.Stop()
+		expireTicks = ticker.C
+	}
+	for {
+		select {
+		case <-expireTicks:
+			tr.pendingServices.expire(export)
+		case spans, ok := <-in:
+			if !ok {
+				return
+			}
+			tr.consumeBatch(spans, export)
+		}
+	}
+}
+
+func (tr *tracesOTELReceiver) consumeBatch(spans []request.Span, export func(*request.Span)) {
+	if tr.cfg.CaptureSessionsOnly && !captureSessions.active() {
+		return
+	}
+	// the batch is shared with the other exporters, which read it concurrently, so the
+	// spans are only modified in a copy of it
+	spans = slices.Clone(spans)
+	if tr.cfg.CorrelateRequests {
+		correlateRequests(spans)
+	}
+	if tr.cfg.EnableSpanLinks {
+		linkRequests(spans)
+	}
+	if tr.cfg.CountResends {
+		countResends(spans)
+	}
+	if tr.clientConns != nil {
+		tr.clientConns.markReused(spans)
+	}
+	for _, i := range tr.exportOrder(spans) {
+		span := &spans[i]
+		if span.IgnoreSpan == request.IgnoreTraces || !tr.hasRequiredHeaders(span) ||
+			(tr.cfg.RootSpansOnly && span.IsClientSpan()) || (tr.cfg.ChildSpansOnly && !span.ParentSpanID.IsValid()) ||
+			tr.droppedByFilters(span) {
+			continue
+		}
+		if tr.cfg.InvalidTimestamps != "" {
+			if span = tr.checkTimestamps(span); span == nil {
+				continue
+			}
+		}
+		if tr.connIdentities != nil && tr.connIdentities.resolve(span) {
+			tr.internalMetrics().OTELTraceServiceIDConflict()
+		}
+		if tr.dedup != nil && tr.dedup.isDuplicate(span) {
+			tr.internalMetrics().OTELTraceDuplicateSpan()
+			continue
+		}
+		if tr.pendingServices != nil {
+			tr.pendingServices.forward(span, export)
+		} else {
+			export(span)
+		}
+	}
+}
+
+// exportOrder returns the order in which the spans of the batch are processed. If the sampling
+// decisions are cached, the locally generated parent spans are processed before their children, so
+// all of them share the decision taken for the parent.
+func (tr *tracesOTELReceiver) exportOrder(spans []request.Span) []int {
+	if tr.decisions != nil {
+		return parentsFirst(spans)
+	}
+	order := make([]int, len(spans))
+	for i := range order {
+		order[i] = i
+	}
+	return order
+}
+
+// droppedByFilters returns whether the span has to be dropped by the DropStatusRanges, IgnorePeerCIDRs
+// or DropUnroutedSpans options. The error spans are kept if AlwaysSampleErrors is set.
+func (tr *tracesOTELReceiver) droppedByFilters(span *request.Span) bool {
+	dropped := droppedByStatus(tr.cfg.DropStatusRanges, span) ||
+		(tr.ignoredPeers != nil && tr.ignoredPeers.ignoredPeer(span)) ||
+		(tr.cfg.DropUnroutedSpans && unrouted(span))
+	return dropped && !(tr.cfg.AlwaysSampleErrors && SpanStatusCode(span) == codes.Error)
+}
+
+// hasRequiredHeaders returns true if the span request carries all the header values required by the configuration
+func (tr *tracesOTELReceiver) hasRequiredHeaders(span *request.Span) bool {
+	for name, expected := range tr.requiredHeaders {
+		if !slices.Contains(span.RequestHeaders[name], expected) {
+			return false
+		}
+	}
+	return true
+}
+
 func getTracesExporter(ctx context.Context, cfg TracesConfig, ctxInfo *global.ContextInfo) (exporter.Traces, error) {
+	var exporters fanOutTracesExporter
+	if len(cfg.Protocols) > 0 && cfg.endpointEnabled() {
+		configs, err := cfg.protocolsConfigs()
+		if err != nil {
+			return nil, err
+		}
+		for i := range configs {
+			exp, err := getEndpointTracesExporter(ctx, configs[i], ctxInfo)
+			if err != nil {
+				return nil, fmt.Errorf("creating %s traces exporter: %w", configs[i].Protocol, err)
+			}
+			exporters = append(exporters, exp)
+		}
+	} else if cfg.endpointEnabled() {
+		exp, err := getEndpointTracesExporter(ctx, cfg, ctxInfo)
+		if err != nil {
+			return nil, err
+		}
+		if len(cfg.Destinations) == 0 {
+			return exp, nil
+		}
+		exporters = append(exporters, exp)
+	}
+	for i := range cfg.Destinations {
+		dst := &cfg.Destinations[i]
+		exp, err := getEndpointTracesExporter(ctx, dst.tracesConfig(&cfg), ctxInfo)
+		if err != nil {
+			return nil, fmt.Errorf("creating traces exporter for destination %q: %w", dst.Endpoint, err)
+		}
+		exporters = append(exporters, exp)
+	}
+	return exporters, nil
+}
+
+func getEndpointTracesExporter(ctx context.Context, cfg TracesConfig, ctxInfo *global.ContextInfo) (exporter.Traces, error) {
 	switch proto := cfg.getProtocol(); proto {
 	case ProtocolHTTPJSON, ProtocolHTTPProtobuf, "": // zero value defaults to HTTP for backwards-compatibility
 		slog.Debug("instantiating HTTP TracesReporter", "protocol", proto)
@@ -194,7 +1052,7 @@ func getTracesExporter(ctx context.Context, cfg TracesConfig, ctxInfo *global.Co
 			slog.Error("can't instantiate OTEL HTTP traces exporter", err)
 			return nil, err
 		}
-		endpoint, _, err := parseTracesEndpoint(&cfg)
+		endpoint, isCommon, err := parseTracesEndpoint(&cfg)
 		if err != nil {
 			slog.Error("can't parse traces endpoint", "error", err)
 			return nil, err
@@ -210,6 +1068,12 @@ func getTracesExporter(ctx context.Context, cfg TracesConfig, ctxInfo *global.Co
 			},
 			Headers: convertHeaders(opts.HTTPHeaders),
 		}
+		if isCommon && cfg.TracesURLPath != "" {
+			// otherwise, the exporter appends the default /v1/traces path to the endpoint
+			tracesURL := *endpoint
+			tracesURL.Path = opts.URLPath
+			config.TracesEndpoint = tracesURL.String()
+		}
 		set := getTraceSettings(ctxInfo, cfg, t)
 		return factory.CreateTracesExporter(ctx, set, config)
 	case ProtocolGRPC:
@@ -234,17 +1098,39 @@ func getTracesExporter(ctx context.Context, cfg TracesConfig, ctxInfo *global.Co
 		config := factory.CreateDefaultConfig().(*otlpexporter.Config)
 		config.QueueConfig.Enabled = false
 		config.ClientConfig = configgrpc.ClientConfig{
-			Endpoint: endpoint.String(),
+			Endpoint: ipFamilyTarget(cfg.IPFamily, endpoint.String(), endpoint.Host),
 			TLSSetting: configtls.ClientConfig{
 				Insecure:           opts.Insecure,
 				InsecureSkipVerify: cfg.InsecureSkipVerify,
 			},
+			Headers: convertHeaders(opts.HTTPHeaders),
 		}
 		set := getTraceSettings(ctxInfo, cfg, t)
-		return factory.CreateTracesExporter(ctx, set, config)
+		if cfg.GRPCConnPoolSize <= 1 {
+			return factory.CreateTracesExporter(ctx, set, config)
+		}
+		// each exporter opens its own gRPC connection on start
+		pool := &roundRobinTracesExporter{exporters: make([]exporter.Traces, 0, cfg.GRPCConnPoolSize)}
+		for i := 0; i < cfg.GRPCConnPoolSize; i++ {
+			exp, err := factory.CreateTracesExporter(ctx, set, config)
+			if err != nil {
+				return nil, err
+			}
+			pool.exporters = append(pool.exporters, exp)
+		}
+		return pool, nil
+	case ProtocolFile:
+		slog.Debug("instantiating file TracesReporter", "path", cfg.FilePath)
+		return newFileTracesExporter(&cfg)
+	case ProtocolZipkin:
+		slog.Debug("instantiating Zipkin TracesReporter")
+		return newZipkinTracesExporter(ctx, &cfg)
+	case ProtocolOpenCensus:
+		slog.Debug("instantiating OpenCensus TracesReporter")
+		return newOpenCensusTracesExporter(ctx, &cfg)
 	default:
-		slog.Error(fmt.Sprintf("invalid protocol value: %q. Accepted values are: %s, %s, %s",
-			proto, ProtocolGRPC, ProtocolHTTPJSON, ProtocolHTTPProtobuf))
+		slog.Error(fmt.Sprintf("invalid protocol value: %q. Accepted values are: %s, %s, %s, %s, %s, %s",
+			proto, ProtocolGRPC, ProtocolHTTPJSON, ProtocolHTTPProtobuf, ProtocolFile, ProtocolZipkin, ProtocolOpenCensus))
 		return nil, fmt.Errorf("invalid protocol value: %q", proto)
 	}
 
@@ -270,6 +1156,12 @@ func getTraceSettings(ctxInfo *global.ContextInfo, cfg TracesConfig, in trace.Sp
 		trace.WithSpanProcessor(bsp),
 		trace.WithSampler(cfg.Sampler.Implementation()),
 	)
+	return exporterSettings(provider)
+}
+
+// exporterSettings returns the settings of the collector exporters, whose internal telemetry
+// is traced with the given provider
+func exporterSettings(provider trace2.TracerProvider) exporter.CreateSettings {
 	telemetrySettings := component.TelemetrySettings{
 		Logger:         zap.NewNop(),
 		MeterProvider:  metric.NewMeterProvider(),
@@ -287,15 +1179,28 @@ func getTraceSettings(ctxInfo *global.ContextInfo, cfg TracesConfig, in trace.Sp
 	}
 }
 
-// GenerateTraces creates a ptrace.Traces from a request.Span
-func GenerateTraces(span *request.Span, userAttrs map[attr.Name]struct{}) ptrace.Traces {
-	t := span.Timings()
+// continuedTrace returns whether the span continues a trace that was propagated by an upstream service.
+// The trace ID can't be used for this, as the eBPF tracers generate their own trace IDs for the requests
+// without an incoming trace context. Only the server spans have an incoming parent, as the parent of the
+// client spans is the local request that invokes them.
+func continuedTrace(span *request.Span) bool {
+	return !span.IsClientSpan() && span.TraceID.IsValid() && span.ParentSpanID.IsValid()
+}
+
+// GenerateTraces creates a ptrace.Traces from a request.Span. The returned ptrace.Traces is empty if the
+// span is rejected because of the AttributeOverflowPolicy
+func GenerateTraces(cfg *TracesConfig, span *request.Span, userAttrs map[attr.Name]struct{}) ptrace.Traces {
+	t := cfg.spanTimings(span)
 	start := spanStartTime(t)
 	hasSubSpans := t.Start.After(start)
 	traces := ptrace.NewTraces()
 	rs := traces.ResourceSpans().AppendEmpty()
+	rs.SetSchemaUrl(cfg.resourceSchemaURL())
 	ss := rs.ScopeSpans().AppendEmpty()
-	resourceAttrs := attrsToMap(getResourceAttrs(span.ServiceID).Attributes())
+	for k, v := range cfg.ScopeAttributes {
+		ss.Scope().Attributes().PutStr(k, v)
+	}
+	resourceAttrs := attrsToMap(getResourceAttrs(span.ServiceID, cfg.resourceExtraAttrs(&span.ServiceID)...).Attributes())
 	resourceAttrs.PutStr(string(semconv.OTelLibraryNameKey), reporterName)
 	resourceAttrs.CopyTo(rs.Resource().Attributes())
 
@@ -310,10 +1215,13 @@ func GenerateTraces(span *request.Span, userAttrs map[attr.Name]struct{}) ptrace
 	} else if span.SpanID.IsValid() {
 		spanID = pcommon.SpanID(span.SpanID)
 	}
+	if cfg.MaxSubSpans > 0 {
+		capSubSpans(&ss, cfg.MaxSubSpans)
+	}
 
 	// Create a parent span for the whole request session
 	s := ss.Spans().AppendEmpty()
-	s.SetName(TraceName(span))
+	s.SetName(truncateName(TraceName(span, cfg.KnownHTTPMethods), cfg.MaxSpanNameLength))
 	s.SetKind(ptrace.SpanKind(spanKind(span)))
 	s.SetStartTimestamp(pcommon.NewTimestampFromTime(start))
 
@@ -323,11 +1231,32 @@ func GenerateTraces(span *request.Span, userAttrs map[attr.Name]struct{}) ptrace
 	if span.ParentSpanID.IsValid() {
 		s.SetParentSpanID(pcommon.SpanID(span.ParentSpanID))
 	}
+	if span.LinkSpanID.IsValid() {
+		link := s.Links().AppendEmpty()
+		link.SetTraceID(pcommon.TraceID(span.LinkTraceID))
+		link.SetSpanID(pcommon.SpanID(span.LinkSpanID))
+	}
 
 	// Set span attributes
-	attrs := traceAttributes(span, userAttrs)
+	attrs := trimSpanAttributes(s.Name(), traceAttributes(cfg, span, userAttrs), cfg.MaxSpanBytes)
+	attrs = renameAttributes(attrs, cfg.AttributeRenames)
 	m := attrsToMap(attrs)
 	m.CopyTo(s.Attributes())
+	if label := latencyBucket(cfg.LatencyBuckets, t.End.Sub(start)); label != "" {
+		s.Attributes().PutStr(string(attr.BeylaLatencyBucket), label)
+	}
+	if cfg.EmitObservedTimestamps {
+		s.Attributes().PutStr(string(attr.BeylaObservedRequestStart), t.RequestStart.Format(time.RFC3339Nano))
+		s.Attributes().PutStr(string(attr.BeylaObservedEnd), t.End.Format(time.RFC3339Nano))
+	}
+	if cfg.AnnotateContinuedTraces && continuedTrace(span) {
+		s.Attributes().PutBool(string(attr.BeylaTraceContinued), true)
+	}
+	addDerivedAttributes(cfg, span, s)
+	if !limitAttributes(cfg, s) {
+		// the span is rejected, so no span is returned
+		return ptrace.NewTraces()
+	}
 
 	// Set status code
 	statusCode := codeToStatusCode(SpanStatusCode(span))
@@ -340,7 +1269,7 @@ func GenerateTraces(span *request.Span, userAttrs map[attr.Name]struct{}) ptrace
 func createSubSpans(span *request.Span, parentSpanID pcommon.SpanID, traceID pcommon.TraceID, ss *ptrace.ScopeSpans, t request.Timings) {
 	// Create a child span showing the queue time
 	spQ := ss.Spans().AppendEmpty()
-	spQ.SetName("in queue")
+	spQ.SetName(subSpanInQueue)
 	spQ.SetStartTimestamp(pcommon.NewTimestampFromTime(t.RequestStart))
 	spQ.SetKind(ptrace.SpanKindInternal)
 	spQ.SetEndTimestamp(pcommon.NewTimestampFromTime(t.Start))
@@ -350,7 +1279,7 @@ func createSubSpans(span *request.Span, parentSpanID pcommon.SpanID, traceID pco
 
 	// Create a child span showing the processing time
 	spP := ss.Spans().AppendEmpty()
-	spP.SetName("processing")
+	spP.SetName(subSpanProcessing)
 	spP.SetStartTimestamp(pcommon.NewTimestampFromTime(t.Start))
 	spP.SetKind(ptrace.SpanKindInternal)
 	spP.SetEndTimestamp(pcommon.NewTimestampFromTime(t.End))
@@ -376,6 +1305,12 @@ func attrsToMap(attrs []attribute.KeyValue) pcommon.Map {
 			m.PutDouble(string(attr.Key), v)
 		case bool:
 			m.PutBool(string(attr.Key), v)
+		case []string:
+			s := m.PutEmptySlice(string(attr.Key))
+			s.EnsureCapacity(len(v))
+			for _, e := range v {
+				s.AppendEmpty().SetStr(e)
+			}
 		}
 	}
 	return m
@@ -511,52 +1446,93 @@ func SpanPeer(span *request.Span) string {
 	return span.Peer
 }
 
-func traceAttributes(span *request.Span, optionalAttrs map[attr.Name]struct{}) []attribute.KeyValue {
+func traceAttributes(cfg *TracesConfig, span *request.Span, optionalAttrs map[attr.Name]struct{}) []attribute.KeyValue {
 	var attrs []attribute.KeyValue
 
 	switch span.Type {
 	case request.EventTypeHTTP:
+		if isGRPCWeb(span) {
+			attrs = grpcWebAttributes(cfg, span)
+			break
+		}
 		attrs = []attribute.KeyValue{
-			request.HTTPRequestMethod(span.Method),
+			request.HTTPRequestMethod(cfg.KnownHTTPMethods.Normalize(span.Method)),
 			request.HTTPResponseStatusCode(span.Status),
 			request.HTTPUrlPath(span.Path),
-			request.ClientAddr(request.SpanPeer(span)),
-			request.ServerAddr(request.SpanHost(span)),
+			request.ClientAddr(cfg.spanPeer(span)),
+			request.ServerAddr(cfg.spanHost(span)),
 			request.ServerPort(span.HostPort),
 			request.HTTPRequestBodySize(int(span.ContentLength)),
 		}
+		attrs = appendHTTPMethodOriginal(attrs, span, cfg.KnownHTTPMethods)
 		if span.Route != "" {
 			attrs = append(attrs, semconv.HTTPRoute(span.Route))
 		}
+		attrs = appendContentTypes(attrs, span, optionalAttrs)
+		if _, ok := optionalAttrs[attr.HTTPResponseBodySize]; ok && span.ResponseLength > 0 {
+			attrs = append(attrs, request.HTTPResponseBodySize(int(span.ResponseLength)))
+		}
+		attrs = appendProtocolVersion(attrs, span, optionalAttrs)
+		attrs = appendRequestLine(attrs, cfg, span, optionalAttrs)
+		if _, ok := optionalAttrs[attr.EnduserID]; ok && span.EndUserID != "" {
+			attrs = append(attrs, semconv.EnduserID(endUserID(cfg, span)))
+		}
 	case request.EventTypeGRPC:
 		attrs = []attribute.KeyValue{
 			semconv.RPCMethod(span.Path),
 			semconv.RPCSystemGRPC,
 			semconv.RPCGRPCStatusCodeKey.Int(span.Status),
-			request.ClientAddr(request.SpanPeer(span)),
-			request.ServerAddr(request.SpanHost(span)),
+			request.ClientAddr(cfg.spanPeer(span)),
+			request.ServerAddr(cfg.spanHost(span)),
 			request.ServerPort(span.HostPort),
 		}
+		attrs = appendGRPCMetadata(attrs, cfg, span)
 	case request.EventTypeHTTPClient:
+		if isGRPCWeb(span) {
+			attrs = grpcWebAttributes(cfg, span)
+			break
+		}
 		attrs = []attribute.KeyValue{
-			request.HTTPRequestMethod(span.Method),
+			request.HTTPRequestMethod(cfg.KnownHTTPMethods.Normalize(span.Method)),
 			request.HTTPResponseStatusCode(span.Status),
 			request.HTTPUrlFull(span.Path),
-			request.ServerAddr(request.SpanHost(span)),
+			request.ServerAddr(cfg.spanHost(span)),
 			request.ServerPort(span.HostPort),
 			request.HTTPRequestBodySize(int(span.ContentLength)),
 		}
+		attrs = appendHTTPMethodOriginal(attrs, span, cfg.KnownHTTPMethods)
+		attrs = appendContentTypes(attrs, span, optionalAttrs)
+		if _, ok := optionalAttrs[attr.HTTPResponseBodySize]; ok && span.ResponseLength > 0 {
+			attrs = append(attrs, request.HTTPResponseBodySize(int(span.ResponseLength)))
+		}
+		// following the semantic conventions, the first attempt of a request does not report its resend count
+		if _, ok := optionalAttrs[attr.HTTPRequestResendCount]; ok && span.ResendCount > 0 {
+			attrs = append(attrs, request.HTTPRequestResendCount(span.ResendCount))
+		}
+		attrs = appendProtocolVersion(attrs, span, optionalAttrs)
+		attrs = appendRequestLine(attrs, cfg, span, optionalAttrs)
 	case request.EventTypeGRPCClient:
 		attrs = []attribute.KeyValue{
 			semconv.RPCMethod(span.Path),
 			semconv.RPCSystemGRPC,
 			semconv.RPCGRPCStatusCodeKey.Int(span.Status),
-			request.ServerAddr(request.SpanHost(span)),
+			request.ServerAddr(cfg.spanHost(span)),
 			request.ServerPort(span.HostPort),
 		}
+		attrs = appendGRPCMetadata(attrs, cfg, span)
 	case request.EventTypeSQLClient:
 		if _, ok := optionalAttrs[attr.IncludeDBStatement]; ok {
-			attrs = append(attrs, semconv.DBStatement(span.Statement))
+			statement := span.Statement
+			if cfg.NormalizeDBStatement {
+				statement = sqlprune.NormalizeStatement(statement)
+			}
+			attrs = append(attrs, semconv.DBStatement(statement))
+		}
+		// following the semantic conventions, single statements do not report the batch size
+		if _, ok := optionalAttrs[attr.DBOperationBatchSize]; ok {
+			if size := sqlprune.StatementsCount(span.Statement); size > 1 {
+				attrs = append(attrs, attr.DBOperationBatchSize.OTEL().Int(size))
+			}
 		}
 		operation := span.Method
 		if operation != "" {
@@ -568,13 +1544,145 @@ func traceAttributes(span *request.Span, optionalAttrs map[attr.Name]struct{}) [
 		}
 	}
 
+	if _, ok := optionalAttrs[attr.NetworkTransport]; ok {
+		attrs = append(attrs, attr.NetworkTransport.OTEL().String(networkTransport(span)))
+	}
+	if _, ok := optionalAttrs[attr.BeylaDetector]; ok && span.Detector != "" {
+		attrs = append(attrs, attr.BeylaDetector.OTEL().String(span.Detector))
+	}
+	if _, ok := optionalAttrs[attr.BeylaConnectionReused]; ok &&
+		span.ConnectionReuse != request.ConnectionReuseUnknown && spanKind(span) == trace2.SpanKindClient {
+		attrs = append(attrs, attr.BeylaConnectionReused.OTEL().Bool(span.ConnectionReuse == request.ConnectionReused))
+	}
+	if _, ok := optionalAttrs[attr.BeylaSamplingProbability]; ok && span.SamplingProbability > 0 {
+		attrs = append(attrs, attr.BeylaSamplingProbability.OTEL().Float64(span.SamplingProbability))
+	}
+	// the adjusted count is the number of spans that each sampled span represents
+	if _, ok := optionalAttrs[attr.BeylaAdjustedCount]; ok && span.SamplingProbability > 0 {
+		attrs = append(attrs, attr.BeylaAdjustedCount.OTEL().Float64(1/span.SamplingProbability))
+	}
+	if isSynthetic(cfg.SyntheticMatchers, span) {
+		attrs = append(attrs, attr.BeylaSynthetic.OTEL().Bool(true))
+	}
+	attrs = appendPeerEnrichment(attrs, cfg.PeerEnricher, span)
+	attrs = bucketSizes(cfg.RequestSizeBuckets, attrs)
+	if cfg.OmitDefaultPorts && span.HostPort == defaultPort(span) {
+		attrs = slices.DeleteFunc(attrs, func(kv attribute.KeyValue) bool {
+			return kv.Key == attr.ServerPort.OTEL()
+		})
+	}
+
+	return applySemconvCompat(cfg.SemconvCompatMode, span, attrs)
+}
+
+// appendContentTypes adds the captured content types. As any other HTTP header attribute, their
+// value is an array of strings
+func appendContentTypes(attrs []attribute.KeyValue, span *request.Span, optionalAttrs map[attr.Name]struct{}) []attribute.KeyValue {
+	if _, ok := optionalAttrs[attr.HTTPRequestContentType]; ok && span.RequestContentType != "" {
+		attrs = append(attrs, attr.HTTPRequestContentType.OTEL().StringSlice([]string{span.RequestContentType}))
+	}
+	if _, ok := optionalAttrs[attr.HTTPResponseContentType]; ok && span.ResponseContentType != "" {
+		attrs = append(attrs, attr.HTTPResponseContentType.OTEL().StringSlice([]string{span.ResponseContentType}))
+	}
+	return attrs
+}
+
+// appendProtocolVersion adds the detected HTTP version
+func appendProtocolVersion(attrs []attribute.KeyValue, span *request.Span, optionalAttrs map[attr.Name]struct{}) []attribute.KeyValue {
+	if _, ok := optionalAttrs[attr.NetworkProtocolVersion]; ok && span.ProtocolVersion != "" {
+		attrs = append(attrs, attr.NetworkProtocolVersion.OTEL().String(span.ProtocolVersion))
+	}
+	return attrs
+}
+
+// connectionSecure returns whether the connection of the span was encrypted with TLS, and false
+// as second value if it is unknown.
+func connectionSecure(span *request.Span) (secure bool, known bool) {
+	switch {
+	case span.ConnectionSecurity == request.ConnectionTLS:
+		return true, true
+	case span.ConnectionSecurity == request.ConnectionPlaintext:
+		return false, true
+	}
+	return false, false
+}
+
+// defaultPort returns the default server port for the scheme of the HTTP and gRPC spans,
+// or zero for the rest of spans. The connections are considered encrypted if they are known
+// to be secure, or the client request URL has the https scheme.
+func defaultPort(span *request.Span) int {
+	switch span.Type {
+	case request.EventTypeHTTP, request.EventTypeHTTPClient, request.EventTypeGRPC, request.EventTypeGRPCClient:
+		if secure, _ := connectionSecure(span); secure ||
+			(span.Type == request.EventTypeHTTPClient && strings.HasPrefix(span.Path, "https://")) {
+			return 443
+		}
+		return 80
+	}
+	return 0
+}
+
+// networkTransport returns the transport protocol of the span connection: UDP for HTTP/3 (QUIC)
+// and TCP for the rest of HTTP versions, gRPC and SQL connections. The connections through unix
+// sockets are not reported, as the probes only track the TCP sockets.
+func networkTransport(span *request.Span) string {
+	if span.ProtocolVersion == "3" {
+		return request.TransportUDP
+	}
+	return request.TransportTCP
+}
+
+// appendGRPCMetadata adds the captured values of the configured metadata keys,
+// following the rpc.grpc.request.metadata.<key> semantic convention
+func appendGRPCMetadata(attrs []attribute.KeyValue, cfg *TracesConfig, span *request.Span) []attribute.KeyValue {
+	if len(span.RequestHeaders) == 0 {
+		return attrs
+	}
+	for _, key := range cfg.CaptureGRPCMetadata {
+		key = strings.ToLower(key)
+		if values, ok := span.RequestHeaders[key]; ok {
+			attrs = append(attrs, attribute.StringSlice(grpcMetadataPrefix+key, values))
+		}
+	}
 	return attrs
 }
 
-func TraceName(span *request.Span) string {
+// endUserID returns the end user identifier of the span, hashed if the configuration requires it
+func endUserID(cfg *TracesConfig, span *request.Span) string {
+	if !cfg.HashEndUserID {
+		return span.EndUserID
+	}
+	sum := sha256.Sum256([]byte(span.EndUserID))
+	return hex.EncodeToString(sum[:])
+}
+
+const nameEllipsis = "..."
+
+// truncateName truncates the name so it does not exceed the given length in bytes, ending it with an
+// ellipsis and without splitting UTF-8 characters. A zero or negative length means unlimited.
+func truncateName(name string, maxLen int) string {
+	if maxLen <= 0 || len(name) <= maxLen {
+		return name
+	}
+	ellipsis := nameEllipsis
+	if maxLen <= len(ellipsis) {
+		ellipsis = ""
+	}
+	cut := maxLen - len(ellipsis)
+	for cut > 0 && !utf8.RuneStart(name[cut]) {
+		cut--
+	}
+	return name[:cut] + ellipsis
+}
+
+// TraceName returns the name of the span. The HTTP methods that aren't known are replaced by HTTP.
+func TraceName(span *request.Span, knownMethods request.HTTPMethods) string {
+	if isGRPCWeb(span) {
+		return grpcWebMethod(span)
+	}
 	switch span.Type {
 	case request.EventTypeHTTP:
-		name := span.Method
+		name := spanNameMethod(span.Method, knownMethods)
 		if span.Route != "" {
 			name += " " + span.Route
 		}
@@ -582,7 +1690,7 @@ func TraceName(span *request.Span) string {
 	case request.EventTypeGRPC, request.EventTypeGRPCClient:
 		return span.Path
 	case request.EventTypeHTTPClient:
-		return span.Method
+		return spanNameMethod(span.Method, knownMethods)
 	case request.EventTypeSQLClient:
 		// We don't have db.name, but follow "<db.operation> <db.name>.<db.sql.table_name>"
 		// or just "<db.operation>" if table is not known, otherwise just a fixed string.
@@ -609,6 +1717,24 @@ func spanKind(span *request.Span) trace2.SpanKind {
 	return trace2.SpanKindInternal
 }
 
+// appendPeerEnrichment adds the attributes returned by the enricher for the peer IP of the server spans,
+// sorted by key so the attributes order is stable
+func appendPeerEnrichment(attrs []attribute.KeyValue, enricher func(string) map[string]string, span *request.Span) []attribute.KeyValue {
+	if enricher == nil || span.Peer == "" || spanKind(span) != trace2.SpanKindServer {
+		return attrs
+	}
+	enriched := enricher(span.Peer)
+	keys := make([]string, 0, len(enriched))
+	for k := range enriched {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	for _, k := range keys {
+		attrs = append(attrs, attribute.String(k, enriched[k]))
+	}
+	return attrs
+}
+
 func spanStartTime(t request.Timings) time.Time {
 	realStart := t.RequestStart
 	if t.Start.Before(realStart) {
@@ -624,24 +1750,8 @@ func spanStartTime(t request.Timings) time.Time {
 // If, by some reason, Grafana changes its OTLP Gateway URL in a distant future, you can still point to the
 // correct URL with the OTLP_EXPORTER_... variables.
 func parseTracesEndpoint(cfg *TracesConfig) (*url.URL, bool, error) {
-	isCommon := false
-	endpoint := cfg.TracesEndpoint
-	if endpoint == "" {
-		isCommon = true
-		endpoint = cfg.CommonEndpoint
-		if endpoint == "" && cfg.Grafana != nil && cfg.Grafana.CloudZone != "" {
-			endpoint = cfg.Grafana.Endpoint()
-		}
-	}
-
-	murl, err := url.Parse(endpoint)
-	if err != nil {
-		return nil, isCommon, fmt.Errorf("parsing endpoint URL %s: %w", endpoint, err)
-	}
-	if murl.Scheme == "" || murl.Host == "" {
-		return nil, isCommon, fmt.Errorf("URL %q must have a scheme and a host", endpoint)
-	}
-	return murl, isCommon, nil
+	signal, common := cfg.signalEndpoints()
+	return parseEndpoint(signal, common, nil)
 }
 
 func getHTTPTracesEndpointOptions(cfg *TracesConfig) (otlpOptions, error) {
@@ -664,7 +1774,13 @@ func getHTTPTracesEndpointOptions(cfg *TracesConfig) (otlpOptions, error) {
 	// If the value is set from the OTEL_EXPORTER_OTLP_ENDPOINT common property, we need to add /v1/traces to the path
 	// otherwise, we leave the path that is explicitly set by the user
 	opts.URLPath = murl.Path
-	if isCommon {
+	if isCommon && cfg.TracesURLPath != "" {
+		if err := validateTracesURLPath(cfg.TracesURLPath); err != nil {
+			return opts, err
+		}
+		opts.URLPath = cfg.TracesURLPath
+		log.Debug("Specifying path", "path", opts.URLPath)
+	} else if isCommon {
 		if strings.HasSuffix(opts.URLPath, "/") {
 			opts.URLPath += "v1/traces"
 		} else {
@@ -679,6 +1795,7 @@ func getHTTPTracesEndpointOptions(cfg *TracesConfig) (otlpOptions, error) {
 	}
 
 	cfg.Grafana.setupOptions(&opts)
+	setupHeaders(cfg, &opts)
 
 	return opts, nil
 }
@@ -704,9 +1821,23 @@ func getGRPCTracesEndpointOptions(cfg *TracesConfig) (otlpOptions, error) {
 		opts.SkipTLSVerify = true
 	}
 
+	setupHeaders(cfg, &opts)
+
 	return opts, nil
 }
 
+func setupHeaders(cfg *TracesConfig, opts *otlpOptions) {
+	if len(cfg.headers) == 0 {
+		return
+	}
+	if opts.HTTPHeaders == nil {
+		opts.HTTPHeaders = map[string]string{}
+	}
+	for k, v := range cfg.headers {
+		opts.HTTPHeaders[k] = v
+	}
+}
+
 // HACK: at the time of writing this, the otelptracehttp API does not support explicitly
 // setting the protocol. They should be properly set via environment variables, but
 // if the user supplied the value via configuration file (and not via env vars), we override the environment.
@@ -0,0 +1,67 @@
+package otel
+
+import (
+	"slices"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.19.0"
+
+	attr "github.com/grafana/beyla/pkg/internal/export/attributes/names"
+)
+
+// spanOverheadBytes roughly accounts the serialized size of the fixed-length fields of a span
+// (trace and span IDs, timestamps, kind, status...)
+const spanOverheadBytes = 64
+
+// grpcMetadataPrefix is the prefix of the attributes that contain the captured gRPC metadata
+const grpcMetadataPrefix = "rpc.grpc.request.metadata."
+
+// trimmableAttr returns whether the attribute can be removed to fit the span in the MaxSpanBytes
+// budget. Only the optional attributes that might contain large values can be trimmed.
+func trimmableAttr(key attribute.Key) bool {
+	return key == semconv.DBStatementKey || strings.HasPrefix(string(key), grpcMetadataPrefix)
+}
+
+func estimateAttrBytes(a attribute.KeyValue) int {
+	return len(a.Key) + len(a.Value.Emit())
+}
+
+// trimSpanAttributes removes the largest trimmable attributes of a span until its estimated serialized
+// size fits in maxBytes, and marks it with the beyla.span_trimmed attribute. If the span still exceeds
+// the budget after removing all the trimmable attributes, it is sent as it is.
+func trimSpanAttributes(name string, attrs []attribute.KeyValue, maxBytes int) []attribute.KeyValue {
+	if maxBytes <= 0 {
+		return attrs
+	}
+	size := spanOverheadBytes + len(name)
+	var trimmable []int
+	for i := range attrs {
+		size += estimateAttrBytes(attrs[i])
+		if trimmableAttr(attrs[i].Key) {
+			trimmable = append(trimmable, i)
+		}
+	}
+	if size <= maxBytes || len(trimmable) == 0 {
+		return attrs
+	}
+	// largest attributes first
+	slices.SortFunc(trimmable, func(a, b int) int {
+		return estimateAttrBytes(attrs[b]) - estimateAttrBytes(attrs[a])
+	})
+	removed := map[int]struct{}{}
+	for _, i := range trimmable {
+		if size <= maxBytes {
+			break
+		}
+		size -= estimateAttrBytes(attrs[i])
+		removed[i] = struct{}{}
+	}
+	trimmed := make([]attribute.KeyValue, 0, len(attrs)-len(removed)+1)
+	for i := range attrs {
+		if _, ok := removed[i]; !ok {
+			trimmed = append(trimmed, attrs[i])
+		}
+	}
+	return append(trimmed, attr.BeylaSpanTrimmed.OTEL().Bool(true))
+}
@@ -0,0 +1,52 @@
+package otel
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.19.0"
+
+	attr "github.com/grafana/beyla/pkg/internal/export/attributes/names"
+	"github.com/grafana/beyla/pkg/internal/request"
+)
+
+func TestGenerateTraces_MaxSpanBytes(t *testing.T) {
+	span := makeSQLRequestSpan("SELECT * FROM users WHERE id IN (" + strings.Repeat("12345, ", 200) + "1)")
+	selection := map[attr.Name]struct{}{attr.IncludeDBStatement: {}}
+
+	traces := GenerateTraces(&TracesConfig{MaxSpanBytes: 512}, &span, selection)
+	attrs := traces.ResourceSpans().At(0).ScopeSpans().At(0).Spans().At(0).Attributes()
+	ensureTraceAttrNotExists(t, attrs, semconv.DBStatementKey)
+	ensureTraceStrAttr(t, attrs, semconv.DBOperationKey, "SELECT")
+	ensureTraceStrAttr(t, attrs, semconv.DBSQLTableKey, "users")
+	trimmed, ok := attrs.Get(string(attr.BeylaSpanTrimmed))
+	assert.True(t, ok)
+	assert.True(t, trimmed.Bool())
+
+	// spans within the budget, or without budget, are not trimmed
+	for _, cfg := range []TracesConfig{{MaxSpanBytes: 4096}, {}} {
+		traces = GenerateTraces(&cfg, &span, selection)
+		attrs = traces.ResourceSpans().At(0).ScopeSpans().At(0).Spans().At(0).Attributes()
+		ensureTraceStrAttr(t, attrs, semconv.DBStatementKey, span.Statement)
+		ensureTraceAttrNotExists(t, attrs, attr.BeylaSpanTrimmed.OTEL())
+	}
+}
+
+func TestTrimSpanAttributes_LargestFirst(t *testing.T) {
+	attrs := []attribute.KeyValue{
+		request.HTTPRequestMethod("GET"),
+		attribute.StringSlice(grpcMetadataPrefix+"small", []string{"abc"}),
+		attribute.StringSlice(grpcMetadataPrefix+"large", []string{strings.Repeat("x", 300)}),
+		attribute.StringSlice(grpcMetadataPrefix+"medium", []string{strings.Repeat("x", 100)}),
+		request.HTTPUrlPath("/" + strings.Repeat("p", 100)),
+	}
+	// removing the large attribute is enough to fit in the budget
+	trimmed := trimSpanAttributes("GET", attrs, 400)
+	assert.Equal(t, []attribute.KeyValue{attrs[0], attrs[1], attrs[3], attrs[4], attr.BeylaSpanTrimmed.OTEL().Bool(true)}, trimmed)
+
+	// the non-optional attributes are never removed, even if the span doesn't fit
+	trimmed = trimSpanAttributes("GET", attrs, 100)
+	assert.Equal(t, []attribute.KeyValue{attrs[0], attrs[4], attr.BeylaSpanTrimmed.OTEL().Bool(true)}, trimmed)
+}
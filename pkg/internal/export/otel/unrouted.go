@@ -0,0 +1,9 @@
+package otel
+
+import "github.com/grafana/beyla/pkg/internal/request"
+
+// unrouted returns true for the HTTP server spans without a route, whose names would
+// be derived from their raw paths.
+func unrouted(span *request.Span) bool {
+	return span.Type == request.EventTypeHTTP && span.Route == ""
+}
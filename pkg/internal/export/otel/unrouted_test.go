@@ -0,0 +1,38 @@
+package otel
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/grafana/beyla/pkg/internal/export/attributes"
+	"github.com/grafana/beyla/pkg/internal/request"
+)
+
+func TestDropUnroutedSpans(t *testing.T) {
+	spans := []request.Span{
+		{Type: request.EventTypeHTTP, Path: "/users/123", Route: "/users/{id}", Status: 200},
+		{Type: request.EventTypeHTTP, Path: "/users/456", Status: 200},
+		{Type: request.EventTypeHTTP, Path: "/unrouted-error", Status: 500},
+		{Type: request.EventTypeHTTPClient, Path: "/client", Status: 200},
+		{Type: request.EventTypeGRPC, Path: "/grpc", Status: 0},
+	}
+	consume := func(cfg TracesConfig) []string {
+		tr := newTracesOTELReceiver(context.Background(), cfg, nil, attributes.Selection{})
+		in := make(chan []request.Span, 1)
+		in <- spans
+		close(in)
+		var exported []string
+		tr.consume(in, func(s *request.Span) { exported = append(exported, s.Path) })
+		return exported
+	}
+
+	// error spans are kept, and the option only applies to HTTP server spans
+	assert.Equal(t, []string{"/users/123", "/unrouted-error", "/client", "/grpc"},
+		consume(TracesConfig{DropUnroutedSpans: true, AlwaysSampleErrors: true}))
+	assert.Equal(t, []string{"/users/123", "/client", "/grpc"},
+		consume(TracesConfig{DropUnroutedSpans: true}))
+	assert.Equal(t, []string{"/users/123", "/users/456", "/unrouted-error", "/client", "/grpc"},
+		consume(TracesConfig{}))
+}
@@ -0,0 +1,41 @@
+package otel
+
+import (
+	"context"
+	"strings"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/exporter/zipkinexporter"
+	"go.opentelemetry.io/collector/exporter"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+// default path of the Zipkin spans API, when the endpoint is defined from the common endpoint
+const zipkinSpansPath = "/api/v2/spans"
+
+// newZipkinTracesExporter instantiates the Zipkin exporter of the OpenTelemetry collector, which
+// submits the traces in the Zipkin V2 JSON format. Unlike the OTLP exporters, it does not trace
+// its own telemetry, as there is no OTLP endpoint to submit it to.
+func newZipkinTracesExporter(ctx context.Context, cfg *TracesConfig) (exporter.Traces, error) {
+	// the OTLP endpoint options are not used, as they would set the OTLP protocol environment variables
+	opts := otlpOptions{}
+	cfg.Grafana.setupOptions(&opts)
+	setupHeaders(cfg, &opts)
+	endpoint, isCommon, err := parseTracesEndpoint(cfg)
+	if err != nil {
+		return nil, err
+	}
+	// as for the OTLP exporters, the path is only added to the common endpoint
+	if isCommon {
+		endpoint.Path = strings.TrimSuffix(endpoint.Path, "/") + zipkinSpansPath
+	}
+	factory := zipkinexporter.NewFactory()
+	config := factory.CreateDefaultConfig().(*zipkinexporter.Config)
+	config.QueueSettings.Enabled = false
+	config.Endpoint = endpoint.String()
+	config.TLSSetting.InsecureSkipVerify = cfg.InsecureSkipVerify
+	config.Headers = convertHeaders(opts.HTTPHeaders)
+	if cfg.ExportTimeout > 0 {
+		config.Timeout = cfg.ExportTimeout
+	}
+	return factory.CreateTracesExporter(ctx, exporterSettings(noop.NewTracerProvider()), config)
+}
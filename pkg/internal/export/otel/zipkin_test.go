@@ -0,0 +1,51 @@
+package otel
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace"
+
+	attr "github.com/grafana/beyla/pkg/internal/export/attributes/names"
+	"github.com/grafana/beyla/pkg/internal/request"
+	"github.com/grafana/beyla/pkg/internal/svc"
+)
+
+func TestZipkinTracesExporter(t *testing.T) {
+	received := make(chan []map[string]any, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		assert.Equal(t, "/api/v2/spans", req.URL.Path)
+		assert.Equal(t, "application/json", req.Header.Get("Content-Type"))
+		assert.Equal(t, "bar", req.Header.Get("Foo"))
+		body, _ := io.ReadAll(req.Body)
+		var spans []map[string]any
+		assert.NoError(t, json.Unmarshal(body, &spans))
+		received <- spans
+		rw.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	cfg := TracesConfig{CommonEndpoint: server.URL, Protocol: ProtocolZipkin, headers: map[string]string{"Foo": "bar"}}
+	exp, err := getEndpointTracesExporter(context.Background(), cfg, nil)
+	require.NoError(t, err)
+	require.NoError(t, exp.Start(context.Background(), nil))
+	defer func() { require.NoError(t, exp.Shutdown(context.Background())) }()
+
+	span := request.Span{Type: request.EventTypeHTTP, Method: "GET", Route: "/users", Status: 200,
+		TraceID:   trace.TraceID{1, 2, 3},
+		ServiceID: svc.ID{Name: "users-svc"}}
+	require.NoError(t, exp.ConsumeTraces(context.Background(), GenerateTraces(&TracesConfig{}, &span, map[attr.Name]struct{}{})))
+	spans := <-received
+	require.Len(t, spans, 1)
+	// Zipkin reports the span names in lowercase
+	assert.Equal(t, "get /users", spans[0]["name"])
+	assert.Equal(t, "SERVER", spans[0]["kind"])
+	assert.Equal(t, "01020300000000000000000000000000", spans[0]["traceId"])
+	assert.Equal(t, "users-svc", spans[0]["localEndpoint"].(map[string]any)["serviceName"])
+}
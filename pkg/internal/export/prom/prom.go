@@ -106,6 +106,10 @@ type PrometheusConfig struct {
 	// Registry is only used for embedding Beyla within the Grafana Agent.
 	// It must be nil when Beyla runs as standalone
 	Registry *prometheus.Registry `yaml:"-"`
+
+	// KnownHTTPMethods needs to be explicitly set up from the traces configuration before building
+	// the graph, so the traces and the metrics report the same HTTP methods
+	KnownHTTPMethods request.HTTPMethods `yaml:"-"`
 }
 
 func (p PrometheusConfig) SpanMetricsEnabled() bool {
@@ -201,19 +205,20 @@ func newReporter(
 		return nil, fmt.Errorf("selecting metrics attributes: %w", err)
 	}
 
-	attrHTTPDuration := attributes.PrometheusGetters(request.SpanPromGetters,
+	spanGetters := request.SpanPromGetters(cfg.KnownHTTPMethods)
+	attrHTTPDuration := attributes.PrometheusGetters(spanGetters,
 		attrsProvider.For(attributes.HTTPServerDuration))
-	attrHTTPClientDuration := attributes.PrometheusGetters(request.SpanPromGetters,
+	attrHTTPClientDuration := attributes.PrometheusGetters(spanGetters,
 		attrsProvider.For(attributes.HTTPClientDuration))
-	attrHTTPRequestSize := attributes.PrometheusGetters(request.SpanPromGetters,
+	attrHTTPRequestSize := attributes.PrometheusGetters(spanGetters,
 		attrsProvider.For(attributes.HTTPServerRequestSize))
-	attrHTTPClientRequestSize := attributes.PrometheusGetters(request.SpanPromGetters,
+	attrHTTPClientRequestSize := attributes.PrometheusGetters(spanGetters,
 		attrsProvider.For(attributes.HTTPClientRequestSize))
-	attrGRPCDuration := attributes.PrometheusGetters(request.SpanPromGetters,
+	attrGRPCDuration := attributes.PrometheusGetters(spanGetters,
 		attrsProvider.For(attributes.RPCServerDuration))
-	attrGRPCClientDuration := attributes.PrometheusGetters(request.SpanPromGetters,
+	attrGRPCClientDuration := attributes.PrometheusGetters(spanGetters,
 		attrsProvider.For(attributes.RPCClientDuration))
-	attrSQLClientDuration := attributes.PrometheusGetters(request.SpanPromGetters,
+	attrSQLClientDuration := attributes.PrometheusGetters(spanGetters,
 		attrsProvider.For(attributes.HTTPServerDuration))
 
 	// If service name is not explicitly set, we take the service name as set by the
@@ -504,7 +509,7 @@ func (r *metricsReporter) labelValuesSpans(span *request.Span) []string {
 	return []string{
 		span.ServiceID.Name,
 		span.ServiceID.Namespace,
-		otel.TraceName(span),
+		otel.TraceName(span, r.cfg.KnownHTTPMethods),
 		strconv.Itoa(int(otel.SpanStatusCode(span))),
 		otel.SpanKindString(span),
 		span.ServiceID.Instance,
@@ -26,6 +26,33 @@ type Reporter interface {
 	OTELTraceExport(i int)
 	// OTELTraceExportError is invoked every time the OpenTelemetry Traces export fails with an error
 	OTELTraceExportError(err error)
+	// OTELTraceSamplingDecision is invoked every time a traces sampler decides whether a span is kept or dropped.
+	// The sampler argument distinguishes the decisions of the active sampler from other samplers that
+	// are evaluated only for accounting (e.g. a shadow sampler).
+	OTELTraceSamplingDecision(sampler string, keep bool)
+	// OTELTraceInvalidTimestamps is invoked every time a span with invalid timestamps is found. The action
+	// argument specifies whether the span was dropped or its timestamps were clamped.
+	OTELTraceInvalidTimestamps(action string)
+	// OTELTraceExportTimeout is invoked every time a traces submission is cancelled because it
+	// exceeded the configured export call timeout
+	OTELTraceExportTimeout()
+	// OTELTraceQueueOverflow is invoked every time a span is dropped because the traces export queue
+	// is full. The policy argument specifies the configured queue overflow policy.
+	OTELTraceQueueOverflow(policy string)
+	// OTELTraceDuplicateSpan is invoked every time a span is dropped because it duplicates a span
+	// that was received within the configured deduplication window
+	OTELTraceDuplicateSpan()
+	// OTELTraceServiceIDConflict is invoked every time a span has a different service identity than the
+	// identity previously seen for the same connection
+	OTELTraceServiceIDConflict()
+	// OTELTraceRejectedSpan is invoked every time a span is dropped because its attributes exceed the
+	// configured limit, with the reject_span attribute overflow policy
+	OTELTraceRejectedSpan()
+	// OTELTraceExportBytes is invoked every time a traces submission is measured, with its serialized size in bytes
+	OTELTraceExportBytes(size int)
+	// OTELTraceSuppressedLog is invoked every time a repeated traces export log line is omitted by the
+	// rate-limited logger. The level argument specifies the level of the omitted log line.
+	OTELTraceSuppressedLog(level string)
 	// PrometheusRequest is invoked every time the Prometheus exporter is invoked, for a given port and path
 	PrometheusRequest(port, path string)
 }
@@ -33,10 +60,19 @@ type Reporter interface {
 // NoopReporter is a metrics Reporter that just does nothing
 type NoopReporter struct{}
 
-func (n NoopReporter) Start(_ context.Context)       {}
-func (n NoopReporter) TracerFlush(_ int)             {}
-func (n NoopReporter) OTELMetricExport(_ int)        {}
-func (n NoopReporter) OTELMetricExportError(_ error) {}
-func (n NoopReporter) OTELTraceExport(_ int)         {}
-func (n NoopReporter) OTELTraceExportError(_ error)  {}
-func (n NoopReporter) PrometheusRequest(_, _ string) {}
+func (n NoopReporter) Start(_ context.Context)                    {}
+func (n NoopReporter) TracerFlush(_ int)                          {}
+func (n NoopReporter) OTELMetricExport(_ int)                     {}
+func (n NoopReporter) OTELMetricExportError(_ error)              {}
+func (n NoopReporter) OTELTraceExport(_ int)                      {}
+func (n NoopReporter) OTELTraceExportError(_ error)               {}
+func (n NoopReporter) OTELTraceSamplingDecision(_ string, _ bool) {}
+func (n NoopReporter) OTELTraceInvalidTimestamps(_ string)        {}
+func (n NoopReporter) OTELTraceExportTimeout()                    {}
+func (n NoopReporter) OTELTraceQueueOverflow(_ string)            {}
+func (n NoopReporter) OTELTraceDuplicateSpan()                    {}
+func (n NoopReporter) OTELTraceExportBytes(_ int)                 {}
+func (n NoopReporter) OTELTraceServiceIDConflict()                {}
+func (n NoopReporter) OTELTraceRejectedSpan()                     {}
+func (n NoopReporter) OTELTraceSuppressedLog(_ string)            {}
+func (n NoopReporter) PrometheusRequest(_, _ string)              {}
@@ -14,6 +14,9 @@ import (
 // TODO: let users override it or create it from the batch_length value
 var pipelineBufferLengths = []float64{0, 10, 20, 40, 80, 160, 320}
 
+// exportPayloadSizes buckets for histogram metrics about the serialized size of the trace submissions, from 256B to 4MB
+var exportPayloadSizes = prometheus.ExponentialBuckets(256, 4, 8)
+
 type PrometheusConfig struct {
 	Port int    `yaml:"port,omitempty" env:"BEYLA_INTERNAL_METRICS_PROMETHEUS_PORT"`
 	Path string `yaml:"path,omitempty" env:"BEYLA_INTERNAL_METRICS_PROMETHEUS_PATH"`
@@ -27,6 +30,15 @@ type PrometheusReporter struct {
 	otelMetricExportErrs *prometheus.CounterVec
 	otelTraceExports     prometheus.Counter
 	otelTraceExportErrs  *prometheus.CounterVec
+	otelTraceSampling    *prometheus.CounterVec
+	otelTraceInvalidTS   *prometheus.CounterVec
+	otelTraceTimeouts    prometheus.Counter
+	otelTraceOverflows   *prometheus.CounterVec
+	otelTraceDuplicates  prometheus.Counter
+	otelTraceBytes       prometheus.Histogram
+	otelTraceSvcConflict prometheus.Counter
+	otelTraceRejected    prometheus.Counter
+	otelTraceSuppressed  *prometheus.CounterVec
 	prometheusRequests   *prometheus.CounterVec
 }
 
@@ -57,6 +69,46 @@ func NewPrometheusReporter(cfg *PrometheusConfig, manager *connector.PrometheusM
 			Name: "otel_trace_export_errors",
 			Help: "error count on each failed OTEL trace export",
 		}, []string{"error"}),
+		otelTraceSampling: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "otel_trace_sampling_decisions",
+			Help: "sampling decisions taken by the OTEL traces samplers, for each span",
+		}, []string{"sampler", "decision"}),
+		otelTraceInvalidTS: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "otel_trace_invalid_timestamps",
+			Help: "spans with invalid timestamps, by the action taken (drop or clamp)",
+		}, []string{"action"}),
+		otelTraceTimeouts: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "otel_trace_export_timeouts",
+			Help: "OTEL trace submissions cancelled because they exceeded the export call timeout",
+		}),
+		otelTraceOverflows: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "otel_trace_queue_overflows",
+			Help: "spans dropped because the OTEL traces export queue was full, by overflow policy",
+		}, []string{"policy"}),
+		otelTraceDuplicates: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "otel_trace_duplicate_spans",
+			Help: "spans dropped because they duplicate a span received within the deduplication window",
+		}),
+		otelTraceBytes: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Name:                            "otel_trace_export_bytes",
+			Help:                            "serialized size, in bytes, of the trace submissions to the OTEL exporter",
+			Buckets:                         exportPayloadSizes,
+			NativeHistogramBucketFactor:     1.1,
+			NativeHistogramMaxBucketNumber:  100,
+			NativeHistogramMinResetDuration: 1 * time.Hour,
+		}),
+		otelTraceSvcConflict: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "otel_trace_service_id_conflicts",
+			Help: "spans whose service identity differs from the identity previously seen for the same connection",
+		}),
+		otelTraceRejected: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "otel_trace_rejected_spans",
+			Help: "spans that are not exported because their attributes exceed the configured limit",
+		}),
+		otelTraceSuppressed: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "otel_trace_suppressed_logs",
+			Help: "repeated OTEL traces export log lines that are omitted by the rate-limited logger, by log level",
+		}, []string{"level"}),
 		prometheusRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
 			Name: "prometheus_http_requests",
 			Help: "requests towards the Prometheus Scrape endpoint",
@@ -68,6 +120,15 @@ func NewPrometheusReporter(cfg *PrometheusConfig, manager *connector.PrometheusM
 		pr.otelMetricExportErrs,
 		pr.otelTraceExports,
 		pr.otelTraceExportErrs,
+		pr.otelTraceSampling,
+		pr.otelTraceInvalidTS,
+		pr.otelTraceTimeouts,
+		pr.otelTraceOverflows,
+		pr.otelTraceDuplicates,
+		pr.otelTraceBytes,
+		pr.otelTraceSvcConflict,
+		pr.otelTraceRejected,
+		pr.otelTraceSuppressed,
 		pr.prometheusRequests)
 
 	return pr
@@ -97,6 +158,46 @@ func (p *PrometheusReporter) OTELTraceExportError(err error) {
 	p.otelTraceExportErrs.WithLabelValues(err.Error()).Inc()
 }
 
+func (p *PrometheusReporter) OTELTraceSamplingDecision(sampler string, keep bool) {
+	decision := "drop"
+	if keep {
+		decision = "keep"
+	}
+	p.otelTraceSampling.WithLabelValues(sampler, decision).Inc()
+}
+
+func (p *PrometheusReporter) OTELTraceInvalidTimestamps(action string) {
+	p.otelTraceInvalidTS.WithLabelValues(action).Inc()
+}
+
+func (p *PrometheusReporter) OTELTraceExportTimeout() {
+	p.otelTraceTimeouts.Inc()
+}
+
+func (p *PrometheusReporter) OTELTraceQueueOverflow(policy string) {
+	p.otelTraceOverflows.WithLabelValues(policy).Inc()
+}
+
+func (p *PrometheusReporter) OTELTraceDuplicateSpan() {
+	p.otelTraceDuplicates.Inc()
+}
+
+func (p *PrometheusReporter) OTELTraceExportBytes(size int) {
+	p.otelTraceBytes.Observe(float64(size))
+}
+
+func (p *PrometheusReporter) OTELTraceServiceIDConflict() {
+	p.otelTraceSvcConflict.Inc()
+}
+
+func (p *PrometheusReporter) OTELTraceRejectedSpan() {
+	p.otelTraceRejected.Inc()
+}
+
+func (p *PrometheusReporter) OTELTraceSuppressedLog(level string) {
+	p.otelTraceSuppressed.WithLabelValues(level).Inc()
+}
+
 func (p *PrometheusReporter) PrometheusRequest(port, path string) {
 	p.prometheusRequests.WithLabelValues(port, path).Inc()
 }
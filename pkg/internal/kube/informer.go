@@ -61,6 +61,8 @@ type PodInfo struct {
 	StartTimeStr string
 	ContainerIDs []string
 	IPs          []string
+	// ContainerImages contains the image reference of each container, by container ID
+	ContainerImages map[string]string
 }
 
 type ReplicaSetInfo struct {
@@ -126,9 +128,11 @@ func (k *Metadata) initPodInformer(informerFactory informers.SharedInformerFacto
 			len(pod.Status.ContainerStatuses)+
 				len(pod.Status.InitContainerStatuses)+
 				len(pod.Status.EphemeralContainerStatuses))
+		containerImages := make(map[string]string, len(pod.Status.ContainerStatuses))
 		for i := range pod.Status.ContainerStatuses {
-			containerIDs = append(containerIDs,
-				rmContainerIDSchema(pod.Status.ContainerStatuses[i].ContainerID))
+			cid := rmContainerIDSchema(pod.Status.ContainerStatuses[i].ContainerID)
+			containerIDs = append(containerIDs, cid)
+			containerImages[cid] = pod.Status.ContainerStatuses[i].Image
 		}
 		for i := range pod.Status.InitContainerStatuses {
 			containerIDs = append(containerIDs,
@@ -162,11 +166,12 @@ func (k *Metadata) initPodInformer(informerFactory informers.SharedInformerFacto
 				UID:       pod.UID,
 				Labels:    pod.Labels,
 			},
-			Owner:        owner,
-			NodeName:     pod.Spec.NodeName,
-			StartTimeStr: startTime,
-			ContainerIDs: containerIDs,
-			IPs:          ips,
+			Owner:           owner,
+			NodeName:        pod.Spec.NodeName,
+			StartTimeStr:    startTime,
+			ContainerIDs:    containerIDs,
+			IPs:             ips,
+			ContainerImages: containerImages,
 		}, nil
 	}); err != nil {
 		return fmt.Errorf("can't set pods transform: %w", err)
@@ -329,7 +334,8 @@ func (k *Metadata) initInformers(ctx context.Context, client kubernetes.Interfac
 // to report as owner.
 func (k *Metadata) FetchPodOwnerInfo(pod *PodInfo) {
 	if pod.Owner != nil && pod.Owner.Type == OwnerReplicaSet {
-		if rsi, ok := k.GetReplicaSetInfo(pod.Namespace, pod.Owner.Name); ok {
+		// the ReplicaSets that are not managed by a Deployment have an empty DeploymentName
+		if rsi, ok := k.GetReplicaSetInfo(pod.Namespace, pod.Owner.Name); ok && rsi.DeploymentName != "" {
 			pod.Owner.Owner = &Owner{Type: OwnerDeployment, Name: rsi.DeploymentName}
 		}
 	}
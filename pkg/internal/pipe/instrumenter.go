@@ -2,6 +2,7 @@ package pipe
 
 import (
 	"context"
+	"log/slog"
 
 	"github.com/mariomac/pipes/pipe"
 
@@ -108,13 +109,23 @@ func newGraphBuilder(ctx context.Context, config *beyla.Config, ctxInfo *global.
 	pipe.AddMiddleProvider(gnb, router, transform.RoutesProvider(config.Routes))
 	pipe.AddMiddleProvider(gnb, kubernetes, transform.KubeDecoratorProvider(ctxInfo, &config.Attributes.Kubernetes))
 	pipe.AddMiddleProvider(gnb, nameResolver, transform.NameResolutionProvider(gb.ctxInfo, config.NameResolver))
-	pipe.AddMiddleProvider(gnb, attrFilter, filter.ByAttribute(config.Filters.Application, spanPtrPromGetters))
+	pipe.AddMiddleProvider(gnb, attrFilter, filter.ByAttribute(config.Filters.Application, spanPtrPromGetters(config.Traces.KnownHTTPMethods)))
 	config.Metrics.Grafana = &gb.config.Grafana.OTLP
+	// the known HTTP methods apply to both the traces and the metrics
+	config.Metrics.KnownHTTPMethods = config.Traces.KnownHTTPMethods
+	config.Prometheus.KnownHTTPMethods = config.Traces.KnownHTTPMethods
+	config.TracesReceiver.KnownHTTPMethods = config.Traces.KnownHTTPMethods
 	pipe.AddFinalProvider(gnb, otelMetrics, otel.ReportMetrics(ctx, gb.ctxInfo, &config.Metrics, config.Attributes.Select))
 	config.Traces.Grafana = &gb.config.Grafana.OTLP
+	if config.Traces.EmitConfigFingerprint {
+		var err error
+		if config.Traces.ConfigFingerprint, err = config.Fingerprint(); err != nil {
+			slog.Warn("can't calculate the configuration fingerprint", "error", err)
+		}
+	}
 	pipe.AddFinalProvider(gnb, otelTraces, otel.TracesReceiver(ctx, config.Traces, gb.ctxInfo, config.Attributes.Select))
 	pipe.AddFinalProvider(gnb, prometheus, prom.PrometheusEndpoint(ctx, gb.ctxInfo, &config.Prometheus, config.Attributes.Select))
-	pipe.AddFinalProvider(gnb, alloyTraces, alloy.TracesReceiver(ctx, &config.TracesReceiver, config.Attributes.Select))
+	pipe.AddFinalProvider(gnb, alloyTraces, alloy.TracesReceiver(ctx, &config.TracesReceiver, gb.ctxInfo, config.Attributes.Select))
 
 	pipe.AddFinalProvider(gnb, noop, debug.NoopNode(config.Noop))
 	pipe.AddFinalProvider(gnb, printer, debug.PrinterNode(config.Printer))
@@ -154,9 +165,12 @@ func (i *Instrumenter) Run(ctx context.Context) {
 // spanPtrPromGetters adapts the invocation of SpanPromGetters to work with a request.Span value
 // instead of a *request.Span pointer. This is a convenience method created to avoid having to
 // rewrite the pipeline types from []request.Span types to []*request.Span
-func spanPtrPromGetters(name attr.Name) (attributes.Getter[request.Span, string], bool) {
-	if ptrGetter, ok := request.SpanPromGetters(name); ok {
-		return func(span request.Span) string { return ptrGetter(&span) }, true
+func spanPtrPromGetters(knownMethods request.HTTPMethods) attributes.NamedGetters[request.Span, string] {
+	ptrGetters := request.SpanPromGetters(knownMethods)
+	return func(name attr.Name) (attributes.Getter[request.Span, string], bool) {
+		if ptrGetter, ok := ptrGetters(name); ok {
+			return func(span request.Span) string { return ptrGetter(&span) }, true
+		}
+		return nil, false
 	}
-	return nil, false
 }
@@ -103,6 +103,39 @@ func TestBasicPipeline(t *testing.T) {
 
 }
 
+func TestBasicPipeline_KnownHTTPMethods(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	tc, err := collector.Start(ctx)
+	require.NoError(t, err)
+
+	gb := newGraphBuilder(ctx, &beyla.Config{
+		Metrics: otel.MetricsConfig{
+			Features:        []string{otel.FeatureApplication},
+			MetricsEndpoint: tc.ServerEndpoint, Interval: 10 * time.Millisecond,
+			ReportersCacheLen: 16,
+		},
+		// the known methods of the traces configuration also apply to the metrics
+		Traces:     otel.TracesConfig{KnownHTTPMethods: request.HTTPMethods{"PROPFIND"}},
+		Attributes: beyla.Attributes{Select: allMetrics},
+	}, gctx(0), make(<-chan []request.Span))
+
+	pipe.AddStart(gb.builder, tracesReader,
+		func(out chan<- []request.Span) {
+			out <- newRequest("foo-svc", 1, "PROPFIND", "/foo/bar", "1.1.1.1:3456", 207)
+			time.Sleep(testTimeout)
+		},
+	)
+	pipe, err := gb.buildGraph()
+	require.NoError(t, err)
+
+	go pipe.Run(ctx)
+
+	event := testutil.ReadChannel(t, tc.Records, testTimeout)
+	assert.Equal(t, "PROPFIND", event.Attributes[string(attr.HTTPRequestMethod)])
+}
+
 func TestTracerPipeline(t *testing.T) {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
@@ -165,14 +198,68 @@ func TestTracerReceiverPipeline(t *testing.T) {
 
 	go pipe.Run(ctx)
 
-	event := testutil.ReadChannel(t, tc.TraceRecords, testTimeout)
+	event := withoutDistroAttrs(t, testutil.ReadChannel(t, tc.TraceRecords, testTimeout))
 	matchInnerTraceEvent(t, "in queue", event)
-	event = testutil.ReadChannel(t, tc.TraceRecords, testTimeout)
+	event = withoutDistroAttrs(t, testutil.ReadChannel(t, tc.TraceRecords, testTimeout))
 	matchInnerTraceEvent(t, "processing", event)
-	event = testutil.ReadChannel(t, tc.TraceRecords, testTimeout)
+	event = withoutDistroAttrs(t, testutil.ReadChannel(t, tc.TraceRecords, testTimeout))
 	matchTraceEvent(t, "GET", event)
 }
 
+// the traces exporter modifies the spans (e.g. correlating the client spans with their server span) while
+// the metrics exporter and the printer read the same batch. Running this test with -race verifies that the
+// traces exporter doesn't modify the shared spans.
+func TestTracesAndMetricsPipeline(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	tc, err := collector.Start(ctx)
+	require.NoError(t, err)
+
+	gb := newGraphBuilder(ctx, &beyla.Config{
+		Metrics: otel.MetricsConfig{
+			Features:        []string{otel.FeatureApplication},
+			MetricsEndpoint: tc.ServerEndpoint, Interval: 10 * time.Millisecond,
+			ReportersCacheLen: 16,
+		},
+		Traces: otel.TracesConfig{
+			BatchTimeout:      10 * time.Millisecond,
+			TracesEndpoint:    tc.ServerEndpoint,
+			ReportersCacheLen: 16,
+			CorrelateRequests: true,
+			EnableSpanLinks:   true,
+		},
+		Printer:    true,
+		Attributes: beyla.Attributes{Select: allMetrics},
+	}, gctx(0), make(<-chan []request.Span))
+	pipe.AddStart(gb.builder, tracesReader,
+		func(out chan<- []request.Span) {
+			server := newRequest("foo-svc", 1, "GET", "/foo/bar", "1.1.1.1:3456", 200)
+			client := newRequestWithTiming("foo-svc", 2, request.EventTypeHTTPClient, "GET", "/backend", "2.2.2.2:8080", 200, 2, 2, 3)
+			out <- append(server, client...)
+			// closing prematurely the input node would finish the whole graph processing
+			// and OTEL exporters could be closed, so we wait.
+			time.Sleep(testTimeout)
+		})
+	pipe, err := gb.buildGraph()
+	require.NoError(t, err)
+
+	go pipe.Run(ctx)
+
+	testutil.ReadChannel(t, tc.Records, testTimeout)
+	testutil.ReadChannel(t, tc.TraceRecords, testTimeout)
+}
+
+// withoutDistroAttrs verifies and removes the telemetry.distro resource attributes, which are
+// added to the traces forwarded to Alloy, as they are generated with the default options
+func withoutDistroAttrs(t *testing.T, event collector.TraceRecord) collector.TraceRecord {
+	assert.Equal(t, "beyla", event.ResourceAttributes["telemetry.distro.name"])
+	assert.NotEmpty(t, event.ResourceAttributes["telemetry.distro.version"])
+	delete(event.ResourceAttributes, "telemetry.distro.name")
+	delete(event.ResourceAttributes, "telemetry.distro.version")
+	return event
+}
+
 func BenchmarkTestTracerPipeline(b *testing.B) {
 	for i := 0; i < b.N; i++ {
 		ctx, cancel := context.WithCancel(context.Background())
@@ -0,0 +1,66 @@
+package request
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+)
+
+const authorizationHeader = "authorization"
+
+// SetEndUserFromHeaders sets the EndUserID of a server span from the captured Authorization request
+// header: the "sub" claim of a Bearer JSON Web Token, or the user name of the Basic authentication.
+// The token signature is not verified, as it is the responsibility of the instrumented service.
+func (s *Span) SetEndUserFromHeaders() {
+	if s.EndUserID != "" || s.IsClientSpan() {
+		return
+	}
+	values := s.RequestHeaders[authorizationHeader]
+	if len(values) == 0 {
+		return
+	}
+	scheme, credentials, ok := strings.Cut(strings.TrimSpace(values[0]), " ")
+	if !ok {
+		return
+	}
+	credentials = strings.TrimSpace(credentials)
+	switch strings.ToLower(scheme) {
+	case "bearer":
+		s.EndUserID = jwtSubject(credentials)
+	case "basic":
+		s.EndUserID = basicAuthUser(credentials)
+	}
+}
+
+// jwtSubject returns the "sub" claim of a JSON Web Token, or an empty string if the token
+// can't be decoded (e.g. because the captured header was truncated)
+func jwtSubject(token string) string {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return ""
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return ""
+	}
+	claims := struct {
+		Sub string `json:"sub"`
+	}{}
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return ""
+	}
+	return claims.Sub
+}
+
+// basicAuthUser returns the user name of the Basic authentication credentials
+func basicAuthUser(credentials string) string {
+	decoded, err := base64.StdEncoding.DecodeString(credentials)
+	if err != nil {
+		return ""
+	}
+	user, _, ok := strings.Cut(string(decoded), ":")
+	if !ok {
+		return ""
+	}
+	return user
+}
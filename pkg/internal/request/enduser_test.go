@@ -0,0 +1,45 @@
+package request
+
+import (
+	"encoding/base64"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetEndUserFromHeaders(t *testing.T) {
+	jwt := func(payload string) string {
+		return "eyJhbGciOiJIUzI1NiJ9." + base64.RawURLEncoding.EncodeToString([]byte(payload)) + ".c2lnbmF0dXJl"
+	}
+	endUser := func(spanType EventType, authorization string) string {
+		span := Span{Type: spanType, RequestHeaders: map[string][]string{authorizationHeader: {authorization}}}
+		span.SetEndUserFromHeaders()
+		return span.EndUserID
+	}
+
+	assert.Equal(t, "user-123", endUser(EventTypeHTTP, "Bearer "+jwt(`{"sub":"user-123","iat":1516239022}`)))
+	assert.Equal(t, "alice", endUser(EventTypeHTTP, "Basic "+base64.StdEncoding.EncodeToString([]byte("alice:secret"))))
+	assert.Equal(t, "alice", endUser(EventTypeHTTP, "basic  "+base64.StdEncoding.EncodeToString([]byte("alice:secret"))))
+
+	// client spans carry the credentials of the instrumented service, not of its end user
+	assert.Empty(t, endUser(EventTypeHTTPClient, "Bearer "+jwt(`{"sub":"user-123"}`)))
+	for _, invalid := range []string{
+		"",
+		"Bearer",
+		"Bearer " + jwt(`{"iat":1516239022}`),
+		// truncated token
+		"Bearer eyJhbGciOiJIUzI1NiJ9.eyJzdWIiOiJ1c2VyLTEyMyIsImlhdCI6MTUx",
+		"Bearer not-a-jwt",
+		"Basic " + base64.StdEncoding.EncodeToString([]byte("no-password")),
+		"Basic !!!",
+		"Digest username=\"alice\"",
+	} {
+		assert.Emptyf(t, endUser(EventTypeHTTP, invalid), "expected no end user for %q", invalid)
+	}
+
+	// an already set end user is not overridden
+	span := Span{Type: EventTypeHTTP, EndUserID: "bob",
+		RequestHeaders: map[string][]string{authorizationHeader: {"Bearer " + jwt(`{"sub":"user-123"}`)}}}
+	span.SetEndUserFromHeaders()
+	assert.Equal(t, "bob", span.EndUserID)
+}
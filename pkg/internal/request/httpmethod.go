@@ -0,0 +1,31 @@
+package request
+
+import "slices"
+
+// HTTPMethodOther replaces the unknown HTTP methods, following the semantic conventions
+const HTTPMethodOther = "_OTHER"
+
+// standardHTTPMethods are the methods defined in RFC9110 and RFC5789
+var standardHTTPMethods = map[string]struct{}{
+	"CONNECT": {}, "DELETE": {}, "GET": {}, "HEAD": {}, "OPTIONS": {}, "PATCH": {}, "POST": {}, "PUT": {}, "TRACE": {},
+}
+
+// HTTPMethods extends the standard HTTP methods with the custom methods (e.g. WebDAV's PROPFIND) that
+// are reported as they are in the traces and metrics. A nil value only accepts the standard methods.
+type HTTPMethods []string
+
+// Known returns whether the method is either standard or one of the extra known methods.
+// The comparison is case-sensitive, as in the semantic conventions.
+func (h HTTPMethods) Known(method string) bool {
+	_, ok := standardHTTPMethods[method]
+	return ok || slices.Contains(h, method)
+}
+
+// Normalize returns the value of the http.request.method attribute. Following the
+// semantic conventions, the unknown methods are reported as _OTHER.
+func (h HTTPMethods) Normalize(method string) string {
+	if h.Known(method) {
+		return method
+	}
+	return HTTPMethodOther
+}
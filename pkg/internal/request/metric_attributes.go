@@ -38,6 +38,14 @@ func HTTPRequestBodySize(val int) attribute.KeyValue {
 	return attribute.Key(attr.HTTPRequestBodySize).Int(val)
 }
 
+func HTTPResponseBodySize(val int) attribute.KeyValue {
+	return attribute.Key(attr.HTTPResponseBodySize).Int(val)
+}
+
+func HTTPRequestResendCount(val int) attribute.KeyValue {
+	return attribute.Key(attr.HTTPRequestResendCount).Int(val)
+}
+
 func SpanKindMetric(val string) attribute.KeyValue {
 	return attribute.Key(attr.SpanKind).String(val)
 }
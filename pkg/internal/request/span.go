@@ -1,6 +1,7 @@
 package request
 
 import (
+	"sync"
 	"time"
 	"unicode/utf8"
 
@@ -22,6 +23,38 @@ const (
 	EventTypeSQLClient
 )
 
+// Names of the detectors that can produce the spans
+const (
+	DetectorGoUprobes   = "go_uprobes"
+	DetectorHTTPParser  = "http_parser"
+	DetectorHTTP2Parser = "http2_parser"
+	DetectorGRPCParser  = "grpc_parser"
+	DetectorSQLParser   = "sql_parser"
+)
+
+// Transport protocols of the span connections, as defined by the network.transport convention
+const (
+	TransportTCP = "tcp"
+	TransportUDP = "udp"
+)
+
+// ConnectionReuse tells whether a client request was sent through an already established connection
+type ConnectionReuse uint8
+
+const (
+	ConnectionReuseUnknown ConnectionReuse = iota
+	ConnectionReused
+)
+
+// ConnectionSecurity tells whether a request was sent through a connection encrypted with TLS
+type ConnectionSecurity uint8
+
+const (
+	ConnectionSecurityUnknown ConnectionSecurity = iota
+	ConnectionPlaintext
+	ConnectionTLS
+)
+
 type IgnoreMode uint8
 
 const (
@@ -61,6 +94,7 @@ type Span struct {
 	Peer           string
 	Host           string
 	HostPort       int
+	PeerPort       int
 	Status         int
 	ContentLength  int64
 	RequestStart   int64
@@ -76,6 +110,41 @@ type Span struct {
 	HostName       string
 	OtherNamespace string
 	Statement      string
+	// RequestContentType and ResponseContentType contain the value of the Content-Type
+	// header of HTTP requests and responses, when it could be captured. The response
+	// headers are only captured for HTTP/2.
+	RequestContentType  string
+	ResponseContentType string
+	// RequestLine is the first line of an HTTP request (e.g. GET /foo?x=1 HTTP/1.1), when it could be captured.
+	RequestLine string
+	// ProtocolVersion is the version of the HTTP protocol (e.g. 1.1, 2 or 3), when it could be detected.
+	ProtocolVersion string
+	// ResponseLength is the size of the response, when it could be captured. Zero means unknown.
+	ResponseLength int64
+	// ResendCount is the number of times that an HTTP client request was resent (e.g. retried
+	// after a failed attempt), when it could be detected. The first attempt has a zero count.
+	ResendCount int
+	// EndUserID identifies the authenticated user of an HTTP server request
+	// (e.g. from the "sub" claim of a JWT), when it could be captured from its headers.
+	EndUserID string
+	// RequestHeaders contains the captured headers of the request (HTTP headers or
+	// gRPC metadata), with lowercase keys. It might be partial or nil.
+	RequestHeaders map[string][]string
+	// Detector is the name of the Beyla probe or protocol detector that generated the span
+	Detector string
+	// ConnectionReuse tells whether a client request reused an existing connection from the
+	// pool. Unknown if it could not be detected (e.g. for the first request of a connection).
+	ConnectionReuse ConnectionReuse
+	// ConnectionSecurity tells whether the connection of the request was encrypted with TLS.
+	// Unknown if it could not be detected.
+	ConnectionSecurity ConnectionSecurity
+	// LinkTraceID and LinkSpanID reference a related span (e.g. the server span that was
+	// being served when a client request was invoked), which is reported as a span link.
+	LinkTraceID trace2.TraceID
+	LinkSpanID  trace2.SpanID
+	// SamplingProbability is the probability with which the sampler that decided to export
+	// the span kept it. Zero if it is unknown.
+	SamplingProbability float64
 }
 
 func (s *Span) Inside(parent *Span) bool {
@@ -88,20 +157,32 @@ type Timings struct {
 	End          time.Time
 }
 
+// Timings converts the monotonic timestamps of the span to wall-clock time, according to the
+// current difference between the wall-clock and the monotonic clock.
 func (s *Span) Timings() Timings {
-	now := clocks.clock()
-	monoNow := clocks.monoClock()
-	startDelta := monoNow - time.Duration(s.Start)
-	endDelta := monoNow - time.Duration(s.End)
-	goStartDelta := monoNow - time.Duration(s.RequestStart)
+	return s.TimingsFrom(clocks.clock().Add(-clocks.monoClock()))
+}
 
+// TimingsFrom converts the monotonic timestamps of the span to wall-clock time, given the
+// wall-clock time at which the monotonic clock started (e.g. the boot time of the host).
+func (s *Span) TimingsFrom(monoStart time.Time) Timings {
 	return Timings{
-		RequestStart: now.Add(-goStartDelta),
-		Start:        now.Add(-startDelta),
-		End:          now.Add(-endDelta),
+		RequestStart: monoStart.Add(time.Duration(s.RequestStart)),
+		Start:        monoStart.Add(time.Duration(s.Start)),
+		End:          monoStart.Add(time.Duration(s.End)),
 	}
 }
 
+var bootTime = sync.OnceValue(func() time.Time {
+	return clocks.clock().Add(-clocks.monoClock())
+})
+
+// BootTime returns the wall-clock time at which the monotonic clock started. It is captured
+// the first time it is invoked, so all the spans converted from it share the same offset.
+func BootTime() time.Time {
+	return bootTime()
+}
+
 func (s *Span) IsValid() bool {
 	if (len(s.Method) > 0 && !utf8.ValidString(s.Method)) ||
 		(len(s.Path) > 0 && !utf8.ValidString(s.Path)) {
@@ -11,12 +11,19 @@ import (
 )
 
 // SpanOTELGetters returns the attributes.Getter function that returns the
-// OTEL attribute.KeyValue of a given attribute name.
-func SpanOTELGetters(name attr.Name) (attributes.Getter[*Span, attribute.KeyValue], bool) {
+// OTEL attribute.KeyValue of a given attribute name. The HTTP methods that aren't
+// known are reported as _OTHER.
+func SpanOTELGetters(knownMethods HTTPMethods) attributes.NamedGetters[*Span, attribute.KeyValue] {
+	return func(name attr.Name) (attributes.Getter[*Span, attribute.KeyValue], bool) {
+		return spanOTELGetter(name, knownMethods)
+	}
+}
+
+func spanOTELGetter(name attr.Name, knownMethods HTTPMethods) (attributes.Getter[*Span, attribute.KeyValue], bool) {
 	var getter attributes.Getter[*Span, attribute.KeyValue]
 	switch name {
 	case attr.HTTPRequestMethod:
-		getter = func(s *Span) attribute.KeyValue { return HTTPRequestMethod(s.Method) }
+		getter = func(s *Span) attribute.KeyValue { return HTTPRequestMethod(knownMethods.Normalize(s.Method)) }
 	case attr.HTTPResponseStatusCode:
 		getter = func(s *Span) attribute.KeyValue { return HTTPResponseStatusCode(s.Status) }
 	case attr.HTTPRoute:
@@ -44,13 +51,20 @@ func SpanOTELGetters(name attr.Name) (attributes.Getter[*Span, attribute.KeyValu
 }
 
 // SpanPromGetters returns the attributes.Getter function that returns the
-// Prometheus string value of a given attribute name.
+// Prometheus string value of a given attribute name. The HTTP methods that aren't
+// known are reported as _OTHER.
+func SpanPromGetters(knownMethods HTTPMethods) attributes.NamedGetters[*Span, string] {
+	return func(attrName attr.Name) (attributes.Getter[*Span, string], bool) {
+		return spanPromGetter(attrName, knownMethods)
+	}
+}
+
 // nolint:cyclop
-func SpanPromGetters(attrName attr.Name) (attributes.Getter[*Span, string], bool) {
+func spanPromGetter(attrName attr.Name, knownMethods HTTPMethods) (attributes.Getter[*Span, string], bool) {
 	var getter attributes.Getter[*Span, string]
 	switch attrName {
 	case attr.HTTPRequestMethod:
-		getter = func(s *Span) string { return s.Method }
+		getter = func(s *Span) string { return knownMethods.Normalize(s.Method) }
 	case attr.HTTPResponseStatusCode:
 		getter = func(s *Span) string { return strconv.Itoa(s.Status) }
 	case attr.HTTPRoute:
@@ -2,6 +2,7 @@ package request
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -21,3 +22,30 @@ func TestSpanClientServer(t *testing.T) {
 		assert.True(t, span.IsClientSpan())
 	}
 }
+
+func TestTimingsFrom(t *testing.T) {
+	boot := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	span := &Span{
+		RequestStart: int64(10 * time.Second),
+		Start:        int64(11 * time.Second),
+		End:          int64(12 * time.Second),
+	}
+	assert.Equal(t, Timings{
+		RequestStart: boot.Add(10 * time.Second),
+		Start:        boot.Add(11 * time.Second),
+		End:          boot.Add(12 * time.Second),
+	}, span.TimingsFrom(boot))
+}
+
+func TestTimings_CurrentOffset(t *testing.T) {
+	defer func(old converter) { clocks = old }(clocks)
+	now := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	clocks = converter{
+		clock:     func() time.Time { return now },
+		monoClock: func() time.Duration { return time.Hour },
+	}
+	span := &Span{RequestStart: int64(30 * time.Minute), Start: int64(30 * time.Minute), End: int64(40 * time.Minute)}
+	timings := span.Timings()
+	assert.Equal(t, now.Add(-30*time.Minute), timings.Start)
+	assert.Equal(t, now.Add(-20*time.Minute), timings.End)
+}
@@ -0,0 +1,53 @@
+package request
+
+import (
+	"encoding/hex"
+	"strings"
+
+	trace2 "go.opentelemetry.io/otel/trace"
+)
+
+const traceparentHeader = "traceparent"
+
+// ParseTraceparent parses the value of a W3C traceparent header
+// (https://www.w3.org/TR/trace-context/#traceparent-header), returning
+// its trace ID, parent span ID and trace flags.
+func ParseTraceparent(value string) (trace2.TraceID, trace2.SpanID, uint8, bool) {
+	parts := strings.Split(strings.TrimSpace(value), "-")
+	// future versions might append more fields
+	if len(parts) < 4 || len(parts[0]) != 2 || parts[0] == "ff" || (parts[0] == "00" && len(parts) != 4) {
+		return trace2.TraceID{}, trace2.SpanID{}, 0, false
+	}
+	traceID, err := trace2.TraceIDFromHex(parts[1])
+	if err != nil {
+		return trace2.TraceID{}, trace2.SpanID{}, 0, false
+	}
+	spanID, err := trace2.SpanIDFromHex(parts[2])
+	if err != nil {
+		return trace2.TraceID{}, trace2.SpanID{}, 0, false
+	}
+	flags, err := hex.DecodeString(parts[3])
+	if err != nil || len(flags) != 1 {
+		return trace2.TraceID{}, trace2.SpanID{}, 0, false
+	}
+	return traceID, spanID, flags[0], true
+}
+
+// SetContextFromHeaders sets the trace ID, parent span ID and flags of a server span from the
+// captured traceparent request header, unless the probes already provided the trace context.
+// Client spans are ignored, as their captured traceparent is the one they propagate to the
+// invoked service, which would make the client span its own parent.
+func (s *Span) SetContextFromHeaders() {
+	if s.TraceID.IsValid() || s.IsClientSpan() {
+		return
+	}
+	values := s.RequestHeaders[traceparentHeader]
+	if len(values) == 0 {
+		return
+	}
+	if traceID, parentID, flags, ok := ParseTraceparent(values[0]); ok {
+		s.TraceID = traceID
+		s.ParentSpanID = parentID
+		s.Flags = flags
+	}
+}
@@ -0,0 +1,66 @@
+package request
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	trace2 "go.opentelemetry.io/otel/trace"
+)
+
+func TestParseTraceparent(t *testing.T) {
+	traceID, parentID, flags, ok := ParseTraceparent("00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01")
+	assert.True(t, ok)
+	assert.Equal(t, "0af7651916cd43dd8448eb211c80319c", traceID.String())
+	assert.Equal(t, "b7ad6b7169203331", parentID.String())
+	assert.Equal(t, uint8(1), flags)
+
+	// future versions might have extra fields
+	_, _, _, ok = ParseTraceparent("cc-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-00-extra")
+	assert.True(t, ok)
+
+	for _, invalid := range []string{
+		"",
+		"00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331",
+		"00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01-extra",
+		"ff-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01",
+		"00-00000000000000000000000000000000-b7ad6b7169203331-01",
+		"00-0af7651916cd43dd8448eb211c80319c-0000000000000000-01",
+		"00-0af7651916cd43dd8448eb211c8031-b7ad6b7169203331-01",
+		"00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-1",
+	} {
+		_, _, _, ok := ParseTraceparent(invalid)
+		assert.False(t, ok, invalid)
+	}
+}
+
+func TestSetContextFromHeaders(t *testing.T) {
+	span := Span{Type: EventTypeHTTP, RequestHeaders: map[string][]string{
+		"traceparent": {"00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01"},
+	}}
+	span.SetContextFromHeaders()
+	assert.Equal(t, "0af7651916cd43dd8448eb211c80319c", span.TraceID.String())
+	assert.Equal(t, "b7ad6b7169203331", span.ParentSpanID.String())
+	assert.Equal(t, uint8(1), span.Flags)
+
+	// the trace context from the probes takes precedence
+	span = Span{
+		TraceID:      trace2.TraceID{1},
+		ParentSpanID: trace2.SpanID{2},
+		RequestHeaders: map[string][]string{
+			"traceparent": {"00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01"},
+		},
+	}
+	span.SetContextFromHeaders()
+	assert.Equal(t, trace2.TraceID{1}, span.TraceID)
+	assert.Equal(t, trace2.SpanID{2}, span.ParentSpanID)
+	// the traceparent of a client request is the one propagated by the client span itself
+	span = Span{
+		Type: EventTypeHTTPClient,
+		RequestHeaders: map[string][]string{
+			"traceparent": {"00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01"},
+		},
+	}
+	span.SetContextFromHeaders()
+	assert.False(t, span.TraceID.IsValid())
+	assert.False(t, span.ParentSpanID.IsValid())
+}
@@ -0,0 +1,166 @@
+package sqlprune
+
+import (
+	"regexp"
+	"strings"
+)
+
+const placeholder = '?'
+
+// inList matches the lists of placeholders inside IN clauses, e.g. IN (?, ?, ?)
+var inList = regexp.MustCompile(`(?i)\b(IN)\s*\(\s*\?(?:\s*,\s*\?)*\s*\)`)
+
+// NormalizeStatement replaces the literal values of the SQL query (strings, numbers and the
+// elements of IN lists) by ? placeholders, so the query does not disclose the values and can be
+// grouped with the rest of executions of the same query. Identifiers, comments and the
+// formatting of the query are kept.
+//
+//nolint:cyclop
+func NormalizeStatement(query string) string {
+	sb := strings.Builder{}
+	sb.Grow(len(query))
+	for i := 0; i < len(query); {
+		c := query[i]
+		switch {
+		case c == '\'':
+			i = skipString(query, i)
+			sb.WriteByte(placeholder)
+		case c == '"' || c == '`' || isCommentStart(query, i):
+			// quoted identifiers and comments are kept as they are
+			end := skipIdentifierOrComment(query, i)
+			sb.WriteString(query[i:end])
+			i = end
+		case isDigit(c) && (i == 0 || !isIdentifierChar(query[i-1])):
+			i = skipNumber(query, i)
+			sb.WriteByte(placeholder)
+		case isIdentifierChar(c):
+			// copy the whole identifier, so the digits inside it are not replaced
+			start := i
+			for i < len(query) && isIdentifierChar(query[i]) {
+				i++
+			}
+			sb.WriteString(query[start:i])
+		default:
+			sb.WriteByte(c)
+			i++
+		}
+	}
+	return inList.ReplaceAllString(sb.String(), "$1 (?)")
+}
+
+func isCommentStart(query string, i int) bool {
+	return strings.HasPrefix(query[i:], "--") || strings.HasPrefix(query[i:], "/*")
+}
+
+// skipIdentifierOrComment returns the position after the end of the quoted identifier or
+// the comment starting at i. Line comments end before the line break.
+func skipIdentifierOrComment(query string, i int) int {
+	var end int
+	switch {
+	case strings.HasPrefix(query[i:], "--"):
+		if end = strings.IndexByte(query[i:], '\n'); end >= 0 {
+			return i + end
+		}
+	case strings.HasPrefix(query[i:], "/*"):
+		if end = strings.Index(query[i+2:], "*/"); end >= 0 {
+			return i + end + 4
+		}
+	default:
+		if end = strings.IndexByte(query[i+1:], query[i]); end >= 0 {
+			return i + end + 2
+		}
+	}
+	return len(query)
+}
+
+// skipString returns the position after the end of the string literal starting at i,
+// considering both doubled quotes and backslash-escaped quotes
+func skipString(query string, i int) int {
+	for i++; i < len(query); i++ {
+		switch query[i] {
+		case '\\':
+			i++
+		case '\'':
+			if i+1 < len(query) && query[i+1] == '\'' {
+				i++
+				continue
+			}
+			return i + 1
+		}
+	}
+	return len(query)
+}
+
+// skipNumber returns the position after the end of the numeric literal starting at i,
+// including decimals, exponents and hexadecimal numbers
+func skipNumber(query string, i int) int {
+	if strings.HasPrefix(query[i:], "0x") || strings.HasPrefix(query[i:], "0X") {
+		i += 2
+		for i < len(query) && isHexDigit(query[i]) {
+			i++
+		}
+		return i
+	}
+	for i < len(query) && (isDigit(query[i]) || query[i] == '.') {
+		i++
+	}
+	if i < len(query) && (query[i] == 'e' || query[i] == 'E') {
+		j := i + 1
+		if j < len(query) && (query[j] == '+' || query[j] == '-') {
+			j++
+		}
+		if j < len(query) && isDigit(query[j]) {
+			for i = j; i < len(query) && isDigit(query[i]); i++ {
+			}
+		}
+	}
+	return i
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isHexDigit(c byte) bool {
+	return isDigit(c) || (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F')
+}
+
+func isIdentifierChar(c byte) bool {
+	return c == '_' || c == '$' || isDigit(c) || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || c >= 0x80
+}
+
+// StatementsCount returns the number of non-empty statements of a query, separated by semicolons
+// (e.g. a batch of INSERT statements). The semicolons inside strings, quoted identifiers and
+// comments are ignored.
+func StatementsCount(query string) int {
+	count := 0
+	empty := true
+	for i := 0; i < len(query); {
+		c := query[i]
+		switch {
+		case c == '\'':
+			i = skipString(query, i)
+			empty = false
+		case isCommentStart(query, i):
+			i = skipIdentifierOrComment(query, i)
+		case c == '"' || c == '`':
+			i = skipIdentifierOrComment(query, i)
+			empty = false
+		case c == ';':
+			if !empty {
+				count++
+			}
+			empty = true
+			i++
+		default:
+			if c != ' ' && c != '\t' && c != '\n' && c != '\r' {
+				empty = false
+			}
+			i++
+		}
+	}
+	if !empty {
+		count++
+	}
+	return count
+}
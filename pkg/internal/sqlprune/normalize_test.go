@@ -0,0 +1,51 @@
+package sqlprune
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizeStatement(t *testing.T) {
+	for _, tc := range []struct {
+		query    string
+		expected string
+	}{
+		{query: "SELECT * FROM users", expected: "SELECT * FROM users"},
+		{query: "SELECT * FROM users WHERE id = 123", expected: "SELECT * FROM users WHERE id = ?"},
+		{query: "SELECT * FROM users WHERE email = 'jane@example.com' AND age > 21.5",
+			expected: "SELECT * FROM users WHERE email = ? AND age > ?"},
+		{query: "SELECT * FROM users WHERE name = 'O''Brien' OR name = 'it\\'s'",
+			expected: "SELECT * FROM users WHERE name = ? OR name = ?"},
+		{query: "SELECT * FROM orders WHERE id IN (1, 2, 3) AND status in ('a','b')",
+			expected: "SELECT * FROM orders WHERE id IN (?) AND status in (?)"},
+		{query: "INSERT INTO t2 (c1, c2) VALUES (-1.5e10, 0xFF)", expected: "INSERT INTO t2 (c1, c2) VALUES (-?, ?)"},
+		{query: "UPDATE accounts SET balance = 100 WHERE token = 'secret' -- set 42",
+			expected: "UPDATE accounts SET balance = ? WHERE token = ? -- set 42"},
+		{query: `SELECT "col1", ` + "`t3`.x" + ` FROM /* 7 */ table9 WHERE $1 = 'x'`,
+			expected: `SELECT "col1", ` + "`t3`.x" + ` FROM /* 7 */ table9 WHERE $1 = ?`},
+		{query: "SELECT * FROM users WHERE name = 'unterminated", expected: "SELECT * FROM users WHERE name = ?"},
+	} {
+		t.Run(tc.query, func(t *testing.T) {
+			assert.Equal(t, tc.expected, NormalizeStatement(tc.query))
+		})
+	}
+}
+
+func TestStatementsCount(t *testing.T) {
+	for _, tc := range []struct {
+		query    string
+		expected int
+	}{
+		{query: "", expected: 0},
+		{query: "INSERT INTO users VALUES (1, 'a')", expected: 1},
+		{query: "INSERT INTO users VALUES (1, 'a');", expected: 1},
+		{query: "INSERT INTO users VALUES (1, 'a'); INSERT INTO users VALUES (2, 'b');\n INSERT INTO users VALUES (3, 'c')", expected: 3},
+		{query: "INSERT INTO users VALUES (1, 'a;b'); -- first; second\n;; /* ; */", expected: 1},
+		{query: `UPDATE "a;b" SET x = 1; UPDATE t SET y = 2`, expected: 2},
+	} {
+		t.Run(tc.query, func(t *testing.T) {
+			assert.Equal(t, tc.expected, StatementsCount(tc.query))
+		})
+	}
+}
@@ -64,6 +64,18 @@ type ID struct {
 	Instance    string
 
 	Metadata map[attr.Name]string
+
+	// PodLabels are the labels of the Kubernetes Pod running the service, when known. They are
+	// not exported as such, but can be used to derive other attributes.
+	PodLabels map[string]string
+
+	// ContainerImage is the image reference (e.g. docker.io/grafana/beyla:1.5) of the container
+	// running the service, when known
+	ContainerImage string
+
+	// ExePath and CmdLine describe the instrumented process, when known
+	ExePath string
+	CmdLine string
 }
 
 func (i *ID) String() string {
@@ -104,6 +104,17 @@ func (id *Database) AddProcess(pid uint32) {
 	id.cntMut.Unlock()
 }
 
+// ContainerID returns the ID of the container running in the passed namespace
+func (id *Database) ContainerID(pidNamespace uint32) (string, bool) {
+	id.nsMut.RLock()
+	defer id.nsMut.RUnlock()
+	info, ok := id.namespaces[pidNamespace]
+	if !ok {
+		return "", false
+	}
+	return info.ContainerID, true
+}
+
 // OwnerPodInfo returns the information of the pod owning the passed namespace
 func (id *Database) OwnerPodInfo(pidNamespace uint32) (*kube.PodInfo, bool) {
 	id.podsCacheMut.RLock()
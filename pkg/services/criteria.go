@@ -39,6 +39,8 @@ type ProcessInfo struct {
 	PPid      int32
 	ExePath   string
 	OpenPorts []uint32
+	// CmdLine is the command line of the process, with the arguments separated by spaces
+	CmdLine string
 }
 
 // DiscoveryConfig for the discover.ProcessFinder pipeline
@@ -82,6 +82,7 @@ func KubeDecoratorProvider(
 // production implementer: kube.Database
 type kubeDatabase interface {
 	OwnerPodInfo(pidNamespace uint32) (*kube.PodInfo, bool)
+	ContainerID(pidNamespace uint32) (string, bool)
 }
 
 type metadataDecorator struct {
@@ -103,6 +104,9 @@ func (md *metadataDecorator) nodeLoop(in <-chan []request.Span, out chan<- []req
 func (md *metadataDecorator) do(span *request.Span) {
 	if podInfo, ok := md.db.OwnerPodInfo(span.Pid.Namespace); ok {
 		appendMetadata(span, podInfo)
+		if containerID, ok := md.db.ContainerID(span.Pid.Namespace); ok {
+			span.ServiceID.ContainerImage = podInfo.ContainerImages[containerID]
+		}
 	} else {
 		// do not leave the service attributes map as nil
 		span.ServiceID.Metadata = map[attr.Name]string{}
@@ -120,6 +124,7 @@ func appendMetadata(span *request.Span, info *kube.PodInfo) {
 		span.ServiceID.Namespace = info.Namespace
 	}
 	span.ServiceID.UID = svc.UID(info.UID)
+	span.ServiceID.PodLabels = info.Labels
 
 	// if, in the future, other pipeline steps modify the service metadata, we should
 	// replace the map literal by individual entry insertions
@@ -130,9 +135,10 @@ func appendMetadata(span *request.Span, info *kube.PodInfo) {
 		attr.K8sPodUID:        string(info.UID),
 		attr.K8sPodStartTime:  info.StartTimeStr,
 	}
-	owner := info.Owner
-	for owner != nil {
-		span.ServiceID.Metadata[owner.Type.LabelName()] = owner.Name
-		owner = owner.Owner
+	// the workload attributes (e.g. k8s.deployment.name) are only added for the resolved owners
+	for owner := info.Owner; owner != nil; owner = owner.Owner {
+		if owner.Type != kube.OwnerUnknown && owner.Name != "" {
+			span.ServiceID.Metadata[owner.Type.LabelName()] = owner.Name
+		}
 	}
 }
@@ -23,10 +23,13 @@ func TestDecoration(t *testing.T) {
 		12: &kube.PodInfo{
 			ObjectMeta: v1.ObjectMeta{
 				Name: "pod-12", Namespace: "the-ns", UID: "uid-12",
+				Labels: map[string]string{"env": "staging"},
 			},
-			NodeName:     "the-node",
-			StartTimeStr: "2020-01-02 12:12:56",
-			Owner:        &kube.Owner{Type: kube.OwnerDeployment, Name: "deployment-12"},
+			ContainerIDs:    []string{"container-12"},
+			ContainerImages: map[string]string{"container-12": "docker.io/grafana/beyla:1.5", "sidecar-12": "envoy:1.29"},
+			NodeName:        "the-node",
+			StartTimeStr:    "2020-01-02 12:12:56",
+			Owner:           &kube.Owner{Type: kube.OwnerDeployment, Name: "deployment-12"},
 		},
 		34: &kube.PodInfo{
 			ObjectMeta: v1.ObjectMeta{
@@ -43,6 +46,14 @@ func TestDecoration(t *testing.T) {
 			NodeName:     "the-node",
 			StartTimeStr: "2020-01-02 12:56:56",
 		},
+		90: &kube.PodInfo{
+			ObjectMeta: v1.ObjectMeta{
+				Name: "db-0", Namespace: "the-ns", UID: "uid-90",
+			},
+			NodeName:     "the-node",
+			StartTimeStr: "2020-01-02 12:09:00",
+			Owner:        &kube.Owner{Type: kube.OwnerStatefulSet, Name: "db"},
+		},
 	}}
 	inputCh, outputhCh := make(chan []request.Span, 10), make(chan []request.Span, 10)
 	defer close(inputCh)
@@ -64,6 +75,8 @@ func TestDecoration(t *testing.T) {
 			"k8s.deployment.name": "deployment-12",
 			"k8s.pod.start_time":  "2020-01-02 12:12:56",
 		}, deco[0].ServiceID.Metadata)
+		assert.Equal(t, map[string]string{"env": "staging"}, deco[0].ServiceID.PodLabels)
+		assert.Equal(t, "docker.io/grafana/beyla:1.5", deco[0].ServiceID.ContainerImage)
 	})
 	t.Run("pod info without deployment should set replicaset as name", func(t *testing.T) {
 		inputCh <- []request.Span{{
@@ -82,6 +95,22 @@ func TestDecoration(t *testing.T) {
 			"k8s.pod.start_time":  "2020-01-02 12:34:56",
 		}, deco[0].ServiceID.Metadata)
 	})
+	t.Run("statefulset-owned pod info should set statefulset as name", func(t *testing.T) {
+		inputCh <- []request.Span{{
+			Pid: request.PidInfo{Namespace: 90}, ServiceID: svc.ID{AutoName: true},
+		}}
+		deco := testutil.ReadChannel(t, outputhCh, timeout)
+		require.Len(t, deco, 1)
+		assert.Equal(t, "db", deco[0].ServiceID.Name)
+		assert.Equal(t, map[attr.Name]string{
+			"k8s.node.name":        "the-node",
+			"k8s.namespace.name":   "the-ns",
+			"k8s.statefulset.name": "db",
+			"k8s.pod.name":         "db-0",
+			"k8s.pod.uid":          "uid-90",
+			"k8s.pod.start_time":   "2020-01-02 12:09:00",
+		}, deco[0].ServiceID.Metadata)
+	})
 	t.Run("pod info with only pod name should set pod name as name", func(t *testing.T) {
 		inputCh <- []request.Span{{
 			Pid: request.PidInfo{Namespace: 56}, ServiceID: svc.ID{AutoName: true},
@@ -133,3 +162,10 @@ func (f fakeDatabase) OwnerPodInfo(pidNamespace uint32) (*kube.PodInfo, bool) {
 	pi, ok := f[pidNamespace]
 	return pi, ok
 }
+
+func (f fakeDatabase) ContainerID(pidNamespace uint32) (string, bool) {
+	if pi, ok := f[pidNamespace]; ok && len(pi.ContainerIDs) > 0 {
+		return pi.ContainerIDs[0], true
+	}
+	return "", false
+}